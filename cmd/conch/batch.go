@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// Output formats for transcribe-dir
+const (
+	batchFormatText = "txt"
+	batchFormatSRT  = "srt"
+	batchFormatJSON = "json"
+)
+
+// transcribeDir transcribes every WAV file in dir, writing a transcript next
+// to each input or into --out. args are the subcommand's arguments after the
+// directory. A file that fails is reported and skipped, and the batch ends
+// with a summary on stderr.
+func transcribeDir(whisperSvc *speech.WhisperServerService, dir string, args []string) error {
+	fs := flag.NewFlagSet("transcribe-dir", flag.ContinueOnError)
+	outDir := fs.String("out", "", "Directory for the transcripts, defaults to next to each input")
+	format := fs.String("format", batchFormatText, "Transcript format: txt, srt or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if dir == "" {
+		return fmt.Errorf("usage: conch [flags] transcribe-dir <dir> [--out dir] [--format txt|srt|json]")
+	}
+	switch *format {
+	case batchFormatText:
+	case batchFormatSRT, batchFormatJSON:
+		// Subtitles and JSON need the segment timings
+		whisperSvc.WithResponseFormat(speech.ResponseFormatVerboseJSON)
+	default:
+		return fmt.Errorf("unknown format %q, expected txt, srt or json", *format)
+	}
+
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.wav"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(inputs)
+	if len(inputs) == 0 {
+		return fmt.Errorf("no .wav files in %s", dir)
+	}
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %v", err)
+		}
+	}
+
+	if err := initializeInterruptible(whisperSvc); err != nil {
+		return err
+	}
+	defer whisperSvc.Cleanup()
+
+	// The server decodes one request at a time, so files go through in order
+	start := time.Now()
+	var done, failed int
+	var audioTotal, processingTotal time.Duration
+	for i, input := range inputs {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(inputs), filepath.Base(input))
+
+		result, err := transcribeBatchFile(whisperSvc, input)
+		if err == nil {
+			err = writeTranscript(batchOutputPath(input, *outDir, *format), *format, result)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  error: %v\n", err)
+			failed++
+			continue
+		}
+		done++
+		audioTotal += result.AudioDuration
+		processingTotal += result.ProcessingDuration
+	}
+
+	fmt.Fprintf(os.Stderr, "Transcribed %d of %d files (%d failed), %v of audio in %v",
+		done, len(inputs), failed, audioTotal.Round(time.Second), time.Since(start).Round(time.Second))
+	if audioTotal > 0 {
+		fmt.Fprintf(os.Stderr, ", average RTF %.2gx", processingTotal.Seconds()/audioTotal.Seconds())
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if done == 0 {
+		return fmt.Errorf("all %d files failed", len(inputs))
+	}
+	return nil
+}
+
+// transcribeBatchFile loads and transcribes one WAV file
+func transcribeBatchFile(whisperSvc *speech.WhisperServerService, path string) (*speech.WhisperServerResult, error) {
+	audio, err := speech.LoadWavFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	return whisperSvc.Transcribe(audio)
+}
+
+// batchOutputPath is the transcript path for input, with the format's
+// extension, in outDir or else next to the input
+func batchOutputPath(input, outDir, format string) string {
+	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + "." + format
+	if outDir == "" {
+		return filepath.Join(filepath.Dir(input), name)
+	}
+	return filepath.Join(outDir, name)
+}
+
+// writeTranscript writes result to path in the given format
+func writeTranscript(path, format string, result *speech.WhisperServerResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create transcript: %v", err)
+	}
+
+	switch format {
+	case batchFormatSRT:
+		err = writeSRT(f, result)
+	case batchFormatJSON:
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(result)
+	default:
+		_, err = fmt.Fprintln(f, strings.TrimSpace(result.Text))
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write transcript: %v", err)
+	}
+	return nil
+}
+
+// writeSRT writes the result's segments as SRT cues. Without segments the
+// whole text becomes one cue spanning the audio.
+func writeSRT(w io.Writer, result *speech.WhisperServerResult) error {
+	segments := result.Segments
+	if len(segments) == 0 {
+		segments = []speech.WhisperSegment{{Start: 0, End: result.AudioDuration.Seconds(), Text: result.Text}}
+	}
+	for i, seg := range segments {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1,
+			srtTimestamp(seg.Start), srtTimestamp(seg.End), strings.TrimSpace(seg.Text))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// srtTimestamp formats seconds as HH:MM:SS,mmm
+func srtTimestamp(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d",
+		int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Milliseconds()%1000)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// TestSRTTimestamp verifies millisecond rounding, including carries into the
+// next second, minute and hour
+func TestSRTTimestamp(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "00:00:00,000"},
+		{1.5, "00:00:01,500"},
+		{1.2346, "00:00:01,235"},
+		{59.9996, "00:01:00,000"},
+		{3599.9999, "01:00:00,000"},
+		{3661.25, "01:01:01,250"},
+		{36000, "10:00:00,000"},
+	}
+
+	for _, tt := range tests {
+		if got := srtTimestamp(tt.seconds); got != tt.want {
+			t.Errorf("srtTimestamp(%v) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+// TestWriteSRT verifies cues are numbered from one, and that a result without
+// segments becomes a single cue spanning the audio
+func TestWriteSRT(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *speech.WhisperServerResult
+		want   string
+	}{
+		{
+			name: "segments",
+			result: &speech.WhisperServerResult{Segments: []speech.WhisperSegment{
+				{Start: 0, End: 1.5, Text: " Hello there."},
+				{Start: 1.5, End: 3.25, Text: " General Kenobi. "},
+			}},
+			want: "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n" +
+				"2\n00:00:01,500 --> 00:00:03,250\nGeneral Kenobi.\n\n",
+		},
+		{
+			name:   "no segments",
+			result: &speech.WhisperServerResult{Text: " Just text ", AudioDuration: 2 * time.Second},
+			want:   "1\n00:00:00,000 --> 00:00:02,000\nJust text\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeSRT(&buf, tt.result); err != nil {
+				t.Fatalf("writeSRT failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+// TestBatchOutputPath verifies the extension is replaced and the transcript
+// goes next to the input unless an output directory is given
+func TestBatchOutputPath(t *testing.T) {
+	tests := []struct {
+		input, outDir, format string
+		want                  string
+	}{
+		{filepath.Join("rec", "a.wav"), "", batchFormatText, filepath.Join("rec", "a.txt")},
+		{filepath.Join("rec", "a.wav"), "out", batchFormatSRT, filepath.Join("out", "a.srt")},
+		{filepath.Join("rec", "take.1.WAV"), "", batchFormatJSON, filepath.Join("rec", "take.1.json")},
+		{"a.wav", "", batchFormatText, "a.txt"},
+	}
+
+	for _, tt := range tests {
+		if got := batchOutputPath(tt.input, tt.outDir, tt.format); got != tt.want {
+			t.Errorf("batchOutputPath(%q, %q, %q) = %q, want %q", tt.input, tt.outDir, tt.format, got, tt.want)
+		}
+	}
+}
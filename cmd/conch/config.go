@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// Sources a configuration value can come from
+const (
+	sourceDefault = "default"
+	sourceFlag    = "flag"
+)
+
+// configEnv names the environment variables that can set a config field
+var configEnv = map[string]string{
+	"ModelPath":  "WHISPER_MODEL",
+	"ServerPath": "WHISPER_BIN",
+}
+
+// configFlags names the flags that set a config field
+var configFlags = map[string][]string{
+	"ModelPath":     {"model"},
+	"Language":      {"language"},
+	"InitialPrompt": {"prompt", "prompt-file"},
+	"GPULayers":     {"gpu-layers"},
+	"FlashAttn":     {"flash-attn"},
+}
+
+// configUnused lists the config fields whisper-server never receives, so
+// setting them has no effect on transcriptions
+var configUnused = map[string]bool{
+	"Translate":    true,
+	"WordThold":    true,
+	"PrintSpecial": true,
+	"NoTimestamps": true,
+}
+
+// flagEnv names the environment variables a flag falls back to
+var flagEnv = map[string]string{
+	"ui":      "CONCH_UI",
+	"api-key": "OPENAI_API_KEY",
+}
+
+// secretFlags are flags whose values aren't printed
+var secretFlags = map[string]bool{
+	"api-key": true,
+}
+
+// printConfig prints the effective whisper server configuration and every
+// option, each with the source it came from: a flag, an environment variable
+// or the built-in default.
+func printConfig(whisperSvc *speech.WhisperServerService) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "# whisper server")
+	config := whisperSvc.Config()
+	defaults := speech.NewDefaultWhisperServerConfig()
+	value := reflect.ValueOf(config)
+	defaultValue := reflect.ValueOf(*defaults)
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		field := value.Field(i).Interface()
+		source := configSource(name, field, defaultValue.Field(i).Interface())
+		if configUnused[name] {
+			source += " (not sent to the server)"
+		}
+		fmt.Fprintf(w, "%s\t%v\t%s\n", name, field, source)
+	}
+
+	fmt.Fprintln(w, "\n# options")
+	flag.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" {
+			value = "(hidden)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, value, flagSource(f.Name))
+	})
+	debug := sourceDefault
+	if os.Getenv("DEBUG") != "" {
+		debug = "env DEBUG"
+	}
+	fmt.Fprintf(w, "debug\t%v\t%s\n", os.Getenv("DEBUG") != "", debug)
+	return w.Flush()
+}
+
+// configSource reports where the config field name got its value from
+func configSource(name string, value, defaultValue interface{}) string {
+	for _, f := range configFlags[name] {
+		if flagSet(f) {
+			return sourceFlag + " --" + f
+		}
+	}
+	if env, ok := configEnv[name]; ok && os.Getenv(env) != "" {
+		return "env " + env
+	}
+	if !reflect.DeepEqual(value, defaultValue) {
+		return sourceFlag
+	}
+	return sourceDefault
+}
+
+// flagSource reports where the flag called name got its value from
+func flagSource(name string) string {
+	if flagSet(name) {
+		return sourceFlag
+	}
+	if env, ok := flagEnv[name]; ok && os.Getenv(env) != "" {
+		return "env " + env
+	}
+	return sourceDefault
+}
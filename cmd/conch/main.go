@@ -1,22 +1,390 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/marcinja/conch/pkg/api"
 	"github.com/marcinja/conch/pkg/common"
+	"github.com/marcinja/conch/pkg/hotkey"
 	"github.com/marcinja/conch/pkg/speech"
 	"github.com/marcinja/conch/pkg/status"
-	"github.com/marcinja/conch/pkg/terminal" // Using bubbletea
-	// "github.com/marcinja/conch/pkg/terminal_tview" // Using tview
+	"github.com/marcinja/conch/pkg/terminal"
 )
 
+// UI is a frontend driving the speech, whisper and status services until the
+// user quits
+type UI interface {
+	Run() error
+}
+
+var _ UI = (*terminal.TerminalApp)(nil)
+
+// checkUI validates the frontend chosen with --ui or CONCH_UI. Only the
+// bubbletea frontend is built in; the older tview frontend was not carried
+// over and is rejected with an explanation rather than silently ignored.
+func checkUI(name string) error {
+	switch name {
+	case "bubbletea":
+		return nil
+	case "tview":
+		return fmt.Errorf("the tview frontend is not available in this build, use bubbletea")
+	default:
+		return fmt.Errorf("unknown frontend %q, expected bubbletea", name)
+	}
+}
+
+// printModels lists the whisper models available in dir
+func printModels(dir string) error {
+	models, err := speech.DiscoverModels(dir)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		fmt.Printf("No ggml-*.bin models found in %s\n", dir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tPATH")
+	for _, model := range models {
+		fmt.Fprintf(w, "%s\t%.1f MB\t%s\n", model.Name, float64(model.Size)/(1024*1024), model.Path)
+	}
+	return w.Flush()
+}
+
+// transcribeFile transcribes the WAV file at path, or stdin when path is "-",
+// and prints the text to stdout. With showRTF the real-time factor goes to stderr.
+func transcribeFile(whisperSvc *speech.WhisperServerService, path string, showRTF bool) error {
+	var audio *speech.AudioData
+	var err error
+	switch path {
+	case "":
+		return fmt.Errorf("usage: conch [flags] transcribe <file.wav|->")
+	case "-":
+		// Stdin isn't seekable, ReadWav reads the chunks sequentially
+		audio, err = speech.ReadWav(os.Stdin)
+	default:
+		audio, err = speech.LoadWavFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audio: %v", err)
+	}
+
+	if err := initializeInterruptible(whisperSvc); err != nil {
+		return err
+	}
+	defer whisperSvc.Cleanup()
+
+	result, err := whisperSvc.Transcribe(audio)
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.TrimSpace(result.Text))
+	if showRTF {
+		fmt.Fprintf(os.Stderr, "RTF %.2gx (%v audio in %v)\n", result.RTF(),
+			result.AudioDuration.Round(time.Millisecond), result.ProcessingDuration.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// initializeInterruptible starts the whisper server, letting Ctrl+C or
+// SIGTERM abort a slow model load. The signals get their default behavior
+// back once the server is up.
+func initializeInterruptible(whisperSvc *speech.WhisperServerService) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return whisperSvc.InitializeContext(ctx)
+}
+
+// newEnsembleService creates a whisper server configured like whisperSvc but
+// running modelName, on a port of its own. It isn't persistent, since the
+// lockfile records a single server.
+func newEnsembleService(whisperSvc *speech.WhisperServerService, modelsDir, modelName string) (*speech.WhisperServerService, error) {
+	modelPath, err := speech.ResolveModel(modelsDir, modelName)
+	if err != nil {
+		return nil, err
+	}
+	config := whisperSvc.Config()
+	config.ModelPath = modelPath
+	config.Port++
+	return speech.NewWhisperServerService().WithConfig(&config).WithAutoPort(true), nil
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
+	derepeat := flag.Bool("derepeat", false, "Collapse phrases whisper repeats in a loop (\"thank you thank you ...\") to one instance")
+	derepeatLimit := flag.Int("derepeat-limit", speech.DefaultMaxRepeats, "How many times in a row a phrase may repeat before --derepeat collapses it")
+	autoPort := flag.Bool("auto-port", false, "Start the whisper server on the next free port if its port is taken, instead of sharing the server there")
+	persistentServer := flag.Bool("persistent-server", false, "Leave the whisper server running on exit and reuse it on the next launch")
+	modelsDir := flag.String("models-dir", speech.DefaultModelsDir(), "Directory to search for ggml-*.bin whisper models")
+	listModels := flag.Bool("list-models", false, "List the whisper models in the models directory and exit")
+	modelName := flag.String("model", "", "Whisper model to use, by short name (e.g. tiny, base.en) or path")
+	prompt := flag.String("prompt", "", "Initial prompt to bias transcription towards domain vocabulary")
+	promptFile := flag.String("prompt-file", "", "File containing the initial prompt, e.g. a glossary of terms")
+	autoCopyAfter := flag.Duration("auto-copy-after", 0, "Accumulate transcriptions and copy them after this much silence (e.g. 3s), 0 disables")
+	readyTimeout := flag.Duration("ready-timeout", speech.DefaultReadyTimeout, "How long to wait for the whisper server to load its model")
+	healthCheck := flag.Duration("health-check", 0, "Ping the whisper server this often and restart it if it hangs (0 disables)")
+	drain := flag.Duration("drain", 0, "On exit, wait up to this long for a transcription in progress to finish (0 stops at once)")
+	historySize := flag.Int("history-size", terminal.DefaultHistoryLimit, "Number of transcriptions to keep in the history, 0 for unlimited")
+	historyMaxChars := flag.Int("history-max-chars", 0, "Bound the history's total size in characters, 0 for unlimited")
+	outputs := flag.String("output", "", "Where copied text goes, comma separated: clipboard (default), stdout, file:PATH")
+	execCmd := flag.String("exec", "", "Also run this shell command with each copied text, {} is replaced by the quoted text, else it's on stdin")
+	interimCopy := flag.Bool("interim-copy", false, "Copy each transcription to the clipboard in the background as soon as it arrives")
+	language := flag.String("language", "", "Spoken language code (e.g. en, es), or auto to detect it and show it in the status bar")
+	maxFallbacks := flag.Int("max-fallbacks", -1, "Cap whisper's higher-temperature retries on hard audio, 0 disables fallback (faster, less accurate)")
+	confidenceRetry := flag.Float64("confidence-retry", 0, "Re-transcribe clips whose confidence (0-1) is below this with a wider beam search, 0 disables")
+	mergeGap := flag.Duration("merge-gap", 0, "Join recordings separated by pauses shorter than this (e.g. 800ms) into one transcription, 0 disables")
+	endpointExtension := flag.Duration("endpoint-extension", 0, "Wait this much longer (e.g. 600ms) to end a recording while speech trails off near the threshold, 0 disables")
+	endpointBand := flag.Float64("endpoint-band", 0.5, "Level counted as trailing-off speech, as a fraction of the voice threshold (0-1)")
+	vadThreshold := flag.Int64("vad-threshold", speech.VadThreshold, "Input level treated as speech, tune it live with the v key")
+	testTone := flag.Bool("test-tone", false, "Feed tone bursts instead of microphone input, for smoke tests without a microphone")
+	gain := flag.Float64("gain", 1.0, "Digital gain applied to the microphone input, for quiet or hot microphones")
+	frameSize := flag.Int("frame-size", speech.AudioSamples, "Samples captured per frame, a power of two (256-32768). Smaller reacts to speech sooner but uses more CPU")
+	inactivityTimeout := flag.Duration("inactivity-timeout", 0, "Pause capture after this long without speech (e.g. 10m) to save power, 0 disables")
+	inactivityResume := flag.Duration("inactivity-resume", 0, "Resume a paused capture automatically after this long, 0 waits for a key press")
+	voiceConfirm := flag.Duration("voice-confirm", 0, "Make clear and quit wait this long (e.g. 10s) for a spoken confirmation, 0 disables")
+	confirmPhrases := flag.String("confirm-phrases", "yes,confirm", "Comma separated phrases that confirm an action with --voice-confirm")
+	wakeWord := flag.String("wake-word", "", "Only dictate after a transcription starting with this phrase, e.g. \"hey conch\"")
+	wakeTimeout := flag.Duration("wake-timeout", terminal.DefaultWakeTimeout, "How long dictation stays awake after the wake word without speech")
+	cancelPhrases := flag.String("cancel-phrases", "no,cancel", "Comma separated phrases that cancel an action with --voice-confirm")
+	registersFile := flag.String("registers-file", "", "Save the text registers (a-z) to this file so they're kept across sessions")
+	logFile := flag.String("log-file", "", "Append log messages to this file while the UI runs, they're also shown with the logs key (l)")
+	bell := flag.Bool("bell", false, "Ring the terminal bell when a transcription completes")
+	flash := flag.Bool("flash", false, "Flash the status bar when a transcription completes")
+	keyBindings := flag.String("keys", "", "Override key bindings, e.g. copy=y,clear=x/X (actions: quit, copy, clear, save, replay, play, cancel, help)")
+	serveAddr := flag.String("serve", "", "Expose an HTTP API on this address (e.g. :9000, localhost only unless a host is given)")
+	rollingBuffer := flag.Duration("rolling-buffer", 0, "Keep the last N seconds of audio (e.g. 30s) so the replay key can transcribe speech the VAD missed, 0 disables")
+	uiName := flag.String("ui", "bubbletea", "Terminal frontend to use (bubbletea), also settable with CONCH_UI")
+	globalHotkey := flag.String("global-hotkey", "", "Helper command reporting a system-wide push-to-talk key as down/up lines on stdout (see README)")
+	gpuLayers := flag.Int("gpu-layers", 0, "Number of model layers to offload to the GPU, needs a GPU-enabled whisper-server, 0 disables")
+	flashAttn := flag.Bool("flash-attn", false, "Enable flash attention, needs a GPU-enabled whisper-server")
+	serverArgs := flag.String("server-args", "", "Extra whisper-server arguments, space separated (e.g. \"--flash-attn -ngl 99\")")
+	fileField := flag.String("file-field", speech.DefaultFileField, "Multipart form field the audio is sent in, for whisper-compatible servers")
+	inferenceEndpoint := flag.String("inference-endpoint", speech.DefaultInferencePath, "Server path transcription requests are posted to")
+	serverURL := flag.String("server-url", "", "Use the whisper server already running at this URL instead of starting one")
+	openAI := flag.Bool("openai", false, "Talk to the server through the OpenAI /v1/audio/transcriptions API")
+	openAIModel := flag.String("openai-model", speech.DefaultOpenAIModel, "Model name sent to an OpenAI-compatible server")
+	apiKey := flag.String("api-key", "", "Bearer token sent with transcription requests, also settable with OPENAI_API_KEY")
+	withTranslation := flag.Bool("with-translation", false, "Also translate each transcription to English and show both (doubles server work)")
+	lenientParse := flag.Bool("lenient-parse", false, "Keep the text of truncated server responses instead of failing the transcription")
+	clipboardASCII := flag.Bool("clipboard-ascii", false, "Copy smart quotes, dashes and ellipses as plain ASCII")
+	clipboardStrip := flag.Bool("clipboard-strip-symbols", false, "Drop emoji and non-printable characters from copied text")
+	showRTF := flag.Bool("show-rtf", false, "Show each transcription's real-time factor (processing time / audio length)")
+	recordingDir := flag.String("recording-dir", "", "Save every recording and its transcription to this directory for review")
+	recordOnly := flag.Bool("record-only", false, "Save recordings to --recording-dir as voice memos without transcribing them")
+	compact := flag.Bool("compact", false, "Show only a status line and the current text, as on terminals under 60 columns")
+	noAltScreen := flag.Bool("no-alt-screen", false, "Draw the UI inline instead of full screen, so it stays in the scrollback after exit")
+	exportParagraph := flag.Bool("export-paragraph", false, "Join transcriptions into one paragraph when saving the session with 's'")
+	ensembleModel := flag.String("ensemble-model", "", "Also transcribe every recording with this model on a second server and keep the better result")
+	ensembleStrategyName := flag.String("ensemble-strategy", "confidence", "How --ensemble-model picks a result: confidence or agreement")
+	flag.Parse()
+
+	if *listModels {
+		if err := printModels(*modelsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing models: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The flag wins over the environment
+	if env := os.Getenv("CONCH_UI"); env != "" && !flagSet("ui") {
+		*uiName = env
+	}
+	if *apiKey == "" {
+		*apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if err := checkUI(*uiName); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --ui: %v\n", err)
+		os.Exit(1)
+	}
+
+	ensembleStrategy, err := speech.ParseEnsembleStrategy(*ensembleStrategyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --ensemble-strategy: %v\n", err)
+		os.Exit(1)
+	}
+
+	// An external server runs its own model, a second one can't be loaded on it
+	if *ensembleModel != "" && *serverURL != "" {
+		fmt.Fprintln(os.Stderr, "--ensemble-model starts a second whisper server, it can't be used with --server-url")
+		os.Exit(1)
+	}
+
+	if *derepeatLimit < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid --derepeat-limit %d: must be at least 1\n", *derepeatLimit)
+		os.Exit(1)
+	}
+
+	if *recordOnly && *recordingDir == "" {
+		fmt.Fprintln(os.Stderr, "--record-only needs --recording-dir to save recordings to")
+		os.Exit(1)
+	}
+
+	keyMap, err := terminal.ParseKeyMap(*keyBindings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	// No sinks leaves the terminal's default of copying to the clipboard
+	var sinks []terminal.OutputSink
+	for _, spec := range strings.Split(*outputs, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		sink, err := terminal.ParseOutputSink(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --output: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	if *execCmd != "" {
+		if len(sinks) == 0 {
+			sinks = append(sinks, terminal.ClipboardSink{})
+		}
+		sinks = append(sinks, terminal.CommandSink{Command: *execCmd})
+	}
+
 	log.SetPrefix("conch: ")
 	log.SetFlags(log.Ltime)
 
+	// Create services
+	speechSvc := speech.NewSpeechService().
+		WithInactivityTimeout(*inactivityTimeout).
+		WithInactivityResume(*inactivityResume).
+		WithRollingBuffer(*rollingBuffer).
+		WithUtteranceMergeGap(*mergeGap).
+		WithSmartEndpointing(*endpointBand, *endpointExtension).
+		WithFrameSize(*frameSize).
+		WithVadThreshold(*vadThreshold)
+	speechSvc.SetInputGain(*gain)
+	if *testTone {
+		speechSvc.WithSyntheticInput(speech.ToneBursts(speech.DefaultToneOn, speech.DefaultToneOff))
+	}
+	// Options for how requests are made and results handled, shared by the
+	// ensemble server so both models are queried the same way
+	whisperOptions := func(svc *speech.WhisperServerService) *speech.WhisperServerService {
+		svc.WithDerepeat(*derepeat).
+			WithDerepeatLimit(*derepeatLimit).
+			WithReadyTimeout(*readyTimeout).
+			WithHealthCheck(*healthCheck).
+			WithDrain(*drain).
+			WithLenientParse(*lenientParse).
+			WithBothTranscriptAndTranslation(*withTranslation).
+			WithExtraServerArgs(strings.Fields(*serverArgs)).
+			WithFileFieldName(*fileField).
+			WithInferenceEndpoint(*inferenceEndpoint).
+			WithOpenAICompatible(*openAI).
+			WithOpenAIModel(*openAIModel).
+			WithAPIKey(*apiKey)
+		if *confidenceRetry > 0 {
+			svc.WithConfidenceRetry(*confidenceRetry)
+		}
+		return svc
+	}
+	whisperSvc := whisperOptions(speech.NewWhisperServerService()).
+		WithPersistentServer(*persistentServer).
+		WithAutoPort(*autoPort).
+		WithServerURL(*serverURL)
+	speechSvc.WithTranscriber(whisperSvc)
+
+	// Resolve a short model name to a file in the models directory
+	config := speech.NewDefaultWhisperServerConfig()
+	if *modelName != "" {
+		modelPath, err := speech.ResolveModel(*modelsDir, *modelName)
+		if err != nil {
+			log.Fatalf("Failed to resolve model: %v", err)
+		}
+		config.ModelPath = modelPath
+		log.Printf("Using model %s", modelPath)
+	}
+	config.GPULayers = *gpuLayers
+	config.FlashAttn = *flashAttn
+	whisperSvc.WithConfig(config)
+
+	if *language != "" {
+		whisperSvc.WithLanguage(*language)
+	}
+	if *maxFallbacks >= 0 {
+		whisperSvc.WithMaxFallbacks(*maxFallbacks)
+	}
+
+	// A prompt file takes precedence so long glossaries can live outside the command line
+	if *promptFile != "" {
+		data, err := os.ReadFile(*promptFile)
+		if err != nil {
+			log.Fatalf("Failed to read prompt file: %v", err)
+		}
+		*prompt = strings.TrimSpace(string(data))
+	}
+	if *prompt != "" {
+		whisperSvc.SetInitialPrompt(*prompt)
+	}
+
+	// "conch config" prints the settings the flags and environment resolve to
+	if flag.Arg(0) == "config" {
+		if err := printConfig(whisperSvc); err != nil {
+			fmt.Fprintf(os.Stderr, "Error printing config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "conch transcribe <file|->" transcribes a WAV file or stdin and exits,
+	// without touching the microphone
+	if flag.Arg(0) == "transcribe" {
+		if err := transcribeFile(whisperSvc, flag.Arg(1), *showRTF); err != nil {
+			fmt.Fprintf(os.Stderr, "Error transcribing: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "transcribe-dir" {
+		var args []string
+		if flag.NArg() > 2 {
+			args = flag.Args()[2:]
+		}
+		if err := transcribeDir(whisperSvc, flag.Arg(1), args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error transcribing directory: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Println("Starting conch terminal")
 
 	// Initialize configuration
@@ -25,25 +393,20 @@ func main() {
 		shell = "/bin/bash" // Default shell if not set
 	}
 
-	// Create services
-	speechSvc := speech.NewSpeechService()
-	whisperSvc := speech.NewWhisperServerService()
-	
-	// Status service will be passed to the terminal app
-	statusSvc := status.NewStatusService(speechSvc)
+	// Status service will be passed to the terminal app. The UI shows the
+	// state itself, and the status line would be drawn over it.
+	statusSvc := status.NewStatusServiceWithWriter(speechSvc, io.Discard)
 
 	// Set up graceful shutdown handler
-	shutdownManager := common.NewGracefulShutdown(10 * time.Second)
+	// bubbletea owns Ctrl+C (double-tap to quit) and SIGINT, so only SIGTERM
+	// from kill or systemd triggers shutdown directly
+	shutdownManager := common.NewGracefulShutdown(10 * time.Second).
+		WithSignals(syscall.SIGTERM)
 	shutdownManager.Register(statusSvc)  // Register status service
 	shutdownManager.Register(whisperSvc) // Register whisper service
 	shutdownManager.Register(speechSvc)  // Register speech service last
 	shutdownManager.Start()
 
-	// Initialize services
-	if err := whisperSvc.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize whisper service: %v", err)
-	}
-
 	if err := speechSvc.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize speech service: %v", err)
 	}
@@ -53,23 +416,132 @@ func main() {
 		log.Fatalf("Failed to start listening: %v", err)
 	}
 
-	// Register the shutdown handler before running the terminal app
-	shutdownChan := make(chan os.Signal, 1)
-	shutdownManager.SetSignalHandler(shutdownChan)
-	
-	// Create and run terminal app with bubbletea
+	// Share the microphone and model with other programs
+	if *serveAddr != "" {
+		apiServer := api.NewServer(*serveAddr, speechSvc, whisperSvc)
+		if err := apiServer.Start(); err != nil {
+			log.Fatalf("Failed to start API server: %v", err)
+		}
+		shutdownManager.Register(apiServer)
+	}
+
+	// Create and run the bubbletea terminal app
 	app, err := terminal.NewTerminalApp(shell, speechSvc, whisperSvc, statusSvc)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing application: %v\n", err)
 		os.Exit(1)
 	}
+	app.WithAltScreen(!*noAltScreen).
+		WithExportParagraph(*exportParagraph).
+		WithAutoFinalize(*autoCopyAfter).
+		WithHistoryLimit(*historySize, *historyMaxChars).
+		WithInterimCopy(*interimCopy).
+		WithOutputSinks(sinks...).
+		WithKeyMap(keyMap).
+		WithCompletionBell(*bell).
+		WithCompletionFlash(*flash).
+		WithShowRTF(*showRTF).
+		WithCompact(*compact).
+		WithClipboardNormalization(terminal.ClipboardNormalization{
+			FoldPunctuation: *clipboardASCII,
+			StripSymbols:    *clipboardStrip,
+		})
+
+	if *registersFile != "" {
+		app.WithRegistersFile(*registersFile)
+	}
+	if *wakeWord != "" {
+		app.WithWakeTimeout(*wakeTimeout).WithWakeWord(*wakeWord)
+	}
+	if *voiceConfirm > 0 {
+		app.WithVoiceConfirm(terminal.VoiceConfirm{
+			Window:  *voiceConfirm,
+			Confirm: splitList(*confirmPhrases),
+			Cancel:  splitList(*cancelPhrases),
+		})
+	}
 
-	log.Println("Starting terminal UI")
-	if err := app.Run(); err != nil {
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		defer f.Close()
+		app.WithLogFile(f)
+	}
+
+	if *recordOnly {
+		// Voice memos don't need the whisper server at all
+		app.WithRecordOnly(*recordingDir)
+	} else {
+		if *recordingDir != "" {
+			app.WithRecordingDir(*recordingDir)
+		}
+
+		// Load the model in the background so the UI can show progress. Without whisper
+		// the UI still starts, so capture can be checked while the server configuration is fixed.
+		app.WithModelLoading(true)
+		whisperSvc.WithStartupProgress(app.StartupProgress)
+
+		// Quitting or a signal during the model load abandons it, rather
+		// than shutdown waiting for the load to finish
+		startupCtx, cancelStartup := context.WithCancel(context.Background())
+		shutdownManager.BeforeShutdown(cancelStartup)
+
+		// A second model transcribes every recording too, and the better result is kept
+		var ensembleSvc *speech.WhisperServerService
+		if *ensembleModel != "" {
+			ensembleSvc, err = newEnsembleService(whisperSvc, *modelsDir, *ensembleModel)
+			if err != nil {
+				log.Fatalf("Failed to resolve ensemble model: %v", err)
+			}
+			whisperOptions(ensembleSvc)
+			shutdownManager.Register(ensembleSvc)
+			ensemble := speech.NewEnsembleTranscriber(ensembleStrategy, whisperSvc, ensembleSvc)
+			app.WithTranscriber(ensemble)
+			speechSvc.WithTranscriber(ensemble)
+		}
+
+		go func() {
+			err := whisperSvc.InitializeContext(startupCtx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to initialize whisper service, transcription disabled: %v", err)
+			}
+			if err == nil && ensembleSvc != nil {
+				if err := ensembleSvc.InitializeContext(startupCtx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("Failed to start the ensemble whisper server, using one model: %v", err)
+				}
+			}
+			app.WhisperStarted(err)
+		}()
+	}
+
+	// Push-to-talk from a system-wide hotkey works without conch having focus
+	if *globalHotkey != "" {
+		listener := hotkey.NewListener(*globalHotkey)
+		if err := listener.Start(); err != nil {
+			log.Printf("Global hotkey unavailable: %v", err)
+		} else {
+			shutdownManager.Register(listener)
+			go func() {
+				for event := range listener.Events() {
+					app.PushToTalk(event == hotkey.Press)
+				}
+			}()
+		}
+	}
+
+	// A SIGTERM shutdown stops the UI too, so Run returns
+	shutdownManager.BeforeShutdown(app.Quit)
+
+	var ui UI = app
+	log.Printf("Starting %s terminal UI", *uiName)
+	if err := ui.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
 	}
-	
-	// Trigger graceful shutdown when app terminates
+
+	// Trigger graceful shutdown when app terminates. If a signal already
+	// started it, this waits for it to finish instead of running it again.
 	log.Println("Terminal UI exited, shutting down services")
 	shutdownManager.StartShutdown()
 }
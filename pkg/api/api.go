@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// maxUploadSize bounds a /transcribe request body, which is otherwise spilled
+// to disk without limit once it outgrows memory
+var maxUploadSize int64 = 32 << 20
+
+// StatusResponse is the JSON body returned by GET /status
+type StatusResponse struct {
+	State          string    `json:"state"` // idle, listening, recording, transcribing or shutting_down
+	Listening      bool      `json:"listening"`
+	Recording      bool      `json:"recording"`
+	Transcribing   bool      `json:"transcribing"`
+	CaptureStalled bool      `json:"capture_stalled"`
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// errorResponse is the JSON body returned when a request fails
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server exposes the speech services over a small local HTTP API, so other
+// programs such as editor plugins can share the microphone and model:
+//
+//	POST /transcribe  multipart WAV upload in the "file" field, returns a WhisperServerResult
+//	GET  /status      returns the current speech state
+//	POST /output      ?active=true|false with an X-Conch header, brackets audio
+//	                  playback so it isn't transcribed
+type Server struct {
+	addr        string
+	speechSvc   *speech.SpeechService
+	transcriber speech.Transcriber
+	httpServer  *http.Server
+}
+
+// NewServer creates an API server. An address without a host, like ":9000",
+// binds to localhost only.
+func NewServer(addr string, speechSvc *speech.SpeechService, transcriber speech.Transcriber) *Server {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+
+	s := &Server{
+		addr:        addr,
+		speechSvc:   speechSvc,
+		transcriber: transcriber,
+	}
+	s.httpServer = &http.Server{Addr: addr, Handler: s.Handler()}
+	return s
+}
+
+// Handler returns the API's HTTP handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /transcribe", s.handleTranscribe)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /output", s.handleOutput)
+	return mux
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", s.addr, err)
+	}
+
+	log.Printf("API listening on http://%s", listener.Addr())
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("API server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Name implements common.Shutdownable
+func (s *Server) Name() string {
+	return "API Server"
+}
+
+// Shutdown implements common.Shutdownable, letting in-flight requests finish
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// handleTranscribe decodes an uploaded WAV file and transcribes it
+func (s *Server) handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("upload larger than %d bytes", maxUploadSize))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid multipart form: %v", err))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing WAV upload in \"file\" field: %v", err))
+		return
+	}
+	defer file.Close()
+
+	audio, err := speech.ReadWav(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := s.transcriber.Transcribe(audio)
+	if err != nil {
+		writeError(w, transcribeStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// transcribeStatus maps a transcription error to a response status, telling
+// a problem with the uploaded audio from the whisper server being unavailable
+// or failing
+func transcribeStatus(err error) int {
+	switch {
+	case errors.Is(err, speech.ErrEmptyAudio):
+		return http.StatusBadRequest
+	case errors.Is(err, speech.ErrWavTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, speech.ErrServerNotRunning), errors.Is(err, speech.ErrShuttingDown):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+// handleStatus reports the speech service state
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &StatusResponse{
+		State:          s.speechSvc.State().String(),
+		Listening:      s.speechSvc.IsListening(),
+		Recording:      s.speechSvc.IsRecording(),
+		Transcribing:   s.speechSvc.IsTranscribing(),
+		CaptureStalled: s.speechSvc.IsCaptureStalled(speech.CaptureStallTimeout),
+		LastActivity:   s.speechSvc.LastActivity(),
+	})
+}
+
+// outputHeader must be set on /output requests. Browsers only send a custom
+// header cross-site after a CORS preflight, which the API never allows, so a
+// web page can't mute dictation.
+const outputHeader = "X-Conch"
+
+// handleOutput lets a client signal audio playback, see SpeechService.SetOutputActive
+func (s *Server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(outputHeader) == "" {
+		writeError(w, http.StatusForbidden, fmt.Errorf("missing %s header", outputHeader))
+		return
+	}
+
+	active, err := strconv.ParseBool(r.URL.Query().Get("active"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid active parameter: %v", err))
+		return
+	}
+
+	s.speechSvc.SetOutputActive(active)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write API response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
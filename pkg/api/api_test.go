@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// fakeTranscriber returns a fixed result, or err if set
+type fakeTranscriber struct {
+	text  string
+	err   error
+	audio *speech.AudioData
+}
+
+func (f *fakeTranscriber) Transcribe(audio *speech.AudioData) (*speech.WhisperServerResult, error) {
+	f.audio = audio
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &speech.WhisperServerResult{Text: f.text, Success: true}, nil
+}
+
+// uploadRequest builds a POST /transcribe request carrying wav in the file field
+func uploadRequest(t *testing.T, wav []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(wav)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/transcribe", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// TestTranscribe verifies an uploaded WAV is decoded and the result returned as JSON
+func TestTranscribe(t *testing.T) {
+	transcriber := &fakeTranscriber{text: "hello world"}
+	server := NewServer(":0", speech.NewSpeechService(), transcriber)
+
+	var wav bytes.Buffer
+	if err := speech.WriteWav(&wav, make([]int16, 1600), speech.AudioFrequency); err != nil {
+		t.Fatalf("Failed to build WAV: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, uploadRequest(t, wav.Bytes()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result speech.WhisperServerResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Text != "hello world" {
+		t.Errorf("Expected transcription text, got %q", result.Text)
+	}
+	if transcriber.audio == nil || len(transcriber.audio.Samples) != 1600 {
+		t.Errorf("Expected the uploaded samples to be transcribed")
+	}
+}
+
+// TestTranscribeErrors verifies bad uploads and transcription failures map to error statuses
+func TestTranscribeErrors(t *testing.T) {
+	var wav bytes.Buffer
+	speech.WriteWav(&wav, make([]int16, 1600), speech.AudioFrequency)
+
+	tests := []struct {
+		name   string
+		body   []byte
+		err    error
+		status int
+	}{
+		{"not a wav", []byte("garbage"), nil, http.StatusBadRequest},
+		{"empty audio", wav.Bytes(), speech.ErrEmptyAudio, http.StatusBadRequest},
+		{"too long", wav.Bytes(), fmt.Errorf("failed to save audio data: %w", speech.ErrWavTooLarge), http.StatusRequestEntityTooLarge},
+		{"server down", wav.Bytes(), speech.ErrServerNotRunning, http.StatusServiceUnavailable},
+		{"shutting down", wav.Bytes(), speech.ErrShuttingDown, http.StatusServiceUnavailable},
+		{"transcription failed", wav.Bytes(), errors.New("boom"), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(":0", speech.NewSpeechService(), &fakeTranscriber{err: tt.err})
+
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, uploadRequest(t, tt.body))
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestTranscribeUploadLimit verifies an upload over the size limit is refused
+// without being read in full
+func TestTranscribeUploadLimit(t *testing.T) {
+	defer func(size int64) { maxUploadSize = size }(maxUploadSize)
+	maxUploadSize = 1024
+
+	transcriber := &fakeTranscriber{}
+	server := NewServer(":0", speech.NewSpeechService(), transcriber)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, uploadRequest(t, make([]byte, 4096)))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if transcriber.audio != nil {
+		t.Error("Expected nothing to be transcribed")
+	}
+}
+
+// TestStatus verifies the speech state is reported
+func TestStatus(t *testing.T) {
+	server := NewServer(":0", speech.NewSpeechService(), &fakeTranscriber{})
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var status StatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.State != "idle" || status.Listening || status.Recording {
+		t.Errorf("Expected an idle service, got %+v", status)
+	}
+}
+
+// TestNewServerDefaultsToLocalhost verifies a bare port binds to localhost
+func TestNewServerDefaultsToLocalhost(t *testing.T) {
+	if addr := NewServer(":9000", nil, nil).addr; addr != "127.0.0.1:9000" {
+		t.Errorf("Expected localhost bind, got %s", addr)
+	}
+}
+
+// TestOutput verifies the output flag is validated and accepted, and that
+// requests without the header a cross-site form can't send are refused
+func TestOutput(t *testing.T) {
+	server := NewServer(":0", speech.NewSpeechService(), &fakeTranscriber{})
+
+	for query, want := range map[string]int{"active=true": http.StatusNoContent, "active=false": http.StatusNoContent, "active=maybe": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/output?"+query, nil)
+		req.Header.Set(outputHeader, "1")
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: expected status %d, got %d", query, want, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/output?active=true", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a request without %s to be refused, got %d", outputHeader, rec.Code)
+	}
+}
@@ -16,13 +16,24 @@ type Shutdownable interface {
 	Shutdown() error
 }
 
+// ContextShutdownable is a Shutdownable that can use the time left before the
+// shutdown timeout, e.g. to let work in progress finish
+type ContextShutdownable interface {
+	Shutdownable
+	ShutdownContext(ctx context.Context) error
+}
+
 // GracefulShutdown handles OS signals and manages shutting down registered services
 type GracefulShutdown struct {
 	services       []Shutdownable
 	sigCh          chan os.Signal
+	signals        []os.Signal
 	timeout        time.Duration
 	mu             sync.Mutex
 	preShutdownCbs []func()
+
+	// Services are shut down once, whether by a signal or StartShutdown
+	once sync.Once
 }
 
 // NewGracefulShutdown creates a shutdown manager with signal handling
@@ -30,6 +41,7 @@ func NewGracefulShutdown(timeout time.Duration) *GracefulShutdown {
 	return &GracefulShutdown{
 		services:       make([]Shutdownable, 0),
 		sigCh:          make(chan os.Signal, 1),
+		signals:        []os.Signal{os.Interrupt, syscall.SIGTERM},
 		timeout:        timeout,
 		preShutdownCbs: make([]func(), 0),
 	}
@@ -43,27 +55,48 @@ func (gs *GracefulShutdown) Register(svc Shutdownable) {
 	log.Printf("Registered service for shutdown: %s", svc.Name())
 }
 
+// WithSignals sets the signals that trigger shutdown, by default os.Interrupt
+// and SIGTERM. Leave os.Interrupt out when something else, like a terminal UI,
+// owns Ctrl+C. Call it before Start.
+func (gs *GracefulShutdown) WithSignals(signals ...os.Signal) *GracefulShutdown {
+	gs.signals = signals
+	return gs
+}
+
+// BeforeShutdown registers fn to run before services are shut down, e.g. to
+// stop a UI when shutdown is triggered by a signal
+func (gs *GracefulShutdown) BeforeShutdown(fn func()) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.preShutdownCbs = append(gs.preShutdownCbs, fn)
+}
+
 // Start begins listening for termination signals
 func (gs *GracefulShutdown) Start() {
 	// Set up signal notification
-	signal.Notify(gs.sigCh, os.Interrupt, syscall.SIGTERM)
+	gs.mu.Lock()
+	sigCh := gs.sigCh
+	signal.Notify(sigCh, gs.signals...)
+	gs.mu.Unlock()
 
 	// Start a goroutine to handle signals
 	go func() {
-		sig := <-gs.sigCh
+		sig := <-sigCh
 		log.Printf("Received signal: %v, initiating graceful shutdown", sig)
 		gs.shutdown()
 	}()
 }
 
-// SetSignalHandler allows using a custom signal channel
+// SetSignalHandler allows using a custom signal channel. Call it before Start.
 func (gs *GracefulShutdown) SetSignalHandler(sigCh chan os.Signal) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
 	// Reset the existing notification
 	signal.Stop(gs.sigCh)
-	
+
 	// Use the new channel
 	gs.sigCh = sigCh
-	signal.Notify(gs.sigCh, os.Interrupt, syscall.SIGTERM)
 }
 
 // StartShutdown initiates the shutdown process programmatically
@@ -72,13 +105,25 @@ func (gs *GracefulShutdown) StartShutdown() {
 	gs.shutdown()
 }
 
-// shutdown performs the actual shutdown process
+// shutdown performs the shutdown process the first time it is called. Later
+// calls wait for that shutdown to finish and return.
 func (gs *GracefulShutdown) shutdown() {
+	gs.once.Do(gs.shutdownServices)
+}
+
+// shutdownServices runs the pre-shutdown callbacks and shuts down every service
+func (gs *GracefulShutdown) shutdownServices() {
 	gs.mu.Lock()
 	svcs := make([]Shutdownable, len(gs.services))
 	copy(svcs, gs.services)
+	cbs := make([]func(), len(gs.preShutdownCbs))
+	copy(cbs, gs.preShutdownCbs)
 	gs.mu.Unlock()
 
+	for _, cb := range cbs {
+		cb()
+	}
+
 	if len(svcs) == 0 {
 		return
 	}
@@ -99,7 +144,12 @@ func (gs *GracefulShutdown) shutdown() {
 			defer wg.Done()
 
 			log.Printf("Shutting down %s...", s.Name())
-			err := s.Shutdown()
+			var err error
+			if cs, ok := s.(ContextShutdownable); ok {
+				err = cs.ShutdownContext(ctx)
+			} else {
+				err = s.Shutdown()
+			}
 			if err != nil {
 				log.Printf("Error shutting down %s: %v", s.Name(), err)
 			} else {
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeService is a Shutdownable that waits for delay and then returns err
+type fakeService struct {
+	name     string
+	delay    time.Duration
+	err      error
+	finished atomic.Bool
+	calls    atomic.Int32
+}
+
+func (f *fakeService) Name() string {
+	return f.name
+}
+
+func (f *fakeService) Shutdown() error {
+	f.calls.Add(1)
+	time.Sleep(f.delay)
+	f.finished.Store(true)
+	return f.err
+}
+
+// captureLog redirects the standard logger for the duration of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return &buf
+}
+
+// TestGracefulShutdown covers fast, failing and blocking services
+func TestGracefulShutdown(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		services    []*fakeService
+		wantTimeout bool
+		wantLog     []string
+	}{
+		{
+			name:     "fast services",
+			services: []*fakeService{{name: "a"}, {name: "b", delay: 10 * time.Millisecond}},
+			wantLog:  []string{"Successfully shut down a", "Successfully shut down b", "All services shut down successfully"},
+		},
+		{
+			name:     "erroring service",
+			services: []*fakeService{{name: "ok"}, {name: "bad", err: errors.New("boom")}},
+			wantLog:  []string{"Successfully shut down ok", "Error shutting down bad: boom", "All services shut down successfully"},
+		},
+		{
+			name:        "blocking service",
+			services:    []*fakeService{{name: "fast"}, {name: "stuck", delay: 10 * timeout}},
+			wantTimeout: true,
+			wantLog:     []string{"Successfully shut down fast", "Shutdown timed out after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+
+			gs := NewGracefulShutdown(timeout)
+			for _, svc := range tt.services {
+				gs.Register(svc)
+			}
+
+			start := time.Now()
+			gs.StartShutdown()
+			elapsed := time.Since(start)
+
+			// A blocking service must not hold up shutdown past the timeout
+			if elapsed > timeout+200*time.Millisecond {
+				t.Errorf("Shutdown took %v, expected it to return by the %v timeout", elapsed, timeout)
+			}
+
+			for _, svc := range tt.services {
+				if svc.delay < timeout && !svc.finished.Load() {
+					t.Errorf("Expected service %s to finish shutting down", svc.name)
+				}
+			}
+
+			output := logs.String()
+			for _, want := range tt.wantLog {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected log to contain %q, got:\n%s", want, output)
+				}
+			}
+			if !tt.wantTimeout && strings.Contains(output, "timed out") {
+				t.Errorf("Unexpected timeout in log:\n%s", output)
+			}
+		})
+	}
+}
+
+// TestGracefulShutdownNoServices verifies shutdown with nothing registered returns immediately
+func TestGracefulShutdownNoServices(t *testing.T) {
+	logs := captureLog(t)
+
+	NewGracefulShutdown(time.Second).StartShutdown()
+
+	if strings.Contains(logs.String(), "Shutting down") {
+		t.Errorf("Expected no services to be shut down, got:\n%s", logs.String())
+	}
+}
+
+// TestGracefulShutdownOnce verifies a signal and a manual shutdown racing each
+// other shut services down exactly once, with callbacks run first
+func TestGracefulShutdownOnce(t *testing.T) {
+	captureLog(t)
+
+	svc := &fakeService{name: "svc", delay: 50 * time.Millisecond}
+	var quit atomic.Bool
+	gs := NewGracefulShutdown(time.Second).WithSignals(syscall.SIGUSR1)
+	gs.Register(svc)
+	gs.BeforeShutdown(func() { quit.Store(true) })
+	gs.Start()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Failed to send signal: %v", err)
+	}
+
+	// Wait for the signal handler to begin shutting down
+	deadline := time.Now().Add(time.Second)
+	for svc.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The manual shutdown must wait for the one in progress rather than repeat it
+	gs.StartShutdown()
+	if !svc.finished.Load() {
+		t.Error("Expected StartShutdown to return after the service finished")
+	}
+	if calls := svc.calls.Load(); calls != 1 {
+		t.Errorf("Expected one shutdown, got %d", calls)
+	}
+	if !quit.Load() {
+		t.Error("Expected the pre-shutdown callback to run")
+	}
+}
+
+// contextService is a ContextShutdownable that blocks until its context is done
+type contextService struct {
+	fakeService
+	ctxErr atomic.Value
+}
+
+func (c *contextService) ShutdownContext(ctx context.Context) error {
+	<-ctx.Done()
+	c.ctxErr.Store(ctx.Err())
+	return nil
+}
+
+// TestGracefulShutdownContext verifies a ContextShutdownable gets the shutdown
+// context instead of Shutdown, expiring at the timeout
+func TestGracefulShutdownContext(t *testing.T) {
+	captureLog(t)
+
+	svc := &contextService{fakeService: fakeService{name: "draining"}}
+	gs := NewGracefulShutdown(50 * time.Millisecond)
+	gs.Register(svc)
+	gs.StartShutdown()
+
+	deadline := time.Now().Add(time.Second)
+	for svc.ctxErr.Load() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err, _ := svc.ctxErr.Load().(error); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context to expire at the timeout, got %v", err)
+	}
+	if svc.calls.Load() != 0 {
+		t.Error("Expected Shutdown not to be called")
+	}
+}
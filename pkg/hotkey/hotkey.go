@@ -0,0 +1,132 @@
+package hotkey
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Event is a change in the global hotkey's state
+type Event int
+
+const (
+	Press Event = iota
+	Release
+)
+
+// Listener watches a system-wide hotkey through a helper command. Grabbing keys
+// regardless of focus needs OS-specific APIs and permissions (input device
+// access on Linux, Input Monitoring on macOS), so conch leaves that to a small
+// helper and reads its stdout, one event per line: "down" or "press" when the
+// key goes down, "up" or "release" when it comes back up.
+type Listener struct {
+	command string
+	cmd     *exec.Cmd
+	events  chan Event
+	mu      sync.Mutex
+}
+
+// NewListener creates a listener for the helper command, run with sh -c
+func NewListener(command string) *Listener {
+	return &Listener{
+		command: command,
+		events:  make(chan Event, 8),
+	}
+}
+
+// Start runs the helper. Its events are delivered on Events until it exits.
+func (l *Listener) Start() error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("global hotkeys are not supported on %s", runtime.GOOS)
+	}
+	if strings.TrimSpace(l.command) == "" {
+		return errors.New("no hotkey helper command given")
+	}
+
+	cmd := exec.Command("sh", "-c", l.command)
+	// Through the log, which the UI routes away from its screen
+	cmd.Stderr = logWriter{}
+	// Its own process group, so Shutdown can stop whatever sh started too
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create helper pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start hotkey helper: %v", err)
+	}
+
+	l.mu.Lock()
+	l.cmd = cmd
+	l.mu.Unlock()
+
+	go func() {
+		defer close(l.events)
+		readEvents(stdout, l.events)
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Hotkey helper exited: %v", err)
+		} else {
+			log.Println("Hotkey helper exited")
+		}
+	}()
+	return nil
+}
+
+// readEvents parses helper output into events until r is exhausted. Unknown
+// lines are logged and skipped.
+func readEvents(r io.Reader, events chan<- Event) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "down", "press":
+			events <- Press
+		case "up", "release":
+			events <- Release
+		case "":
+		default:
+			log.Printf("Ignoring unknown hotkey helper output %q", scanner.Text())
+		}
+	}
+}
+
+// logWriter logs each line the helper writes to stderr
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		log.Printf("Hotkey helper: %s", line)
+	}
+	return len(p), nil
+}
+
+// Events returns the hotkey events, closed when the helper exits
+func (l *Listener) Events() <-chan Event {
+	return l.events
+}
+
+// Name implements common.Shutdownable
+func (l *Listener) Name() string {
+	return "Global Hotkey"
+}
+
+// Shutdown stops the helper and any processes it started
+func (l *Listener) Shutdown() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cmd == nil || l.cmd.Process == nil {
+		return nil
+	}
+	// The group is gone already if everything in it exited
+	if err := syscall.Kill(-l.cmd.Process.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to stop hotkey helper: %v", err)
+	}
+	return nil
+}
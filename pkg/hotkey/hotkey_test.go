@@ -0,0 +1,114 @@
+package hotkey
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestReadEvents verifies helper output is parsed and unknown lines are skipped
+func TestReadEvents(t *testing.T) {
+	events := make(chan Event, 8)
+	readEvents(strings.NewReader("down\nUP\n\nbogus\npress\nrelease\n"), events)
+	close(events)
+
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	want := []Event{Press, Release, Press, Release}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestListener verifies events from a running helper arrive and the channel closes when it exits
+func TestListener(t *testing.T) {
+	l := NewListener("echo down; echo up")
+	if err := l.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer l.Shutdown()
+
+	var got []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-l.Events():
+			if !ok {
+				if !reflect.DeepEqual(got, []Event{Press, Release}) {
+					t.Errorf("Unexpected events %v", got)
+				}
+				return
+			}
+			got = append(got, event)
+		case <-timeout:
+			t.Fatal("Timed out waiting for the helper to exit")
+		}
+	}
+}
+
+// TestListenerShutdown verifies the helper's stderr goes to the log, and that
+// Shutdown stops processes the helper started, not just sh
+func TestListenerShutdown(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	l := NewListener("echo grabbed the keyboard >&2; sleep 30 & echo $! > " + pidFile + "; wait")
+	if err := l.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	var pid int
+	deadline := time.Now().Add(5 * time.Second)
+	for pid == 0 {
+		if data, err := os.ReadFile(pidFile); err == nil && bytes.HasSuffix(data, []byte("\n")) {
+			pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the helper to start its child")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := l.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	for range l.Events() {
+	}
+	// The child isn't ours to reap, so give its parent's exit a moment
+	deadline = time.Now().Add(5 * time.Second)
+	for running(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the helper's child (PID %d) to be stopped", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.Contains(logs.String(), "Hotkey helper: grabbed the keyboard") {
+		t.Errorf("Expected the helper's stderr in the log, got %q", logs.String())
+	}
+}
+
+// running reports whether pid exists and, where procfs shows it, isn't a
+// zombie waiting for whichever process inherited it to reap it
+func running(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,85 @@
+package speech
+
+import "math"
+
+// AGC settings
+const (
+	AGCWindowSamples = AudioFrequency / 100 // 10ms analysis windows
+	AGCMaxGain       = 20.0                 // Upper bound so noise isn't blown up
+	AGCAttack        = 0.5                  // Smoothing when the gain must drop (loud onset)
+	AGCRelease       = 0.05                 // Smoothing when the gain may rise (speech fading)
+)
+
+// AGC is an automatic gain control stage that scales a recording toward a
+// target RMS level. The gain follows the per-window speech level with
+// asymmetric attack/release smoothing, so it reacts quickly to loud onsets but
+// recovers slowly, avoiding audible pumping.
+type AGC struct {
+	TargetRMS float64
+	MaxGain   float64
+	Attack    float64
+	Release   float64
+}
+
+// NewAGC creates an AGC targeting the given RMS level
+func NewAGC(targetRMS int) *AGC {
+	return &AGC{
+		TargetRMS: float64(targetRMS),
+		MaxGain:   AGCMaxGain,
+		Attack:    AGCAttack,
+		Release:   AGCRelease,
+	}
+}
+
+// Process scales samples in place toward the target RMS. Windows below the
+// voice threshold don't steer the gain, and a recording with no speech at all
+// is left untouched.
+func (a *AGC) Process(samples []int16) {
+	// Measure the overall speech level to seed the gain, so the start of the
+	// recording isn't left at unity while the envelope catches up
+	var sumSquares float64
+	var voiced int
+	for start := 0; start < len(samples); start += AGCWindowSamples {
+		window := samples[start:min(start+AGCWindowSamples, len(samples))]
+		if level := rmsLevel(window); level > VadThreshold {
+			sumSquares += level * level * float64(len(window))
+			voiced += len(window)
+		}
+	}
+	if voiced == 0 {
+		return
+	}
+
+	gain := a.gainFor(math.Sqrt(sumSquares / float64(voiced)))
+	for start := 0; start < len(samples); start += AGCWindowSamples {
+		window := samples[start:min(start+AGCWindowSamples, len(samples))]
+		if level := rmsLevel(window); level > VadThreshold {
+			desired := a.gainFor(level)
+			coeff := a.Release
+			if desired < gain {
+				coeff = a.Attack
+			}
+			gain += coeff * (desired - gain)
+		}
+		applyGain(window, gain)
+	}
+}
+
+// gainFor returns the gain that brings level to the target, capped at MaxGain
+func (a *AGC) gainFor(level float64) float64 {
+	return math.Min(a.TargetRMS/level, a.MaxGain)
+}
+
+// rmsLevel returns the root-mean-square amplitude of samples
+func rmsLevel(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		value := float64(sample)
+		sum += value * value
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
@@ -0,0 +1,34 @@
+package speech
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAGCNormalizesLevels verifies quiet and loud clips both land near the target RMS
+func TestAGCNormalizesLevels(t *testing.T) {
+	const target = 3000
+	leading, speech := AudioFrequency/2, AudioFrequency
+
+	for _, amplitude := range []int16{300, 20000} {
+		samples := synthesizeClip(leading, speech, AudioFrequency/2, amplitude)
+
+		NewAGC(target).Process(samples)
+
+		level := rmsLevel(samples[leading : leading+speech])
+		if math.Abs(level-target) > target*0.1 {
+			t.Errorf("Amplitude %d: expected RMS near %d after AGC, got %.0f", amplitude, target, level)
+		}
+	}
+}
+
+// TestAGCLeavesSilence verifies a recording without speech isn't amplified
+func TestAGCLeavesSilence(t *testing.T) {
+	samples := synthesizeClip(0, AudioFrequency, 0, VadThreshold/2)
+
+	NewAGC(3000).Process(samples)
+
+	if level := rmsLevel(samples); level != VadThreshold/2 {
+		t.Errorf("Expected silence to be left unchanged, got RMS %.0f", level)
+	}
+}
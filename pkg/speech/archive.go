@@ -0,0 +1,91 @@
+package speech
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRecordings caps how many recordings an archive keeps on disk
+const DefaultMaxRecordings = 200
+
+// recordingTimeFormat names archived recordings so they sort chronologically
+const recordingTimeFormat = "20060102-150405.000"
+
+// RecordingArchive saves recordings to a directory as rec_<timestamp>.wav, each
+// with a .txt sidecar holding its transcription. Once more than MaxRecordings
+// are stored the oldest are deleted, so a long session can't fill the disk.
+type RecordingArchive struct {
+	Dir           string
+	MaxRecordings int
+}
+
+// NewRecordingArchive creates an archive in dir, which is created on the first save
+func NewRecordingArchive(dir string) *RecordingArchive {
+	return &RecordingArchive{Dir: dir, MaxRecordings: DefaultMaxRecordings}
+}
+
+// Save writes audio and its transcription, then prunes old recordings. It
+// returns the path of the WAV file.
+func (a *RecordingArchive) Save(audio *AudioData, text string, when time.Time) (string, error) {
+	wavPath, err := a.writeAudio(audio, when)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(strings.TrimSuffix(wavPath, ".wav")+".txt", []byte(text+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to save transcription: %v", err)
+	}
+
+	return wavPath, a.prune()
+}
+
+// SaveAudio writes audio without a transcription sidecar, for voice memos,
+// then prunes old recordings. It returns the path of the WAV file.
+func (a *RecordingArchive) SaveAudio(audio *AudioData, when time.Time) (string, error) {
+	wavPath, err := a.writeAudio(audio, when)
+	if err != nil {
+		return "", err
+	}
+	return wavPath, a.prune()
+}
+
+// writeAudio writes audio to a WAV file named after when
+func (a *RecordingArchive) writeAudio(audio *AudioData, when time.Time) (string, error) {
+	if err := os.MkdirAll(a.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create recording directory: %v", err)
+	}
+
+	wavPath := filepath.Join(a.Dir, "rec_"+when.Format(recordingTimeFormat)+".wav")
+	if err := WriteWavFile(wavPath, audio.Samples, audio.SampleRate); err != nil {
+		return "", fmt.Errorf("failed to save recording: %v", err)
+	}
+	return wavPath, nil
+}
+
+// prune deletes the oldest recordings beyond MaxRecordings, along with their sidecars
+func (a *RecordingArchive) prune() error {
+	if a.MaxRecordings <= 0 {
+		return nil
+	}
+
+	recordings, err := filepath.Glob(filepath.Join(a.Dir, "rec_*.wav"))
+	if err != nil {
+		return err
+	}
+	if len(recordings) <= a.MaxRecordings {
+		return nil
+	}
+
+	// Timestamped names sort oldest first
+	sort.Strings(recordings)
+	for _, path := range recordings[:len(recordings)-a.MaxRecordings] {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to prune recording: %v", err)
+		}
+		os.Remove(strings.TrimSuffix(path, ".wav") + ".txt")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package speech
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRecordingArchive verifies recordings are saved with a sidecar and pruned oldest first
+func TestRecordingArchive(t *testing.T) {
+	archive := NewRecordingArchive(filepath.Join(t.TempDir(), "recordings"))
+	archive.MaxRecordings = 2
+
+	audio := &AudioData{Samples: synthesizeClip(0, 1600, 0, 1000), SampleRate: AudioFrequency}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var paths []string
+	for i, text := range []string{"first", "second", "third"} {
+		path, err := archive.Save(audio, text, start.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	// The oldest recording and its sidecar are gone
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected oldest recording to be pruned")
+	}
+	if _, err := os.Stat(filepath.Join(archive.Dir, "rec_20240501-120000.000.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected oldest sidecar to be pruned")
+	}
+
+	// The newest is readable and has its transcription alongside
+	loaded, err := LoadWavFile(paths[2])
+	if err != nil {
+		t.Fatalf("Failed to read saved recording: %v", err)
+	}
+	if len(loaded.Samples) != len(audio.Samples) {
+		t.Errorf("Expected %d samples, got %d", len(audio.Samples), len(loaded.Samples))
+	}
+	text, err := os.ReadFile(filepath.Join(archive.Dir, "rec_20240501-120002.000.txt"))
+	if err != nil || string(text) != "third\n" {
+		t.Errorf("Expected sidecar with transcription, got %q (%v)", text, err)
+	}
+}
+
+// TestRecordingArchiveSaveAudio verifies voice memos are saved without a sidecar
+func TestRecordingArchiveSaveAudio(t *testing.T) {
+	archive := NewRecordingArchive(t.TempDir())
+	archive.MaxRecordings = 0
+
+	audio := &AudioData{Samples: synthesizeClip(0, 1600, 0, 1000), SampleRate: AudioFrequency}
+	path, err := archive.SaveAudio(audio, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("SaveAudio failed: %v", err)
+	}
+
+	if _, err := LoadWavFile(path); err != nil {
+		t.Errorf("Failed to read saved memo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archive.Dir, "rec_20240501-120000.000.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no transcription sidecar")
+	}
+}
@@ -0,0 +1,31 @@
+package speech
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// audioBackend is the capture device read by the capture loop. SDL provides it
+// in production; tests script the frames it returns.
+type audioBackend interface {
+	// Pause stops or restarts the device delivering audio
+	Pause(pause bool)
+	// Dequeue copies queued audio into data, returning the bytes copied
+	Dequeue(data []byte) (int, error)
+	// Clear drops any queued audio
+	Clear()
+}
+
+// sdlBackend reads from an opened SDL capture device
+type sdlBackend struct {
+	id sdl.AudioDeviceID
+}
+
+func (b sdlBackend) Pause(pause bool) {
+	sdl.PauseAudioDevice(b.id, pause)
+}
+
+func (b sdlBackend) Dequeue(data []byte) (int, error) {
+	return sdl.DequeueAudio(b.id, data)
+}
+
+func (b sdlBackend) Clear() {
+	sdl.ClearQueuedAudio(b.id)
+}
@@ -0,0 +1,340 @@
+package speech
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureFrame is the number of samples the scripted backend returns per read (50ms)
+const captureFrame = AudioFrequency / 20
+
+// testSilenceDuration ends scripted recordings after two frames of silence,
+// so scripts don't need the seconds of it the default window takes
+const testSilenceDuration = 100 * time.Millisecond
+
+// newCaptureService creates a service for scripted capture with the short
+// test silence window
+func newCaptureService() *SpeechService {
+	return NewSpeechService().WithSilenceDuration(testSilenceDuration)
+}
+
+// scriptedBackend replays a fixed sequence of frames, then reports an empty queue
+type scriptedBackend struct {
+	mu     sync.Mutex
+	frames [][]int16
+	read   int
+
+	// Called from the capture loop before the frame at that index is returned
+	before map[int]func()
+}
+
+// newScriptedBackend builds a backend from runs of silence and loud frames
+func newScriptedBackend(runs ...frameRun) *scriptedBackend {
+	b := &scriptedBackend{}
+	for _, run := range runs {
+		for i := 0; i < run.frames; i++ {
+			frame := make([]int16, captureFrame)
+			for j := range frame {
+				frame[j] = run.amplitude
+			}
+			b.frames = append(b.frames, frame)
+		}
+	}
+	return b
+}
+
+// frameRun is a number of consecutive frames at one amplitude
+type frameRun struct {
+	frames    int
+	amplitude int16
+}
+
+func silence(frames int) frameRun { return frameRun{frames, 0} }
+func loud(frames int) frameRun    { return frameRun{frames, 2000} }
+
+// trailing is quiet speech just under the VAD threshold
+func trailing(frames int) frameRun { return frameRun{frames, VadThreshold * 7 / 10} }
+
+func (b *scriptedBackend) Pause(bool) {}
+func (b *scriptedBackend) Clear()     {}
+
+func (b *scriptedBackend) Dequeue(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.frames) == 0 {
+		return 0, nil
+	}
+	if fn := b.before[b.read]; fn != nil {
+		fn()
+	}
+	b.read++
+	frame := b.frames[0]
+	b.frames = b.frames[1:]
+	for i, sample := range frame {
+		data[i*2] = byte(sample)
+		data[i*2+1] = byte(sample >> 8)
+	}
+	return len(frame) * 2, nil
+}
+
+// runCapture runs the capture loop over backend until it has been drained and
+// the scripted audio has had time to settle, and returns the delivered recordings
+// and discarded durations
+func runCapture(t *testing.T, svc *SpeechService, backend *scriptedBackend) ([]*AudioData, []time.Duration) {
+	t.Helper()
+	svc.backend = backend
+	svc.isListening = true
+	done := make(chan struct{})
+	go func() {
+		svc.captureAudio()
+		close(done)
+	}()
+
+	var recordings []*AudioData
+	var discarded []time.Duration
+	deadline := time.After(5 * time.Second)
+	var idle <-chan time.Time
+	for {
+		// Keep collecting until the script is exhausted and nothing more arrives
+		backend.mu.Lock()
+		drained := len(backend.frames) == 0
+		backend.mu.Unlock()
+		if drained && idle == nil {
+			// A merged recording is held back for the gap before delivery
+			idle = time.After(300*time.Millisecond + svc.mergeGap)
+		}
+
+		select {
+		case audio := <-svc.recordingStopped:
+			recordings = append(recordings, audio)
+		case d := <-svc.recordingDiscarded:
+			discarded = append(discarded, d)
+		case <-idle:
+			svc.StopListening()
+			<-done
+			return recordings, discarded
+		case <-deadline:
+			t.Fatal("Timed out running the capture script")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// TestCaptureRecordingBoundaries drives scripted audio through the VAD and
+// recording state machine
+func TestCaptureRecordingBoundaries(t *testing.T) {
+	// The test silence window ends a recording after two 50ms frames
+	silenceFrames := testSilenceDuration.Milliseconds() / (captureFrame * 1000 / AudioFrequency)
+
+	tests := []struct {
+		name          string
+		mergeGap      time.Duration
+		endpointing   bool
+		runs          []frameRun
+		wantSamples   []int
+		wantDiscarded int
+	}{
+		{
+			name:        "one second of speech",
+			runs:        []frameRun{silence(4), loud(20), silence(6)},
+			wantSamples: []int{(20 + int(silenceFrames)) * captureFrame},
+		},
+		{
+			name:          "burst too short",
+			runs:          []frameRun{silence(2), loud(2), silence(6)},
+			wantDiscarded: 1,
+		},
+		{
+			name:        "pause splits utterances",
+			runs:        []frameRun{loud(10), silence(4), loud(10), silence(6)},
+			wantSamples: []int{(10 + int(silenceFrames)) * captureFrame, (10 + int(silenceFrames)) * captureFrame},
+		},
+		{
+			name:        "pause within merge gap",
+			mergeGap:    500 * time.Millisecond,
+			runs:        []frameRun{loud(10), silence(4), loud(10), silence(6)},
+			wantSamples: []int{(20 + 2*int(silenceFrames)) * captureFrame},
+		},
+		{
+			name:        "trailing off cut without endpointing",
+			runs:        []frameRun{loud(10), trailing(3), loud(6), silence(6)},
+			wantSamples: []int{(10 + int(silenceFrames)) * captureFrame, (6 + int(silenceFrames)) * captureFrame},
+		},
+		{
+			name:        "trailing off kept with endpointing",
+			endpointing: true,
+			runs:        []frameRun{loud(10), trailing(3), loud(6), silence(6)},
+			wantSamples: []int{(10 + 3 + 6 + int(silenceFrames)) * captureFrame},
+		},
+		{
+			name:        "true silence ends with endpointing",
+			endpointing: true,
+			runs:        []frameRun{loud(10), silence(6)},
+			wantSamples: []int{(10 + int(silenceFrames)) * captureFrame},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newCaptureService().WithUtteranceMergeGap(tt.mergeGap)
+			if tt.endpointing {
+				svc.WithSmartEndpointing(0.5, 200*time.Millisecond)
+			}
+			recordings, discarded := runCapture(t, svc, newScriptedBackend(tt.runs...))
+
+			if len(recordings) != len(tt.wantSamples) {
+				t.Fatalf("Expected %d recordings, got %d", len(tt.wantSamples), len(recordings))
+			}
+			for i, want := range tt.wantSamples {
+				if got := len(recordings[i].Samples); got != want {
+					t.Errorf("Recording %d: expected %d samples, got %d", i, want, got)
+				}
+			}
+			if len(discarded) != tt.wantDiscarded {
+				t.Errorf("Expected %d discarded bursts, got %v", tt.wantDiscarded, discarded)
+			}
+		})
+	}
+}
+
+// TestCancelRecording verifies a cancelled recording is dropped without being
+// delivered or reported as discarded
+func TestCancelRecording(t *testing.T) {
+	svc := newCaptureService()
+	if err := svc.CancelRecording(); err != ErrNotRecording {
+		t.Errorf("Expected ErrNotRecording when idle, got %v", err)
+	}
+
+	backend := newScriptedBackend(silence(2), loud(10), silence(6))
+	var cancelErr error
+	// Cancel once the speech has ended but before the silence window closes
+	backend.before = map[int]func(){12: func() { cancelErr = svc.CancelRecording() }}
+
+	recordings, discarded := runCapture(t, svc, backend)
+	if cancelErr != nil {
+		t.Errorf("Expected the recording to be cancelled, got %v", cancelErr)
+	}
+	if len(recordings) != 0 || len(discarded) != 0 {
+		t.Errorf("Expected nothing delivered, got %d recordings and %d discards", len(recordings), len(discarded))
+	}
+	if svc.IsRecording() {
+		t.Error("Expected the service to be back to listening")
+	}
+}
+
+// TestCaptureClipping verifies full-scale samples are counted per recording
+func TestCaptureClipping(t *testing.T) {
+	svc := newCaptureService()
+	backend := newScriptedBackend(loud(6), frameRun{4, math.MaxInt16}, frameRun{2, math.MinInt16}, silence(6))
+
+	recordings, _ := runCapture(t, svc, backend)
+	if len(recordings) != 1 {
+		t.Fatalf("Expected 1 recording, got %d", len(recordings))
+	}
+	if got, want := recordings[0].Clipped, 6*captureFrame; got != want {
+		t.Errorf("Expected %d clipped samples, got %d", want, got)
+	}
+	if got := recordings[0].ClippedFraction(); got <= ClipWarningFraction {
+		t.Errorf("Expected the clipping to be over the warning level, got %v", got)
+	}
+}
+
+// TestCapturePanic verifies a panic in the capture loop stops listening and
+// is reported to waiters instead of crashing
+func TestCapturePanic(t *testing.T) {
+	svc := newCaptureService()
+	backend := newScriptedBackend(loud(4), silence(4))
+	backend.before = map[int]func(){2: func() { panic("device went away") }}
+	svc.backend = backend
+	svc.isListening = true
+
+	done := make(chan struct{})
+	go func() {
+		svc.captureAudio()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Capture loop didn't exit after the panic")
+	}
+
+	if svc.IsListening() {
+		t.Error("Expected listening to stop after the panic")
+	}
+	if _, err := svc.WaitForRecording(); !errors.Is(err, ErrCaptureFailed) {
+		t.Errorf("Expected ErrCaptureFailed, got %v", err)
+	}
+}
+
+// TestCaptureInputGain verifies a gain set while capturing boosts the next
+// frames above the VAD threshold
+func TestCaptureInputGain(t *testing.T) {
+	svc := newCaptureService()
+	backend := newScriptedBackend(frameRun{10, 50}, silence(6), frameRun{10, 50}, silence(6))
+	backend.before = map[int]func(){16: func() { svc.SetInputGain(4) }}
+
+	recordings, _ := runCapture(t, svc, backend)
+	if len(recordings) != 1 {
+		t.Fatalf("Expected only the boosted burst to be recorded, got %d recordings", len(recordings))
+	}
+	if got := recordings[0].Samples[0]; got != 200 {
+		t.Errorf("Expected boosted samples of 200, got %d", got)
+	}
+	if got := svc.InputGain(); got != 4 {
+		t.Errorf("Expected gain 4, got %v", got)
+	}
+}
+
+// TestCaptureVadThreshold verifies a threshold set while capturing applies to
+// the next frames, and that triggers and the noise floor are tracked
+func TestCaptureVadThreshold(t *testing.T) {
+	svc := newCaptureService()
+	backend := newScriptedBackend(frameRun{4, 50}, loud(10), silence(6), loud(10), silence(6))
+	// Raise the threshold above the loud frames before the second burst
+	backend.before = map[int]func(){20: func() { svc.SetVadThreshold(3000) }}
+
+	recordings, _ := runCapture(t, svc, backend)
+	if len(recordings) != 1 {
+		t.Fatalf("Expected only the first burst to be recorded, got %d recordings", len(recordings))
+	}
+	if got := svc.CurrentThreshold(); got != 3000 {
+		t.Errorf("Expected threshold 3000, got %d", got)
+	}
+
+	events := svc.RecentVadEvents()
+	if len(events) != 2 || !events[0].Started || events[0].Level != 2000 || events[1].Started {
+		t.Errorf("Expected a start and an end event, got %+v", events)
+	}
+	// The background before speech and the loud frames below the raised threshold
+	if floor := svc.NoiseFloor(); floor <= 0 || floor >= 2000 {
+		t.Errorf("Expected a noise floor between the quiet and loud levels, got %d", floor)
+	}
+}
+
+// TestSyntheticInput verifies generated tone bursts go through the real
+// capture pipeline and come out as recordings, without SDL
+func TestSyntheticInput(t *testing.T) {
+	svc := newCaptureService().WithSyntheticInput(ToneBursts(400*time.Millisecond, 400*time.Millisecond))
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer svc.Cleanup()
+	if err := svc.StartListening(); err != nil {
+		t.Fatalf("StartListening failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	audio, err := svc.WaitForRecordingContext(ctx)
+	if err != nil {
+		t.Fatalf("Expected a recording of the first burst, got %v", err)
+	}
+	if d := samplesDuration(len(audio.Samples)); d < 300*time.Millisecond || d > time.Second {
+		t.Errorf("Expected a recording about as long as the burst, got %v", d)
+	}
+}
@@ -0,0 +1,62 @@
+package speech
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// WithDrain makes shutdown wait up to timeout for transcriptions in progress
+// to finish before stopping the server, so the last utterance isn't lost.
+// New transcriptions are refused with ErrShuttingDown meanwhile. Zero stops
+// the server at once.
+func (s *WhisperServerService) WithDrain(timeout time.Duration) *WhisperServerService {
+	s.drainTimeout = timeout
+	return s
+}
+
+// ShutdownContext implements common.ContextShutdownable, draining
+// transcriptions in progress if WithDrain is set, for no longer than ctx allows
+func (s *WhisperServerService) ShutdownContext(ctx context.Context) error {
+	s.drain(ctx)
+	return s.Cleanup()
+}
+
+// beginTranscription counts a transcription as in progress, unless the
+// service is draining
+func (s *WhisperServerService) beginTranscription() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.draining {
+		return ErrShuttingDown
+	}
+	s.inFlight.Add(1)
+	return nil
+}
+
+// drain refuses new transcriptions and waits for those in progress
+func (s *WhisperServerService) drain(ctx context.Context) {
+	s.mutex.Lock()
+	timeout := s.drainTimeout
+	if timeout > 0 {
+		s.draining = true
+	}
+	s.mutex.Unlock()
+	if timeout <= 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Stopping the whisper server with a transcription still in progress")
+	}
+}
@@ -0,0 +1,168 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// EnsembleStrategy picks the result an EnsembleTranscriber returns
+type EnsembleStrategy int
+
+const (
+	// EnsembleConfidence picks the result with the highest average segment
+	// confidence. Results without confidences lose to those with them.
+	EnsembleConfidence EnsembleStrategy = iota
+	// EnsembleAgreement picks the result sharing the most words, in order,
+	// with the other results, falling back to confidence on a tie. It needs
+	// three or more transcribers to differ from EnsembleConfidence.
+	EnsembleAgreement
+)
+
+// ParseEnsembleStrategy parses "confidence" or "agreement"
+func ParseEnsembleStrategy(name string) (EnsembleStrategy, error) {
+	switch name {
+	case "confidence":
+		return EnsembleConfidence, nil
+	case "agreement":
+		return EnsembleAgreement, nil
+	}
+	return 0, fmt.Errorf("unknown ensemble strategy %q (want confidence or agreement)", name)
+}
+
+// EnsembleTranscriber runs each recording through several transcribers at
+// once, e.g. servers with different models, and returns the best result. It
+// is as slow as the slowest of them.
+type EnsembleTranscriber struct {
+	transcribers []Transcriber
+	strategy     EnsembleStrategy
+}
+
+// NewEnsembleTranscriber creates an ensemble of transcribers. Ties go to the
+// one listed first. Transcribers that take options, like
+// WhisperServerService, are asked for segment confidences on every request,
+// whatever their response format, since the strategies rely on them.
+func NewEnsembleTranscriber(strategy EnsembleStrategy, transcribers ...Transcriber) *EnsembleTranscriber {
+	members := make([]Transcriber, len(transcribers))
+	for i, transcriber := range transcribers {
+		members[i] = transcriber
+		if t, ok := transcriber.(optionsTranscriber); ok {
+			members[i] = confidenceTranscriber{t}
+		}
+	}
+	return &EnsembleTranscriber{transcribers: members, strategy: strategy}
+}
+
+// optionsTranscriber is a Transcriber that also takes per-request options
+type optionsTranscriber interface {
+	TranscribeWithOptions(audioData *AudioData, opts TranscribeOptions) (*WhisperServerResult, error)
+}
+
+// confidenceTranscriber requests segment confidences with every transcription
+type confidenceTranscriber struct {
+	optionsTranscriber
+}
+
+func (t confidenceTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	return t.TranscribeWithOptions(audioData, TranscribeOptions{WantConfidence: true})
+}
+
+// Transcribe implements Transcriber. Transcribers that fail are left out, and
+// the first error is returned only if all of them fail.
+func (e *EnsembleTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	if len(e.transcribers) == 0 {
+		return nil, errors.New("ensemble has no transcribers")
+	}
+
+	results := make([]*WhisperServerResult, len(e.transcribers))
+	errs := make([]error, len(e.transcribers))
+	var wg sync.WaitGroup
+	for i, transcriber := range e.transcribers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = transcribeRecovered(transcriber, audioData)
+		}()
+	}
+	wg.Wait()
+
+	var candidates []*WhisperServerResult
+	for i, result := range results {
+		if errs[i] != nil {
+			log.Printf("Ensemble transcriber %d failed: %v", i+1, errs[i])
+			continue
+		}
+		candidates = append(candidates, result)
+	}
+	if len(candidates) == 0 {
+		return nil, errs[0]
+	}
+	return e.pick(candidates), nil
+}
+
+// pick returns the best of results according to the strategy
+func (e *EnsembleTranscriber) pick(results []*WhisperServerResult) *WhisperServerResult {
+	agreement := make([]int, len(results))
+	if e.strategy == EnsembleAgreement {
+		words := make([][]string, len(results))
+		for i, result := range results {
+			words[i] = ensembleWords(result.Text)
+		}
+		for i := range results {
+			for j := range results {
+				if i != j {
+					agreement[i] += commonWords(words[i], words[j])
+				}
+			}
+		}
+	}
+
+	best := 0
+	for i := 1; i < len(results); i++ {
+		if agreement[i] != agreement[best] {
+			if agreement[i] > agreement[best] {
+				best = i
+			}
+			continue
+		}
+		if ensembleConfidence(results[i]) > ensembleConfidence(results[best]) {
+			best = i
+		}
+	}
+	return results[best]
+}
+
+// ensembleConfidence is a result's confidence, below any real one if unknown
+func ensembleConfidence(result *WhisperServerResult) float64 {
+	if confidence, ok := result.Confidence(); ok {
+		return confidence
+	}
+	return -1
+}
+
+// ensembleWords splits text into lowercase words without punctuation
+func ensembleWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// commonWords is the length of the longest common subsequence of a and b
+func commonWords(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,161 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fixedTranscriber returns the same text and confidence, or error, every time
+type fixedTranscriber struct {
+	text       string
+	confidence float64
+	err        error
+}
+
+func (f fixedTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &WhisperServerResult{
+		Text:     f.text,
+		Segments: []WhisperSegment{{Text: f.text, Confidence: f.confidence}},
+	}, nil
+}
+
+// TestEnsembleTranscriber verifies each strategy's pick, and that failing
+// transcribers are left out
+func TestEnsembleTranscriber(t *testing.T) {
+	audio := &AudioData{Samples: make([]int16, 10), SampleRate: AudioFrequency}
+	failed := errors.New("server down")
+
+	tests := []struct {
+		name         string
+		strategy     EnsembleStrategy
+		transcribers []Transcriber
+		want         string
+		wantErr      error
+	}{
+		{
+			name:     "highest confidence",
+			strategy: EnsembleConfidence,
+			transcribers: []Transcriber{
+				fixedTranscriber{text: "recognize speech", confidence: 0.6},
+				fixedTranscriber{text: "wreck a nice beach", confidence: 0.9},
+			},
+			want: "wreck a nice beach",
+		},
+		{
+			name:     "tie goes to the first",
+			strategy: EnsembleConfidence,
+			transcribers: []Transcriber{
+				fixedTranscriber{text: "first"},
+				fixedTranscriber{text: "second"},
+			},
+			want: "first",
+		},
+		{
+			name:     "most agreement",
+			strategy: EnsembleAgreement,
+			transcribers: []Transcriber{
+				fixedTranscriber{text: "the quick brown fox", confidence: 0.5},
+				fixedTranscriber{text: "a quack brown box", confidence: 0.95},
+				fixedTranscriber{text: "The quick brown fox jumps.", confidence: 0.6},
+			},
+			want: "The quick brown fox jumps.",
+		},
+		{
+			name:     "agreement tie falls back to confidence",
+			strategy: EnsembleAgreement,
+			transcribers: []Transcriber{
+				fixedTranscriber{text: "hello there", confidence: 0.5},
+				fixedTranscriber{text: "hello their", confidence: 0.8},
+			},
+			want: "hello their",
+		},
+		{
+			name:     "failure left out",
+			strategy: EnsembleConfidence,
+			transcribers: []Transcriber{
+				fixedTranscriber{err: failed},
+				fixedTranscriber{text: "still here", confidence: 0.1},
+			},
+			want: "still here",
+		},
+		{
+			name:     "all failed",
+			strategy: EnsembleConfidence,
+			transcribers: []Transcriber{
+				fixedTranscriber{err: failed},
+				fixedTranscriber{err: errors.New("also down")},
+			},
+			wantErr: failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewEnsembleTranscriber(tt.strategy, tt.transcribers...).Transcribe(audio)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Text != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, result.Text)
+			}
+		})
+	}
+}
+
+// newSegmentServer starts a whisper service whose server reports text with
+// segment confidences only when verbose_json is requested, as whisper.cpp does
+func newSegmentServer(t *testing.T, text string, avgLogprob float64) *WhisperServerService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("response_format") != ResponseFormatVerboseJSON {
+			fmt.Fprintf(w, `{"text": %q}`, text)
+			return
+		}
+		fmt.Fprintf(w, `{"text": %q, "segments": [{"text": %q, "avg_logprob": %v}]}`, text, text, avgLogprob)
+	}))
+	t.Cleanup(server.Close)
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+	return svc
+}
+
+// TestEnsembleDefaultServices verifies services in their default json
+// configuration are compared by confidence rather than always picking the first
+func TestEnsembleDefaultServices(t *testing.T) {
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	for _, strategy := range []EnsembleStrategy{EnsembleConfidence, EnsembleAgreement} {
+		ensemble := NewEnsembleTranscriber(strategy,
+			newSegmentServer(t, "recognize speech", -1.5),
+			newSegmentServer(t, "wreck a nice beach", -0.1))
+		result, err := ensemble.Transcribe(audio)
+		if err != nil {
+			t.Fatalf("Transcribe failed: %v", err)
+		}
+		if result.Text != "wreck a nice beach" {
+			t.Errorf("Strategy %d: expected the more confident result, got %q", strategy, result.Text)
+		}
+	}
+}
+
+// TestParseEnsembleStrategy verifies strategy names and rejects unknown ones
+func TestParseEnsembleStrategy(t *testing.T) {
+	if strategy, err := ParseEnsembleStrategy("agreement"); err != nil || strategy != EnsembleAgreement {
+		t.Errorf("Expected EnsembleAgreement, got %v, %v", strategy, err)
+	}
+	if _, err := ParseEnsembleStrategy("vote"); err == nil {
+		t.Error("Expected an unknown strategy to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package speech
+
+import (
+	"log"
+	"time"
+)
+
+// DefaultHealthFailures is how many health checks in a row a running server
+// may fail before it is considered hung
+const DefaultHealthFailures = 3
+
+// ServerHealth is the outcome of the latest periodic health check
+type ServerHealth struct {
+	Healthy  bool      // Whether the server answered
+	Checked  time.Time // When it was checked, zero before the first check
+	Failures int       // Checks failed in a row
+}
+
+// WithHealthCheck pings the running server every interval and restarts it
+// after DefaultHealthFailures failed pings in a row, catching a server that
+// hangs without exiting. Servers we didn't spawn are only reported unhealthy.
+// Zero disables the check.
+func (s *WhisperServerService) WithHealthCheck(interval time.Duration) *WhisperServerService {
+	s.healthInterval = interval
+	return s
+}
+
+// Health returns the result of the latest health check
+func (s *WhisperServerService) Health() ServerHealth {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.health
+}
+
+// startHealthCheck starts the health checker if it is enabled and not
+// already running. The caller must hold s.mutex.
+func (s *WhisperServerService) startHealthCheck() {
+	if s.healthInterval <= 0 || s.healthStop != nil {
+		return
+	}
+	s.health = ServerHealth{}
+	s.healthStop = make(chan struct{})
+	go s.watchHealth(s.serverURL, s.healthInterval, s.healthStop)
+}
+
+// stopHealthCheck stops the health checker. The caller must hold s.mutex.
+func (s *WhisperServerService) stopHealthCheck() {
+	if s.healthStop != nil {
+		close(s.healthStop)
+		s.healthStop = nil
+	}
+}
+
+// watchHealth pings url every interval until stop is closed
+func (s *WhisperServerService) watchHealth(url string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		healthy := serverHealthy(url)
+		s.mutex.Lock()
+		s.health.Healthy = healthy
+		s.health.Checked = time.Now()
+		if healthy {
+			s.health.Failures = 0
+		} else {
+			s.health.Failures++
+		}
+		failures := s.health.Failures
+		s.mutex.Unlock()
+
+		if failures == DefaultHealthFailures {
+			log.Printf("Whisper server failed %d health checks in a row", failures)
+			if s.restartUnhealthy() {
+				return
+			}
+		}
+	}
+}
+
+// restartUnhealthy restarts a hung server we spawned, reporting whether it
+// did. Restarting starts a new health checker.
+func (s *WhisperServerService) restartUnhealthy() bool {
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+
+	s.mutex.Lock()
+	spawned := s.cmd != nil && !s.attached && s.isRunning
+	s.mutex.Unlock()
+	if !spawned {
+		return false
+	}
+
+	log.Printf("Restarting unresponsive whisper server")
+	if err := s.restartSpawned(); err != nil {
+		log.Printf("Failed to restart whisper server: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package speech
+
+import "strings"
+
+// LanguageAuto asks whisper to detect the spoken language
+const LanguageAuto = "auto"
+
+// languageCodes maps whisper's full language names to their codes, for the
+// most widely spoken of the languages whisper supports
+var languageCodes = map[string]string{
+	"arabic":     "ar",
+	"chinese":    "zh",
+	"czech":      "cs",
+	"danish":     "da",
+	"dutch":      "nl",
+	"english":    "en",
+	"finnish":    "fi",
+	"french":     "fr",
+	"german":     "de",
+	"greek":      "el",
+	"hebrew":     "he",
+	"hindi":      "hi",
+	"hungarian":  "hu",
+	"indonesian": "id",
+	"italian":    "it",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"norwegian":  "no",
+	"persian":    "fa",
+	"polish":     "pl",
+	"portuguese": "pt",
+	"romanian":   "ro",
+	"russian":    "ru",
+	"spanish":    "es",
+	"swedish":    "sv",
+	"thai":       "th",
+	"turkish":    "tr",
+	"ukrainian":  "uk",
+	"vietnamese": "vi",
+}
+
+// languageCode normalizes a language reported by the server to its code.
+// Codes and unrecognized names are returned lowercased as they are.
+func languageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	return language
+}
@@ -0,0 +1,79 @@
+package speech
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ModelInfo describes a whisper model file found on disk
+type ModelInfo struct {
+	Name string // Short name, e.g. "base.en" for ggml-base.en.bin
+	Path string // Full path to the model file
+	Size int64  // File size in bytes
+}
+
+// DefaultModelsDir returns the directory containing the default model
+func DefaultModelsDir() string {
+	return filepath.Dir(NewDefaultWhisperServerConfig().ModelPath)
+}
+
+// DiscoverModels lists the ggml-*.bin model files in dir, sorted by name
+func DiscoverModels(dir string) ([]ModelInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "ggml-*.bin"))
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]ModelInfo, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "ggml-"), ".bin")
+		models = append(models, ModelInfo{Name: name, Path: path, Size: info.Size()})
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// ResolveModel maps a short model name like "tiny" to a model file in dir.
+// An exact name match wins, otherwise the name must be a unique prefix.
+// A name that is already a path to an existing file is returned unchanged.
+func ResolveModel(dir, name string) (string, error) {
+	if info, err := os.Stat(name); err == nil && !info.IsDir() {
+		return name, nil
+	}
+
+	models, err := DiscoverModels(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []ModelInfo
+	for _, model := range models {
+		if model.Name == name {
+			return model.Path, nil
+		}
+		if strings.HasPrefix(model.Name, name) {
+			matches = append(matches, model)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no model named %q in %s", name, dir)
+	case 1:
+		return matches[0].Path, nil
+	default:
+		names := make([]string, len(matches))
+		for i, model := range matches {
+			names[i] = model.Name
+		}
+		return "", fmt.Errorf("model name %q is ambiguous, matches: %s", name, strings.Join(names, ", "))
+	}
+}
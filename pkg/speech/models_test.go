@@ -0,0 +1,82 @@
+package speech
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModels creates empty model files with the given sizes in a temp directory
+func writeModels(t *testing.T, files map[string]int) string {
+	dir := t.TempDir()
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// TestDiscoverModels verifies model files are found, named and sized
+func TestDiscoverModels(t *testing.T) {
+	dir := writeModels(t, map[string]int{
+		"ggml-tiny.bin":    10,
+		"ggml-base.en.bin": 20,
+		"other.bin":        5,
+		"ggml-notes.txt":   5,
+	})
+
+	models, err := DiscoverModels(dir)
+	if err != nil {
+		t.Fatalf("Failed to discover models: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %d: %+v", len(models), models)
+	}
+	if models[0].Name != "base.en" || models[0].Size != 20 {
+		t.Errorf("Unexpected first model: %+v", models[0])
+	}
+	if models[1].Name != "tiny" || models[1].Path != filepath.Join(dir, "ggml-tiny.bin") {
+		t.Errorf("Unexpected second model: %+v", models[1])
+	}
+}
+
+// TestResolveModel verifies short names resolve to model paths
+func TestResolveModel(t *testing.T) {
+	dir := writeModels(t, map[string]int{
+		"ggml-tiny.bin":    1,
+		"ggml-tiny.en.bin": 1,
+		"ggml-base.en.bin": 1,
+	})
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"tiny", filepath.Join(dir, "ggml-tiny.bin"), false},
+		{"base", filepath.Join(dir, "ggml-base.en.bin"), false},
+		{"tin", "", true},
+		{"large", "", true},
+		{filepath.Join(dir, "ggml-tiny.en.bin"), filepath.Join(dir, "ggml-tiny.en.bin"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveModel(dir, tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package speech
+
+import (
+	"mime/multipart"
+)
+
+// OpenAI-compatible API defaults
+const (
+	OpenAITranscriptionPath = "/v1/audio/transcriptions"
+	OpenAITranslationPath   = "/v1/audio/translations"
+	DefaultOpenAIModel      = "whisper-1"
+)
+
+// WithOpenAICompatible talks to the server through the OpenAI audio API
+// instead of whisper.cpp's, for hosted services and servers such as
+// faster-whisper that only offer that. Requests go to
+// OpenAITranscriptionPath unless WithInferenceEndpoint chose another path,
+// and translations to OpenAITranslationPath. Use it with WithServerURL.
+func (s *WhisperServerService) WithOpenAICompatible(enabled bool) *WhisperServerService {
+	s.openAI = enabled
+	if enabled && s.inferencePath == DefaultInferencePath {
+		s.inferencePath = OpenAITranscriptionPath
+	}
+	return s
+}
+
+// WithOpenAIModel sets the model name sent to an OpenAI-compatible server
+func (s *WhisperServerService) WithOpenAIModel(name string) *WhisperServerService {
+	s.openAIModel = name
+	return s
+}
+
+// WithAPIKey sends key as a bearer token with every transcription request
+func (s *WhisperServerService) WithAPIKey(key string) *WhisperServerService {
+	s.apiKey = key
+	return s
+}
+
+// writeOpenAIFields writes the form fields of an OpenAI transcription request.
+// The API takes a single temperature, and rejects "auto" as a language, so
+// that is left out to let the server detect it.
+func (s *WhisperServerService) writeOpenAIFields(writer *multipart.Writer, language, prompt, responseFormat string, temperature float64) {
+	writer.WriteField("model", s.openAIModel)
+	writer.WriteField("response_format", responseFormat)
+	writer.WriteField("temperature", formatTemperature(temperature))
+	if language != "" && language != LanguageAuto {
+		writer.WriteField("language", language)
+	}
+	if prompt != "" {
+		writer.WriteField("prompt", prompt)
+	}
+}
+
+// openAIPath returns the endpoint an OpenAI-compatible request is posted to,
+// which differs for translations
+func (s *WhisperServerService) openAIPath(opts TranscribeOptions) string {
+	if opts.Translate != nil && *opts.Translate && s.inferencePath == OpenAITranscriptionPath {
+		return OpenAITranslationPath
+	}
+	return s.inferencePath
+}
@@ -0,0 +1,75 @@
+package speech
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ErrNoRecording is returned when there is no recording to play back yet
+var ErrNoRecording = errors.New("no recording yet")
+
+// LastRecording returns the most recently delivered recording, or nil
+func (s *SpeechService) LastRecording() *AudioData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastRecording
+}
+
+// PlayLastRecording plays the most recently delivered recording through the
+// default output device, blocking until it finishes. Hearing exactly what was
+// captured tells a capture problem apart from a model problem. Capture is
+// suppressed meanwhile so the playback isn't recorded again.
+func (s *SpeechService) PlayLastRecording() error {
+	s.mutex.Lock()
+	audio := s.lastRecording
+	initialized := s.isInitialized
+	s.mutex.Unlock()
+
+	if audio == nil {
+		return ErrNoRecording
+	}
+	if !initialized {
+		return errors.New("audio not initialized")
+	}
+
+	spec := sdl.AudioSpec{
+		Freq:     AudioFrequency,
+		Format:   AudioFormat,
+		Channels: AudioChannels,
+		Samples:  uint16(s.frameSize),
+	}
+	var obtainedSpec sdl.AudioSpec
+	deviceID, err := sdl.OpenAudioDevice("", false, &spec, &obtainedSpec, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open playback device: %v", err)
+	}
+	defer sdl.CloseAudioDevice(deviceID)
+
+	s.SetOutputActive(true)
+	defer s.SetOutputActive(false)
+
+	if err := sdl.QueueAudio(deviceID, samplesToBytes(audio.Samples)); err != nil {
+		return fmt.Errorf("failed to queue playback audio: %v", err)
+	}
+	sdl.PauseAudioDevice(deviceID, false)
+
+	for sdl.GetQueuedAudioSize(deviceID) > 0 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// The device still holds its last buffer once the queue is empty
+	time.Sleep(samplesDuration(int(obtainedSpec.Samples)))
+	return nil
+}
+
+// samplesToBytes encodes samples as little-endian 16-bit PCM
+func samplesToBytes(samples []int16) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	return data
+}
@@ -0,0 +1,110 @@
+package speech
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// autoPortAttempts is how many ports after the configured one WithAutoPort tries
+const autoPortAttempts = 100
+
+// WithAutoPort starts the server on the next free port when the configured
+// one is taken, e.g. by another conch. Without it, a whisper server already
+// on the port is used as is and anything else there fails Initialize.
+func (s *WhisperServerService) WithAutoPort(enabled bool) *WhisperServerService {
+	s.autoPort = enabled
+	return s
+}
+
+// WithServerURL uses the server already running at url, e.g. on another
+// machine or a hosted API, instead of starting whisper-server. It is never
+// stopped by Cleanup.
+func (s *WhisperServerService) WithServerURL(url string) *WhisperServerService {
+	s.externalURL = strings.TrimSuffix(url, "/")
+	return s
+}
+
+// useExternalServer points requests at the server set with WithServerURL.
+// The caller holds the mutex.
+func (s *WhisperServerService) useExternalServer() {
+	s.serverURL = s.externalURL
+	s.attached = true
+	s.isRunning = true
+	s.startTime = time.Now()
+	log.Printf("Using the whisper server at %s", s.serverURL)
+}
+
+// serverURLFor returns the base URL of a server on host:port, bracketing
+// IPv6 addresses
+func serverURLFor(host string, port int) string {
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// checkHost rejects a host that is neither an IP address nor a name that
+// resolves, before it turns into a confusing spawn or probe failure
+func checkHost(host string) error {
+	if host == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidHost)
+	}
+	if strings.HasPrefix(host, "[") {
+		return fmt.Errorf("%w: %q (write IPv6 addresses without brackets)", ErrInvalidHost, host)
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if _, err := net.LookupHost(host); err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidHost, host, err)
+	}
+	return nil
+}
+
+// portFree reports whether host:port can be listened on
+func portFree(host string, port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// claimPort settles where the server runs when the configured port is taken,
+// before anything is spawned. It returns true if an existing whisper server
+// there was attached to instead. The caller holds the mutex.
+func (s *WhisperServerService) claimPort() (bool, error) {
+	host, port := s.config.Host, s.config.Port
+	if portFree(host, port) {
+		return false, nil
+	}
+
+	if s.autoPort {
+		for next := port + 1; next <= port+autoPortAttempts && next <= 65535; next++ {
+			if portFree(host, next) {
+				log.Printf("Port %d is in use, starting the whisper server on port %d", port, next)
+				// The config may be shared with the caller, so don't change it in place
+				config := *s.config
+				config.Port = next
+				s.config = &config
+				return false, nil
+			}
+		}
+		return false, fmt.Errorf("%w: no free port in %d-%d", ErrPortInUse, port, port+autoPortAttempts)
+	}
+
+	// Probably another conch's server. Share it rather than start a second
+	// one that can't bind, and leave it running for its owner.
+	url := serverURLFor(host, port)
+	if !serverHealthy(url) {
+		return false, fmt.Errorf("%w: %s is taken by something that isn't a whisper server (try --auto-port)", ErrPortInUse, net.JoinHostPort(host, strconv.Itoa(port)))
+	}
+	s.serverURL = url
+	s.attached = true
+	s.isRunning = true
+	s.startTime = time.Now()
+	log.Printf("A whisper server is already running at %s, using it. Its model may differ from %s; use --auto-port to start a separate one.", url, s.config.ModelPath)
+	return true, nil
+}
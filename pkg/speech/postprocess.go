@@ -0,0 +1,174 @@
+package speech
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Capitalize applies conservative sentence casing to a transcription: the first
+// word of each sentence and the pronoun "I" are capitalized, and a period is
+// added if the text doesn't end in punctuation. Words containing anything other
+// than lowercase letters and apostrophes (code, acronyms, mixed case) are never
+// changed, and nothing is ever lowercased.
+func Capitalize(text string) string {
+	var out strings.Builder
+	out.Grow(len(text) + 1)
+
+	sentenceStart := true
+	rest := text
+	for len(rest) > 0 {
+		// Copy whitespace through unchanged
+		if i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) }); i != 0 {
+			if i < 0 {
+				i = len(rest)
+			}
+			out.WriteString(rest[:i])
+			rest = rest[i:]
+			continue
+		}
+
+		// Take the next whitespace-delimited word
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+
+		if sentenceStart || isPronounI(word) {
+			word = capitalizeWord(word)
+		}
+		out.WriteString(word)
+
+		// A sentence ends at terminal punctuation followed by whitespace, so
+		// "e.g." or "3.14" in the middle of a word doesn't count
+		sentenceStart = endsSentence(word)
+	}
+
+	result := out.String()
+	trimmed := strings.TrimRightFunc(result, unicode.IsSpace)
+	if trimmed == "" {
+		return result
+	}
+
+	// Ensure terminal punctuation when the text ends in a plain word
+	last := []rune(trimmed)[len([]rune(trimmed))-1]
+	if unicode.IsLetter(last) || unicode.IsDigit(last) {
+		return trimmed + "." + result[len(trimmed):]
+	}
+	return result
+}
+
+// isPronounI reports whether word is "i" or a contraction of it, ignoring trailing punctuation
+func isPronounI(word string) bool {
+	switch strings.TrimRightFunc(word, unicode.IsPunct) {
+	case "i", "i'm", "i've", "i'll", "i'd":
+		return true
+	}
+	return false
+}
+
+// capitalizeWord uppercases the first letter of an all-lowercase word, leaving
+// anything that looks like code, an acronym or a proper mixed-case name alone
+func capitalizeWord(word string) string {
+	core := strings.TrimRightFunc(word, unicode.IsPunct)
+	if core == "" {
+		return word
+	}
+	for _, r := range core {
+		if !unicode.IsLower(r) && r != '\'' {
+			return word
+		}
+	}
+
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// abbreviations end in a period but don't end a sentence
+var abbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "vs.": true, "etc.": true,
+}
+
+// endsSentence reports whether word ends with sentence-terminating punctuation
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, "\"')")
+	if strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?") {
+		return true
+	}
+	if !strings.HasSuffix(word, ".") {
+		return false
+	}
+
+	// Dotted abbreviations like "e.g." and common short forms don't end a sentence
+	if strings.Contains(strings.TrimSuffix(word, "."), ".") || abbreviations[strings.ToLower(word)] {
+		return false
+	}
+	return true
+}
+
+// DefaultMaxRepeats is how many times in a row Derepeat lets a phrase repeat
+const DefaultMaxRepeats = 3
+
+// maxLoopWords is the longest phrase, in words, Derepeat looks for repeats of
+const maxLoopWords = 8
+
+// Derepeat collapses whisper's repetition loops: a phrase of up to
+// maxLoopWords words repeated more than maxRepeats times in a row becomes a
+// single instance. Words are compared ignoring case and punctuation, so
+// "Thank you. thank you," repeats. Shorter runs like "very very good" are
+// left alone. It reports whether anything was collapsed; if not, text is
+// returned unchanged.
+func Derepeat(text string, maxRepeats int) (string, bool) {
+	words := strings.Fields(text)
+	keys := make([]string, len(words))
+	for i, word := range words {
+		keys[i] = strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+	}
+
+	var out []string
+	collapsed := false
+	for i := 0; i < len(words); {
+		// Pick the phrase length whose run covers the most words
+		bestLen, bestCount := 0, 0
+		for n := 1; n <= maxLoopWords && i+n <= len(words); n++ {
+			count := repeatCount(keys, i, n)
+			if count > maxRepeats && n*count > bestLen*bestCount {
+				bestLen, bestCount = n, count
+			}
+		}
+		if bestLen == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+
+		// Keep the first instance, ending with the run's last word so
+		// closing punctuation survives
+		end := i + bestLen*bestCount
+		out = append(out, words[i:i+bestLen-1]...)
+		out = append(out, words[end-1])
+		collapsed = true
+		i = end
+	}
+
+	if !collapsed {
+		return text, false
+	}
+	return strings.Join(out, " "), true
+}
+
+// repeatCount counts how many times the n words at start repeat back to back
+func repeatCount(keys []string, start, n int) int {
+	count := 1
+	for next := start + n; next+n <= len(keys); next += n {
+		for j := 0; j < n; j++ {
+			if keys[next+j] != keys[start+j] {
+				return count
+			}
+		}
+		count++
+	}
+	return count
+}
@@ -0,0 +1,67 @@
+package speech
+
+import (
+	"testing"
+)
+
+// TestCapitalize covers representative dictation output
+func TestCapitalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"sentence start and period", "hello world", "Hello world."},
+		{"multiple sentences", "this is one. this is two? yes! ok", "This is one. This is two? Yes! Ok."},
+		{"pronoun I", "i think i'm ready and i've said so", "I think I'm ready and I've said so."},
+		{"keeps existing punctuation", "already done!", "Already done!"},
+		{"preserves leading whitespace", " leading space", " Leading space."},
+		{"leaves acronyms alone", "NASA launched. the API works", "NASA launched. The API works."},
+		{"leaves code alone", "run ls -la then git status", "Run ls -la then git status."},
+		{"leaves mixed case alone", "iPhone sales grew", "iPhone sales grew."},
+		{"dotted abbreviation", "use e.g. this one", "Use e.g. this one."},
+		{"title abbreviation", "ask dr. smith", "Ask dr. smith."},
+		{"decimal numbers", "pi is 3.14", "Pi is 3.14."},
+		{"ends in code symbol", "call foo()", "Call foo()"},
+		{"empty", "", ""},
+		{"whitespace only", "  ", "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Capitalize(tt.input)
+			if got != tt.expected {
+				t.Errorf("Capitalize(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDerepeat verifies repetition loops collapse while repetition within the limit stays
+func TestDerepeat(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  string
+		collapsed bool
+	}{
+		{"looped phrase", "Thank you. Thank you. Thank you. Thank you. Thank you.", "Thank you.", true},
+		{"loop after real text", "see you tomorrow thank you thank you thank you thank you thank you", "see you tomorrow thank you", true},
+		{"single word loop", "no no no no no no way", "no way", true},
+		{"longer phrase loop", "and then we go and then we go and then we go and then we go home", "and then we go home", true},
+		{"near-identical case and punctuation", "okay, Okay okay. OKAY okay!", "okay!", true},
+		{"legitimate repetition", "that was very very good", "that was very very good", false},
+		{"at the limit", "ha ha ha stop", "ha ha ha stop", false},
+		{"spacing kept when nothing collapses", "  two  spaces ", "  two  spaces ", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, collapsed := Derepeat(tt.input, DefaultMaxRepeats)
+			if got != tt.expected || collapsed != tt.collapsed {
+				t.Errorf("Derepeat(%q) = %q, %v, expected %q, %v", tt.input, got, collapsed, tt.expected, tt.collapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// DefaultQueueDepth is how many recordings a TranscriptionQueue holds before
+// Submit blocks
+const DefaultQueueDepth = 16
+
+// ErrQueueClosed is returned when submitting to a closed TranscriptionQueue
+var ErrQueueClosed = errors.New("transcription queue closed")
+
+// TranscriptionQueue transcribes submitted recordings, up to a fixed number at
+// once, and delivers the results in submission order. A short clip that
+// finishes quickly never overtakes an earlier, longer one.
+type TranscriptionQueue struct {
+	transcriber Transcriber
+	slots       chan struct{}                  // Bounds concurrent transcriptions
+	order       chan chan *TranscriptionResult // Pending results, in submission order
+	results     chan *TranscriptionResult
+	busy        func(bool)
+
+	// submitMu serializes Submit and Close, and may be held while Submit
+	// blocks, so the in-flight count has its own lock
+	submitMu sync.Mutex
+	nextSeq  uint64
+	closed   bool
+
+	mu       sync.Mutex
+	inFlight int
+}
+
+// NewTranscriptionQueue creates a queue running up to concurrency
+// transcriptions at once, at least one
+func NewTranscriptionQueue(transcriber Transcriber, concurrency int) *TranscriptionQueue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	q := &TranscriptionQueue{
+		transcriber: transcriber,
+		slots:       make(chan struct{}, concurrency),
+		order:       make(chan chan *TranscriptionResult, DefaultQueueDepth),
+		results:     make(chan *TranscriptionResult, 1),
+	}
+	go q.deliver()
+	return q
+}
+
+// OnBusy registers fn to be called with true when the queue starts
+// transcribing and false once nothing is in flight. Set it before Submit.
+func (q *TranscriptionQueue) OnBusy(fn func(bool)) *TranscriptionQueue {
+	q.busy = fn
+	return q
+}
+
+// Submit queues a recording and returns its sequence number, which tags its
+// result. It blocks while DefaultQueueDepth results are waiting for delivery.
+func (q *TranscriptionQueue) Submit(audioData *AudioData) (uint64, error) {
+	q.submitMu.Lock()
+	defer q.submitMu.Unlock()
+	if q.closed {
+		return 0, ErrQueueClosed
+	}
+
+	seq := q.nextSeq
+	q.nextSeq++
+	result := make(chan *TranscriptionResult, 1)
+	q.order <- result
+
+	go q.transcribe(seq, audioData, result)
+	return seq, nil
+}
+
+// transcribe waits for a free slot, transcribes audioData and hands the result over
+func (q *TranscriptionQueue) transcribe(seq uint64, audioData *AudioData, out chan<- *TranscriptionResult) {
+	q.slots <- struct{}{}
+	q.setInFlight(1)
+
+	r := &TranscriptionResult{Seq: seq, Audio: audioData}
+	if q.transcriber == nil {
+		r.Err = errors.New("no transcriber configured")
+	} else {
+		r.Result, r.Err = transcribeRecovered(q.transcriber, audioData)
+	}
+
+	q.setInFlight(-1)
+	<-q.slots
+	out <- r
+}
+
+// transcribeRecovered runs transcriber, turning a panic into an error wrapping
+// ErrTranscribeFailed so one bad recording doesn't take the process down
+func transcribeRecovered(transcriber Transcriber, audioData *AudioData) (result *WhisperServerResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Transcription panicked: %v\n%s", r, debug.Stack())
+			result, err = nil, fmt.Errorf("%w: %v", ErrTranscribeFailed, r)
+		}
+	}()
+	return transcriber.Transcribe(audioData)
+}
+
+// setInFlight adjusts the in-flight count, reporting busy transitions
+func (q *TranscriptionQueue) setInFlight(delta int) {
+	// busy is called with the lock held, so a true and the false after it
+	// can't be reordered on the way out
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inFlight += delta
+
+	if q.busy == nil {
+		return
+	}
+	if delta > 0 && q.inFlight == 1 {
+		q.busy(true)
+	} else if delta < 0 && q.inFlight == 0 {
+		q.busy(false)
+	}
+}
+
+// deliver forwards results in submission order until the queue is closed and drained
+func (q *TranscriptionQueue) deliver() {
+	defer close(q.results)
+	for result := range q.order {
+		q.results <- <-result
+	}
+}
+
+// Results returns the results in submission order. It is closed once the
+// queue is closed and every submitted recording has been delivered.
+func (q *TranscriptionQueue) Results() <-chan *TranscriptionResult {
+	return q.results
+}
+
+// Close stops accepting recordings. Those already submitted are still delivered.
+func (q *TranscriptionQueue) Close() {
+	q.submitMu.Lock()
+	defer q.submitMu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.order)
+	}
+}
@@ -0,0 +1,132 @@
+package speech
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sleepyTranscriber takes longer for longer recordings and tracks peak concurrency
+type sleepyTranscriber struct {
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+func (s *sleepyTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Duration(len(audioData.Samples)) * time.Millisecond)
+	return &WhisperServerResult{Text: "ok"}, nil
+}
+
+// TestTranscriptionQueueOrder verifies concurrent transcriptions are delivered in submission order
+func TestTranscriptionQueueOrder(t *testing.T) {
+	transcriber := &sleepyTranscriber{}
+	var busyChanges atomic.Int32
+	q := NewTranscriptionQueue(transcriber, 3).OnBusy(func(bool) { busyChanges.Add(1) })
+
+	// The first recording is the slowest, so later ones finish before it
+	lengths := []int{60, 10, 30, 5}
+	for i, n := range lengths {
+		seq, err := q.Submit(&AudioData{Samples: make([]int16, n), SampleRate: AudioFrequency})
+		if err != nil || seq != uint64(i) {
+			t.Fatalf("Submit %d: got seq %d, err %v", i, seq, err)
+		}
+	}
+	q.Close()
+
+	if _, err := q.Submit(&AudioData{}); err != ErrQueueClosed {
+		t.Errorf("Expected ErrQueueClosed after Close, got %v", err)
+	}
+
+	var got []int
+	for result := range q.Results() {
+		if result.Err != nil {
+			t.Fatalf("Unexpected error: %v", result.Err)
+		}
+		if result.Seq != uint64(len(got)) {
+			t.Errorf("Expected seq %d, got %d", len(got), result.Seq)
+		}
+		got = append(got, len(result.Audio.Samples))
+	}
+
+	for i := range lengths {
+		if i >= len(got) || got[i] != lengths[i] {
+			t.Fatalf("Expected results in order %v, got %v", lengths, got)
+		}
+	}
+	if peak := transcriber.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("Expected 2-3 concurrent transcriptions, peak was %d", peak)
+	}
+	if busyChanges.Load() < 2 {
+		t.Errorf("Expected busy to be set and cleared, got %d changes", busyChanges.Load())
+	}
+}
+
+// panickyTranscriber panics on empty recordings
+type panickyTranscriber struct{}
+
+func (panickyTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	_ = audioData.Samples[0]
+	return &WhisperServerResult{Text: "ok"}, nil
+}
+
+// TestTranscriptionQueuePanic verifies a panicking transcription fails with
+// ErrTranscribeFailed and later recordings are still transcribed
+func TestTranscriptionQueuePanic(t *testing.T) {
+	q := NewTranscriptionQueue(panickyTranscriber{}, 1)
+	q.Submit(&AudioData{})
+	q.Submit(&AudioData{Samples: make([]int16, 10), SampleRate: AudioFrequency})
+	q.Close()
+
+	first, second := <-q.Results(), <-q.Results()
+	if !errors.Is(first.Err, ErrTranscribeFailed) {
+		t.Errorf("Expected ErrTranscribeFailed, got %v", first.Err)
+	}
+	if second.Err != nil || second.Result.Text != "ok" {
+		t.Errorf("Expected the next recording to be transcribed, got %+v", second)
+	}
+}
+
+// TestTranscriptionQueueBusyOrder verifies busy changes alternate and end
+// with false, however transcriptions overlap
+func TestTranscriptionQueueBusyOrder(t *testing.T) {
+	var mu sync.Mutex
+	var changes []bool
+	q := NewTranscriptionQueue(&sleepyTranscriber{}, 4).OnBusy(func(busy bool) {
+		mu.Lock()
+		changes = append(changes, busy)
+		mu.Unlock()
+	})
+	drained := make(chan struct{})
+	go func() {
+		for range q.Results() {
+		}
+		close(drained)
+	}()
+	for i := 0; i < 200; i++ {
+		q.Submit(&AudioData{Samples: make([]int16, i%3), SampleRate: AudioFrequency})
+	}
+	q.Close()
+	<-drained
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, busy := range changes {
+		if busy != (i%2 == 0) {
+			t.Fatalf("Expected busy changes to alternate, got %v", changes)
+		}
+	}
+	if len(changes) == 0 || changes[len(changes)-1] {
+		t.Errorf("Expected busy to end cleared, got %v", changes)
+	}
+}
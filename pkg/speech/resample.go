@@ -0,0 +1,94 @@
+package speech
+
+import "math"
+
+// WhisperSampleRate is the sample rate whisper.cpp expects its input at
+const WhisperSampleRate = 16000
+
+// Anti-aliasing filter for downsampling. The cutoff sits a little below the
+// output Nyquist frequency so the filter's transition band doesn't fold back
+// into speech, and each side of the kernel spans this many zero crossings.
+const (
+	resampleCutoff        = 0.85
+	resampleZeroCrossings = 16
+)
+
+// resample converts samples from one sample rate to another using linear
+// interpolation. Downsampling low-pass filters first, so content above the
+// new Nyquist frequency doesn't alias into the output.
+func resample(samples []int16, fromRate, toRate int) []int16 {
+	if fromRate == toRate || len(samples) == 0 {
+		return samples
+	}
+
+	var kernel []float64
+	if toRate < fromRate {
+		kernel = lowPassKernel(resampleCutoff * float64(toRate) / float64(fromRate) / 2)
+	}
+	at := func(idx int) float64 {
+		if kernel == nil {
+			return float64(samples[idx])
+		}
+		return filteredSample(samples, kernel, idx)
+	}
+
+	outLen := int(int64(len(samples)) * int64(toRate) / int64(fromRate))
+	out := make([]int16, outLen)
+	step := float64(fromRate) / float64(toRate)
+	for i := range out {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx >= len(samples)-1 {
+			out[i] = clampSample(at(len(samples) - 1))
+			continue
+		}
+		frac := pos - float64(idx)
+		if frac == 0 {
+			out[i] = clampSample(at(idx))
+			continue
+		}
+		out[i] = clampSample(at(idx)*(1-frac) + at(idx+1)*frac)
+	}
+	return out
+}
+
+// lowPassKernel builds a Blackman-windowed sinc filter with the given cutoff
+// as a fraction of the sample rate, normalized to unity gain at DC
+func lowPassKernel(cutoff float64) []float64 {
+	half := int(math.Ceil(resampleZeroCrossings / (2 * cutoff)))
+	kernel := make([]float64, 2*half+1)
+	var sum float64
+	for i := range kernel {
+		n := float64(i - half)
+		sinc := 1.0
+		if n != 0 {
+			sinc = math.Sin(2*math.Pi*cutoff*n) / (2 * math.Pi * cutoff * n)
+		}
+		phase := 2 * math.Pi * float64(i) / float64(len(kernel)-1)
+		window := 0.42 - 0.5*math.Cos(phase) + 0.08*math.Cos(2*phase)
+		kernel[i] = sinc * window
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// filteredSample applies kernel centered on samples[idx], treating samples
+// past either end as silence
+func filteredSample(samples []int16, kernel []float64, idx int) float64 {
+	half := len(kernel) / 2
+	var acc float64
+	for k, weight := range kernel {
+		if j := idx + k - half; j >= 0 && j < len(samples) {
+			acc += float64(samples[j]) * weight
+		}
+	}
+	return acc
+}
+
+// clampSample rounds v to the nearest 16-bit sample
+func clampSample(v float64) int16 {
+	return int16(max(math.MinInt16, min(math.MaxInt16, math.Round(v))))
+}
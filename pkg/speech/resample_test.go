@@ -0,0 +1,79 @@
+package speech
+
+import (
+	"math"
+	"testing"
+)
+
+// TestResampleLength verifies output length follows the rate ratio
+func TestResampleLength(t *testing.T) {
+	tests := []struct {
+		from, to int
+		in, out  int
+	}{
+		{44100, 16000, 44100, 16000},
+		{48000, 16000, 4800, 1600},
+		{8000, 16000, 800, 1600},
+		{16000, 16000, 1234, 1234},
+	}
+
+	for _, tt := range tests {
+		got := resample(make([]int16, tt.in), tt.from, tt.to)
+		if len(got) != tt.out {
+			t.Errorf("resample %d samples %d->%d Hz: expected %d samples, got %d",
+				tt.in, tt.from, tt.to, tt.out, len(got))
+		}
+	}
+}
+
+// TestResampleUpsampleInterpolates verifies upsampling interpolates between samples
+func TestResampleUpsampleInterpolates(t *testing.T) {
+	got := resample([]int16{0, 100, 200}, 8000, 16000)
+	expected := []int16{0, 50, 100, 150, 200, 200}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Sample %d: expected %d, got %d", i, expected[i], got[i])
+		}
+	}
+}
+
+// sine returns seconds of a full-scale-ish sine wave at freq, sampled at rate
+func sine(freq float64, rate int, seconds float64) []int16 {
+	samples := make([]int16, int(seconds*float64(rate)))
+	for i := range samples {
+		samples[i] = int16(10000 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// rms is the root mean square of samples, skipping edge samples the filter
+// sees only partly
+func rms(samples []int16, skip int) float64 {
+	samples = samples[skip : len(samples)-skip]
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// TestResampleDownsampleSine verifies a 48kHz tone in the speech band keeps its
+// level after downsampling, and one above 8kHz is filtered out instead of
+// aliasing into the output
+func TestResampleDownsampleSine(t *testing.T) {
+	in := sine(1000, 48000, 1)
+	out := resample(in, 48000, WhisperSampleRate)
+	if len(out) != WhisperSampleRate {
+		t.Fatalf("Expected %d samples, got %d", WhisperSampleRate, len(out))
+	}
+	if ratio := rms(out, 100) / rms(in, 300); ratio < 0.95 || ratio > 1.05 {
+		t.Errorf("Expected a 1kHz tone to pass at unity gain, got a level ratio of %.3f", ratio)
+	}
+
+	// Plain decimation would fold 12kHz to a full-level 4kHz tone
+	in = sine(12000, 48000, 1)
+	out = resample(in, 48000, WhisperSampleRate)
+	if ratio := rms(out, 100) / rms(in, 300); ratio > 0.01 {
+		t.Errorf("Expected a 12kHz tone to be filtered out, got a level ratio of %.3f", ratio)
+	}
+}
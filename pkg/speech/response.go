@@ -0,0 +1,179 @@
+package speech
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Response formats understood by the whisper.cpp server
+const (
+	ResponseFormatJSON        = "json"
+	ResponseFormatVerboseJSON = "verbose_json"
+	ResponseFormatText        = "text"
+	ResponseFormatSRT         = "srt"
+	ResponseFormatVTT         = "vtt"
+)
+
+// validResponseFormat reports whether format is one parseResponse understands
+func validResponseFormat(format string) bool {
+	switch format {
+	case ResponseFormatJSON, ResponseFormatVerboseJSON, ResponseFormatText, ResponseFormatSRT, ResponseFormatVTT:
+		return true
+	}
+	return false
+}
+
+// parseResponse converts a server response body in the requested format to a result
+func parseResponse(format string, body []byte) (*WhisperServerResult, error) {
+	switch format {
+	case ResponseFormatJSON, ResponseFormatVerboseJSON:
+		var result WhisperServerResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		// whisper.cpp reports a segment's mean token log probability rather
+		// than a confidence
+		for i, segment := range result.Segments {
+			if segment.Confidence == 0 && segment.AvgLogprob != 0 {
+				result.Segments[i].Confidence = math.Exp(segment.AvgLogprob)
+			}
+		}
+		return &result, nil
+
+	case ResponseFormatText:
+		return &WhisperServerResult{Text: strings.TrimSpace(string(body))}, nil
+
+	case ResponseFormatSRT, ResponseFormatVTT:
+		segments, err := parseSubtitles(string(body))
+		if err != nil {
+			return nil, err
+		}
+		texts := make([]string, len(segments))
+		for i, segment := range segments {
+			texts[i] = segment.Text
+		}
+		return &WhisperServerResult{Text: strings.Join(texts, " "), Segments: segments}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported response format %q", format)
+	}
+}
+
+// recoverPartialText extracts the first "text" string from a malformed JSON
+// body, up to its closing quote or the end of a truncated body. It reports
+// false if the body has no text field.
+func recoverPartialText(body []byte) (string, bool) {
+	s := string(body)
+	key := strings.Index(s, `"text"`)
+	if key < 0 {
+		return "", false
+	}
+
+	// Expect optional whitespace, a colon and the opening quote
+	rest := strings.TrimLeft(s[key+len(`"text"`):], " \t\r\n")
+	if !strings.HasPrefix(rest, ":") {
+		return "", false
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if !strings.HasPrefix(rest, `"`) {
+		return "", false
+	}
+	rest = rest[1:]
+
+	// Scan to the closing quote, skipping escaped characters
+	end := len(rest)
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '\\' {
+			i++
+			continue
+		}
+		if rest[i] == '"' {
+			end = i
+			break
+		}
+	}
+	raw := rest[:end]
+
+	// Decode the escapes, dropping an escape sequence cut off by truncation
+	var text string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &text); err == nil {
+		return text, true
+	}
+	if i := strings.LastIndex(raw, `\`); i >= 0 {
+		if err := json.Unmarshal([]byte(`"`+raw[:i]+`"`), &text); err == nil {
+			return text, true
+		}
+	}
+	return raw, true
+}
+
+// parseSubtitles parses SRT or WebVTT cues into segments. Both formats are
+// blocks separated by blank lines with a "start --> end" timing line followed
+// by the cue text; SRT uses a comma before milliseconds and VTT a period.
+func parseSubtitles(body string) ([]WhisperSegment, error) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	var segments []WhisperSegment
+	for _, block := range strings.Split(body, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+
+		// Find the timing line, skipping the SRT index or VTT cue identifier
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		// Blocks without timing are headers (WEBVTT) or notes
+		if timing < 0 {
+			continue
+		}
+
+		parts := strings.SplitN(lines[timing], "-->", 2)
+		start, err := parseTimestamp(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		// VTT allows cue settings after the end time
+		endFields := strings.Fields(parts[1])
+		if len(endFields) == 0 {
+			return nil, fmt.Errorf("missing end time in %q", lines[timing])
+		}
+		end, err := parseTimestamp(endFields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, WhisperSegment{
+			ID:    len(segments),
+			Start: start,
+			End:   end,
+			Text:  strings.TrimSpace(strings.Join(lines[timing+1:], " ")),
+		})
+	}
+
+	return segments, nil
+}
+
+// parseTimestamp converts "HH:MM:SS,mmm", "HH:MM:SS.mmm" or "MM:SS.mmm" to seconds
+func parseTimestamp(ts string) (float64, error) {
+	ts = strings.ReplaceAll(strings.TrimSpace(ts), ",", ".")
+	fields := strings.Split(ts, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+
+	var seconds float64
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", ts)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
@@ -0,0 +1,137 @@
+package speech
+
+import (
+	"math"
+	"testing"
+)
+
+// TestParseResponseJSON verifies the default JSON response shape
+func TestParseResponseJSON(t *testing.T) {
+	result, err := parseResponse(ResponseFormatJSON, []byte(`{"text": " hello world"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if result.Text != " hello world" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+
+	if _, err := parseResponse(ResponseFormatJSON, []byte("hello world")); err == nil {
+		t.Error("Expected an error for a non-JSON body")
+	}
+}
+
+// TestParseResponseConfidence verifies verbose_json log probabilities become segment confidence
+func TestParseResponseConfidence(t *testing.T) {
+	body := `{"text": "hi", "segments": [{"text": "hi", "avg_logprob": -0.5}, {"text": "there"}]}`
+	result, err := parseResponse(ResponseFormatVerboseJSON, []byte(body))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	want := math.Exp(-0.5)
+	if got := result.Segments[0].Confidence; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected confidence %v, got %v", want, got)
+	}
+	if got, ok := result.Confidence(); !ok || math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected mean confidence %v ignoring unscored segments, got %v (%v)", want, got, ok)
+	}
+
+	if _, ok := (&WhisperServerResult{Text: "hi"}).Confidence(); ok {
+		t.Error("Expected no confidence without segments")
+	}
+}
+
+// TestRecoverPartialText verifies text is salvaged from truncated and malformed bodies
+func TestRecoverPartialText(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"complete text, truncated segments", `{"text": "hello world", "segments": [{"te`, "hello world", true},
+		{"truncated text", `{"text": "hello wor`, "hello wor", true},
+		{"escapes", `{"text": "say \"hi\"\nnow`, "say \"hi\"\nnow", true},
+		{"cut escape", `{"text": "caf\u00`, "caf", true},
+		{"no text", `{"segments": [`, "", false},
+		{"text not a string", `{"text": 42`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := recoverPartialText([]byte(tt.body))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+// TestParseResponseText verifies plain text responses become the result text
+func TestParseResponseText(t *testing.T) {
+	result, err := parseResponse(ResponseFormatText, []byte(" hello world\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse text: %v", err)
+	}
+	if result.Text != "hello world" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+}
+
+// TestParseResponseSubtitles verifies SRT and VTT responses are parsed into segments
+func TestParseResponseSubtitles(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		body   string
+	}{
+		{"srt", ResponseFormatSRT, "1\n00:00:00,000 --> 00:00:02,500\n And so my fellow Americans\n\n" +
+			"2\n00:00:02,500 --> 00:01:04,250\n ask not\nwhat your country can do\n"},
+		{"vtt", ResponseFormatVTT, "WEBVTT\n\n00:00:00.000 --> 00:00:02.500\n And so my fellow Americans\n\n" +
+			"00:00:02.500 --> 00:01:04.250 align:start\n ask not\nwhat your country can do\n"},
+		{"srt crlf", ResponseFormatSRT, "1\r\n00:00:00,000 --> 00:00:02,500\r\nAnd so my fellow Americans\r\n\r\n" +
+			"2\r\n00:00:02,500 --> 00:01:04,250\r\nask not\r\nwhat your country can do\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseResponse(tt.format, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", tt.format, err)
+			}
+
+			if len(result.Segments) != 2 {
+				t.Fatalf("Expected 2 segments, got %d", len(result.Segments))
+			}
+			second := result.Segments[1]
+			if second.ID != 1 || second.Start != 2.5 || second.End != 64.25 {
+				t.Errorf("Unexpected second segment timing: %+v", second)
+			}
+			if second.Text != "ask not what your country can do" {
+				t.Errorf("Unexpected second segment text: %q", second.Text)
+			}
+			if result.Text != "And so my fellow Americans ask not what your country can do" {
+				t.Errorf("Unexpected text: %q", result.Text)
+			}
+		})
+	}
+}
+
+// TestParseTimestamp verifies subtitle timestamps convert to seconds
+func TestParseTimestamp(t *testing.T) {
+	tests := map[string]float64{
+		"00:00:01,500": 1.5,
+		"01:02:03.250": 3723.25,
+		"02:03.000":    123,
+	}
+	for input, expected := range tests {
+		got, err := parseTimestamp(input)
+		if err != nil || got != expected {
+			t.Errorf("parseTimestamp(%q) = %v, %v; expected %v", input, got, err, expected)
+		}
+	}
+
+	if _, err := parseTimestamp("soon"); err == nil {
+		t.Error("Expected an error for an invalid timestamp")
+	}
+}
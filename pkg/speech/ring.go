@@ -0,0 +1,54 @@
+package speech
+
+import "sync"
+
+// ringBuffer keeps the most recent samples written to it, up to a fixed
+// capacity. It is safe for a capture writer and readers on other goroutines.
+type ringBuffer struct {
+	mu      sync.Mutex
+	samples []int16
+	pos     int  // Next write position
+	full    bool // Whether the buffer has wrapped
+}
+
+// newRingBuffer creates a ring buffer holding up to capacity samples
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{samples: make([]int16, capacity)}
+}
+
+// Write appends samples, overwriting the oldest once the buffer is full
+func (r *ringBuffer) Write(samples []int16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Only the tail of an oversized write fits
+	if len(samples) >= len(r.samples) {
+		copy(r.samples, samples[len(samples)-len(r.samples):])
+		r.pos = 0
+		r.full = true
+		return
+	}
+
+	n := copy(r.samples[r.pos:], samples)
+	if n < len(samples) {
+		copy(r.samples, samples[n:])
+		r.full = true
+	}
+	r.pos = (r.pos + len(samples)) % len(r.samples)
+	if r.pos == 0 && len(samples) > 0 {
+		r.full = true
+	}
+}
+
+// Snapshot returns a copy of the buffered samples, oldest first
+func (r *ringBuffer) Snapshot() []int16 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]int16(nil), r.samples[:r.pos]...)
+	}
+	out := make([]int16, 0, len(r.samples))
+	out = append(out, r.samples[r.pos:]...)
+	return append(out, r.samples[:r.pos]...)
+}
@@ -0,0 +1,56 @@
+package speech
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestRingBuffer verifies the buffer keeps the most recent samples in order
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes [][]int16
+		want   []int16
+	}{
+		{"partial", [][]int16{{1, 2}}, []int16{1, 2}},
+		{"exactly full", [][]int16{{1, 2}, {3, 4}}, []int16{1, 2, 3, 4}},
+		{"wrapped", [][]int16{{1, 2, 3}, {4, 5, 6}}, []int16{3, 4, 5, 6}},
+		{"oversized write", [][]int16{{1}, {2, 3, 4, 5, 6, 7}}, []int16{4, 5, 6, 7}},
+		{"empty", nil, []int16{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRingBuffer(4)
+			for _, w := range tt.writes {
+				r.Write(w)
+			}
+			if got := r.Snapshot(); !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestTranscribeRollingBuffer verifies the buffered audio is handed to the transcriber
+func TestTranscribeRollingBuffer(t *testing.T) {
+	svc := NewSpeechService()
+	if _, err := svc.TranscribeRollingBuffer(); err == nil {
+		t.Error("Expected an error without a rolling buffer")
+	}
+
+	svc.WithRollingBuffer(time.Second).WithTranscriber(&fakeTranscriber{text: "replayed"})
+	if _, err := svc.TranscribeRollingBuffer(); err == nil {
+		t.Error("Expected an error while the buffer is empty")
+	}
+
+	svc.rolling.Write(synthesizeClip(0, AudioFrequency/2, 0, 1000))
+	result, err := svc.TranscribeRollingBuffer()
+	if err != nil {
+		t.Fatalf("TranscribeRollingBuffer failed: %v", err)
+	}
+	if result.Text != "replayed" {
+		t.Errorf("Unexpected result text: %q", result.Text)
+	}
+}
@@ -0,0 +1,181 @@
+package speech
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// serverLock records a whisper server left running by a persistent session
+type serverLock struct {
+	URL        string `json:"url"`
+	PID        int    `json:"pid"`
+	ModelPath  string `json:"model_path"`
+	ServerPath string `json:"server_path"`
+}
+
+// defaultServerLockPath returns where persistent server details are recorded,
+// in a per-user directory so other users can't plant a lockfile
+func defaultServerLockPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			cache = os.TempDir()
+		}
+		dir = filepath.Join(cache, "conch")
+	}
+	return filepath.Join(dir, "conch-whisper-server.lock")
+}
+
+// readServerLock loads the lockfile at path
+func readServerLock(path string) (*serverLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock serverLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %v", path, err)
+	}
+	return &lock, nil
+}
+
+// writeServerLock records the running server so the next launch can attach to it
+func writeServerLock(path string, lock *serverLock) error {
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Signal 0 performs the existence check without delivering anything
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// serverHealthy reports whether the server at url answers HTTP requests
+func serverHealthy(url string) bool {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// processCommand returns the command line of the process with the given PID
+func processCommand(pid int) (string, error) {
+	if data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline")); err == nil {
+		return strings.ReplaceAll(string(data), "\x00", " "), nil
+	}
+	// No procfs, e.g. on macOS
+	out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ownsLockedServer reports whether the lock's PID still runs the whisper
+// server it recorded, rather than an unrelated process that reused the PID
+func ownsLockedServer(lock *serverLock) bool {
+	command, err := processCommand(lock.PID)
+	if err != nil {
+		return false
+	}
+	server := "whisper-server"
+	if lock.ServerPath != "" {
+		server = filepath.Base(lock.ServerPath)
+	}
+	return strings.Contains(command, server) && strings.Contains(command, lock.ModelPath)
+}
+
+// stopLockedServer terminates a server recorded in a lockfile that can't be
+// reused, unless its PID no longer runs that server
+func stopLockedServer(lock *serverLock) {
+	if !ownsLockedServer(lock) {
+		log.Printf("PID %d no longer runs the recorded whisper server, leaving it alone", lock.PID)
+		return
+	}
+	proc, err := os.FindProcess(lock.PID)
+	if err != nil {
+		return
+	}
+	if err := proc.Signal(os.Interrupt); err != nil {
+		return
+	}
+
+	// Give it a moment to release the port before a fresh server binds it
+	for i := 0; i < 20 && processAlive(lock.PID); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if processAlive(lock.PID) {
+		proc.Kill()
+	}
+}
+
+// attachToPersistentServer reuses a healthy server left running by a previous
+// persistent session. Stale or unusable lockfiles are removed. Must be called
+// with s.mutex held.
+func (s *WhisperServerService) attachToPersistentServer() bool {
+	lock, err := readServerLock(s.lockPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Ignoring unreadable whisper server lockfile: %v", err)
+			os.Remove(s.lockPath)
+		}
+		return false
+	}
+
+	if !processAlive(lock.PID) {
+		log.Printf("Removing stale whisper server lockfile (PID %d is gone)", lock.PID)
+		os.Remove(s.lockPath)
+		return false
+	}
+
+	// Another session may still be using it, so it's left running
+	wantURL := serverURLFor(s.config.Host, s.config.Port)
+	if lock.ModelPath != s.config.ModelPath || lock.URL != wantURL {
+		log.Printf("Persistent whisper server (PID %d) at %s uses a different model or address, leaving it running", lock.PID, lock.URL)
+		return false
+	}
+
+	if !serverHealthy(lock.URL) {
+		log.Printf("Persistent whisper server (PID %d) is not responding, restarting it", lock.PID)
+		stopLockedServer(lock)
+		os.Remove(s.lockPath)
+		return false
+	}
+
+	s.serverURL = lock.URL
+	s.pid = lock.PID
+	s.attached = true
+	s.isRunning = true
+	s.startTime = time.Now()
+	log.Printf("Attached to persistent whisper server at %s (PID: %d)", lock.URL, lock.PID)
+	return true
+}
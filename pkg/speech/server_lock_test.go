@@ -0,0 +1,91 @@
+package speech
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestAttachStaleServerLock verifies a lockfile for a dead process is removed
+// and the service falls back to launching a fresh server
+func TestAttachStaleServerLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "server.lock")
+
+	svc := NewWhisperServerService().WithPersistentServer(true)
+	svc.lockPath = lockPath
+
+	// PIDs are positive, so -1 never refers to a running process
+	lock := &serverLock{URL: "http://127.0.0.1:8080", PID: -1, ModelPath: svc.config.ModelPath}
+	if err := writeServerLock(lockPath, lock); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+
+	if svc.attachToPersistentServer() {
+		t.Fatal("Expected not to attach to a server whose process is gone")
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("Expected stale lockfile to be removed, stat returned: %v", err)
+	}
+	if svc.attached || svc.IsRunning() {
+		t.Errorf("Expected service to remain detached and not running")
+	}
+}
+
+// TestServerLockRoundTrip verifies lockfile contents survive a write and read
+func TestServerLockRoundTrip(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "server.lock")
+	lock := &serverLock{URL: "http://127.0.0.1:9090", PID: 4242, ModelPath: "/models/ggml-base.bin"}
+
+	if err := writeServerLock(lockPath, lock); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	got, err := readServerLock(lockPath)
+	if err != nil {
+		t.Fatalf("Failed to read lockfile: %v", err)
+	}
+	if *got != *lock {
+		t.Errorf("Expected %+v, got %+v", *lock, *got)
+	}
+}
+
+// TestLockedServerLeftAlone verifies a lockfile whose PID runs something else,
+// or a server for another model, never gets that process signalled
+func TestLockedServerLeftAlone(t *testing.T) {
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Skipf("sleep unavailable: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		other.Wait()
+		close(exited)
+	}()
+	defer other.Process.Kill()
+
+	svc := NewWhisperServerService().WithPersistentServer(true)
+	svc.lockPath = filepath.Join(t.TempDir(), "server.lock")
+	svc.config.Port = 1 // Nothing answers, so the server looks hung
+
+	locks := []*serverLock{
+		{URL: serverURLFor(svc.config.Host, 1), PID: other.Process.Pid, ModelPath: svc.config.ModelPath},
+		{URL: serverURLFor(svc.config.Host, 1), PID: other.Process.Pid, ModelPath: "other-model.bin"},
+	}
+	for _, lock := range locks {
+		if err := writeServerLock(svc.lockPath, lock); err != nil {
+			t.Fatalf("Failed to write lockfile: %v", err)
+		}
+		if svc.attachToPersistentServer() {
+			t.Fatal("Expected not to attach")
+		}
+		select {
+		case <-exited:
+			t.Fatalf("Expected PID %d, which isn't a whisper server, to be left running", other.Process.Pid)
+		default:
+		}
+	}
+
+	if !ownsLockedServer(&serverLock{PID: other.Process.Pid, ServerPath: "/bin/sleep", ModelPath: "30"}) {
+		t.Error("Expected the command line to match the recorded server and model")
+	}
+}
@@ -1,10 +1,12 @@
 package speech
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
@@ -29,14 +31,56 @@ const (
 	AudioFrequency  = 16000
 	AudioFormat     = sdl.AUDIO_S16LSB
 	AudioChannels   = 1
-	AudioSamples    = 4096
+	AudioSamples    = 4096        // Default frame size, 256ms at 16kHz
 	AudioBufferSize = 1024 * 1024 // 1MB buffer
 
+	// Frame sizes WithFrameSize accepts, powers of two from 16ms to ~2s
+	MinFrameSize = 256
+	MaxFrameSize = 32768
+
 	// Voice activity detection
 	VadThreshold     = 100 // Threshold for detecting voice activity (much lower)
 	VadSilenceFrames = 10  // Number of frames of silence to end recording (shorter pause)
+
+	// A default capture frame lasts 256ms, so the default silence window
+	// keeps the behaviour of VadSilenceFrames, about 2.5s
+	VadFrameDuration       = AudioSamples * time.Second / AudioFrequency
+	DefaultSilenceDuration = VadSilenceFrames * VadFrameDuration
+
+	// Capture is considered stalled when no audio arrives for this long while listening
+	CaptureStallTimeout = 5 * time.Second
+
+	// Recordings with more clipped samples than this fraction are reported as too loud
+	ClipWarningFraction = 0.001
+
+	// Silence trimming
+	TrimWindowSamples = AudioFrequency / 100 // 10ms analysis window
+	TrimMarginSamples = AudioFrequency / 10  // Keep 100ms of audio around detected speech
 )
 
+// Errors returned by the listening and recording methods
+var (
+	ErrShuttingDown     = errors.New("service is shutting down")
+	ErrNotListening     = errors.New("not currently listening")
+	ErrListeningStopped = errors.New("listening stopped")
+	ErrNotRecording     = errors.New("not currently recording")
+	ErrInvalidFrameSize = errors.New("invalid frame size")
+	ErrCaptureFailed    = errors.New("audio capture failed")
+)
+
+// Transcriber converts recorded audio to text
+type Transcriber interface {
+	Transcribe(audioData *AudioData) (*WhisperServerResult, error)
+}
+
+// TranscriptionResult is delivered for each recording transcribed by the service
+type TranscriptionResult struct {
+	Seq    uint64               // Position of the recording in capture order
+	Audio  *AudioData           // The recording that was transcribed
+	Result *WhisperServerResult // The transcription, nil if Err is set
+	Err    error                // Error from the transcriber, if any
+}
+
 // AudioCallback is called by SDL when more audio data is needed
 type AudioCallback struct {
 	buffer       []int16
@@ -50,27 +94,103 @@ type AudioCallback struct {
 type AudioData struct {
 	Samples    []int16
 	SampleRate int
+
+	// Samples captured at full scale, counted while recording
+	Clipped int
+}
+
+// ClippedFraction is the fraction of samples that clipped
+func (a *AudioData) ClippedFraction() float64 {
+	if len(a.Samples) == 0 {
+		return 0
+	}
+	return float64(a.Clipped) / float64(len(a.Samples))
 }
 
 // SpeechService handles voice activity detection and transcription
 type SpeechService struct {
 	deviceID       sdl.AudioDeviceID
+	backend        audioBackend   // Capture device, the SDL device once initialized
+	synthetic      func() []int16 // Generates the input instead of a microphone when set
 	callback       *AudioCallback
 	isInitialized  bool
 	isListening    bool
+	captureErr     error // Why capture stopped on its own, if it panicked
 	isRecording    bool
 	isTranscribing bool
+	transcribing   int // Transcriptions in progress, see SetTranscribing
 	isShutdown     bool
 	audioData      *AudioData
+	lastActivity   time.Time // Last time the capture loop received audio
 
 	// Events channels
 	recordingStarted chan struct{}
 	recordingStopped chan *AudioData
 
+	// Receives the duration of clips too short to transcribe
+	recordingDiscarded chan time.Duration
+
+	// Inactivity pause: capture stops after inactivityTimeout without a
+	// recording starting, until Resume is called or inactivityResume elapses
+	inactivityTimeout time.Duration
+	inactivityResume  time.Duration
+	isInactive        bool
+	inactiveEvents    chan struct{}
+	resume            chan struct{}
+
+	// Push-to-talk key state from BeginRecording and EndRecording
+	pushToTalk chan bool
+
+	// Set by CancelRecording until the capture loop drops the recording
+	recordingCancelled bool
+
+	// Voice activity detection policy
+	vad VAD
+
+	// Transcription pipeline
+	transcriber           Transcriber
+	transcriptions        chan *TranscriptionResult
+	transcribeConcurrency int
+
 	// Control
 	stopListening chan struct{}
 	mutex         sync.Mutex
 
+	// Echo avoidance: capture is ignored while the caller reports audio output,
+	// and for a short release time after to let the room's reverb die down
+	outputActive    bool
+	outputReleaseAt time.Time
+
+	// Last few seconds of audio regardless of VAD, for transcribing on demand
+	rolling *ringBuffer
+
+	// Average level of the most recent frame, for level meters
+	level int64
+
+	// Voice threshold of the default energy VAD, adjustable while capturing,
+	// and what tuning displays show alongside it
+	vadThreshold int64
+	noiseFloor   float64
+	vadEvents    []VadEvent
+
+	// The most recently delivered recording, kept for playback
+	lastRecording *AudioData
+
+	// Samples per capture frame, the unit VAD decisions are made on
+	frameSize int
+
+	// Processing options
+	trimSilence     bool
+	gain            float64
+	agc             *AGC
+	silenceDuration time.Duration
+	mergeGap        time.Duration
+
+	// Smart endpointing: silence frames with energy within endpointBand of
+	// the VAD threshold extend the silence window by endpointExtension
+	endpointBand      float64
+	endpointExtension time.Duration
+
 	// Debug settings
 	debugMode DebugMode
 }
@@ -110,17 +230,38 @@ func NewSpeechService() *SpeechService {
 	}
 
 	return &SpeechService{
-		recordingStarted: make(chan struct{}, 1),
-		recordingStopped: make(chan *AudioData, 1),
-		stopListening:    make(chan struct{}, 1),
+		recordingStarted:   make(chan struct{}, 1),
+		recordingStopped:   make(chan *AudioData, 1),
+		recordingDiscarded: make(chan time.Duration, 1),
+		inactiveEvents:     make(chan struct{}, 1),
+		resume:             make(chan struct{}, 1),
+		pushToTalk:         make(chan bool, 4),
+		stopListening:      make(chan struct{}, 1),
 		audioData: &AudioData{
 			Samples:    make([]int16, 0, AudioBufferSize),
 			SampleRate: AudioFrequency,
 		},
-		debugMode: debugMode,
+		backend:         sdlBackend{},
+		vad:             NewEnergyVAD(VadThreshold),
+		vadThreshold:    VadThreshold,
+		gain:            1.0,
+		frameSize:       AudioSamples,
+		silenceDuration: DefaultSilenceDuration,
+		debugMode:       debugMode,
 	}
 }
 
+// checkObtainedSpec verifies the device delivers the format the capture loop
+// decodes. SDL should convert for us, but a mismatch would silently corrupt
+// every recording, so fail clearly instead.
+func checkObtainedSpec(desired, obtained *sdl.AudioSpec) error {
+	if obtained.Freq != desired.Freq || obtained.Format != desired.Format || obtained.Channels != desired.Channels {
+		return fmt.Errorf("audio device opened with unsupported format: %d Hz, format 0x%x, %d channels (need %d Hz, format 0x%x, %d channel)",
+			obtained.Freq, uint16(obtained.Format), obtained.Channels, desired.Freq, uint16(desired.Format), desired.Channels)
+	}
+	return nil
+}
+
 // WithDebug sets the debug mode for the speech service
 func (s *SpeechService) WithDebug(mode DebugMode) *SpeechService {
 	s.debugMode = mode
@@ -130,6 +271,122 @@ func (s *SpeechService) WithDebug(mode DebugMode) *SpeechService {
 	return s
 }
 
+// WithTrimSilence enables trimming of leading and trailing silence from recordings
+func (s *SpeechService) WithTrimSilence(enabled bool) *SpeechService {
+	s.trimSilence = enabled
+	return s
+}
+
+// WithGain sets a digital gain applied to captured samples, for quiet microphones
+func (s *SpeechService) WithGain(factor float64) *SpeechService {
+	s.gain = factor
+	return s
+}
+
+// WithAGC enables automatic gain control, scaling each recording toward
+// targetRMS before it's handed off for transcription. Zero disables it.
+func (s *SpeechService) WithAGC(targetRMS int) *SpeechService {
+	s.agc = nil
+	if targetRMS > 0 {
+		s.agc = NewAGC(targetRMS)
+	}
+	return s
+}
+
+// WithRollingBuffer keeps the last d of captured audio, whether or not it was
+// recognized as speech, for TranscribeRollingBuffer. Zero disables it.
+func (s *SpeechService) WithRollingBuffer(d time.Duration) *SpeechService {
+	s.rolling = nil
+	if d > 0 {
+		s.rolling = newRingBuffer(int(d.Seconds() * AudioFrequency))
+	}
+	return s
+}
+
+// WithInactivityTimeout pauses capture once no recording has started for d, to
+// save CPU and battery. Zero disables it.
+func (s *SpeechService) WithInactivityTimeout(d time.Duration) *SpeechService {
+	s.inactivityTimeout = d
+	return s
+}
+
+// WithInactivityResume resumes an inactivity pause automatically after d.
+// Zero waits for Resume.
+func (s *SpeechService) WithInactivityResume(d time.Duration) *SpeechService {
+	s.inactivityResume = d
+	return s
+}
+
+// WithSilenceDuration sets how long the input must stay silent to end a recording
+func (s *SpeechService) WithSilenceDuration(d time.Duration) *SpeechService {
+	s.silenceDuration = d
+	return s
+}
+
+// WithUtteranceMergeGap joins recordings separated by pauses shorter than d
+// into one clip, so a sentence with natural pauses is transcribed as a whole.
+// Each recording is held for d before delivery to see if speech resumes. Zero
+// delivers every recording as soon as it ends.
+func (s *SpeechService) WithUtteranceMergeGap(d time.Duration) *SpeechService {
+	s.mergeGap = d
+	return s
+}
+
+// WithSmartEndpointing keeps recording through speech that trails off. When
+// the level after speech stays within band (a fraction of the VAD threshold,
+// e.g. 0.5) of the threshold, the silence window is extended by extension, so
+// only sustained true silence ends the recording. Zero extension disables it.
+func (s *SpeechService) WithSmartEndpointing(band float64, extension time.Duration) *SpeechService {
+	s.endpointBand = band
+	s.endpointExtension = extension
+	return s
+}
+
+// WithFrameSize sets how many samples are captured and checked for voice at
+// a time, a power of two between MinFrameSize and MaxFrameSize. Smaller
+// frames notice the start and end of speech sooner but wake the capture loop
+// more often. The default, AudioSamples, is 256ms per frame at 16kHz; 1024
+// (64ms) suits low latency. The silence duration is measured in samples, so
+// it holds for any frame size, rounded up to a whole frame.
+func (s *SpeechService) WithFrameSize(n int) *SpeechService {
+	s.frameSize = n
+	return s
+}
+
+// checkFrameSize rejects frame sizes SDL won't take as a buffer size
+func checkFrameSize(n int) error {
+	if n < MinFrameSize || n > MaxFrameSize || n&(n-1) != 0 {
+		return fmt.Errorf("%w: %d, expected a power of two from %d to %d", ErrInvalidFrameSize, n, MinFrameSize, MaxFrameSize)
+	}
+	return nil
+}
+
+// silenceSampleLimit converts the silence duration to a sample count, so the
+// end-of-speech timing doesn't depend on how much audio each read returns
+func (s *SpeechService) silenceSampleLimit() int {
+	return int(s.silenceDuration.Seconds() * AudioFrequency)
+}
+
+// WithVAD replaces the default energy-threshold voice activity detector
+func (s *SpeechService) WithVAD(v VAD) *SpeechService {
+	s.vad = v
+	return s
+}
+
+// WithTranscriber sets the transcriber used by Transcriptions
+func (s *SpeechService) WithTranscriber(t Transcriber) *SpeechService {
+	s.transcriber = t
+	return s
+}
+
+// WithTranscriptionConcurrency lets Transcriptions run up to n transcriptions
+// at once, so back-to-back recordings don't wait for each other. Results are
+// still delivered in recording order. The default is one at a time.
+func (s *SpeechService) WithTranscriptionConcurrency(n int) *SpeechService {
+	s.transcribeConcurrency = n
+	return s
+}
+
 // Initialize sets up SDL2 audio capture
 func (s *SpeechService) Initialize() error {
 	s.mutex.Lock()
@@ -139,6 +396,15 @@ func (s *SpeechService) Initialize() error {
 		return nil
 	}
 
+	if err := checkFrameSize(s.frameSize); err != nil {
+		return err
+	}
+	if s.synthetic != nil {
+		s.backend = newSyntheticBackend(s.synthetic)
+		s.isInitialized = true
+		log.Println("Using synthetic audio input")
+		return nil
+	}
 	if err := sdl.Init(sdl.INIT_AUDIO); err != nil {
 		return fmt.Errorf("failed to initialize SDL audio: %v", err)
 	}
@@ -154,17 +420,28 @@ func (s *SpeechService) Initialize() error {
 		Freq:     AudioFrequency,
 		Format:   AudioFormat,
 		Channels: AudioChannels,
-		Samples:  AudioSamples,
+		Samples:  uint16(s.frameSize),
 		Callback: nil, // We'll use AudioDeviceID.QueueAudio instead
 	}
 
+	// Capture, VAD and transcription all assume 16kHz mono S16LSB, so only the
+	// buffer size may change and SDL converts anything else the device delivers
 	var obtainedSpec sdl.AudioSpec
-	deviceID, err := sdl.OpenAudioDevice("", true, &spec, &obtainedSpec, sdl.AUDIO_ALLOW_ANY_CHANGE)
+	deviceID, err := sdl.OpenAudioDevice("", true, &spec, &obtainedSpec, sdl.AUDIO_ALLOW_SAMPLES_CHANGE)
 	if err != nil {
 		return fmt.Errorf("failed to open audio device: %v", err)
 	}
 
+	if err := checkObtainedSpec(&spec, &obtainedSpec); err != nil {
+		sdl.CloseAudioDevice(deviceID)
+		return err
+	}
+	if obtainedSpec.Samples != spec.Samples {
+		log.Printf("Audio device uses a buffer of %d samples (requested %d)", obtainedSpec.Samples, spec.Samples)
+	}
+
 	s.deviceID = deviceID
+	s.backend = sdlBackend{id: deviceID}
 	s.isInitialized = true
 	log.Println("SDL audio initialized successfully")
 
@@ -185,6 +462,7 @@ func (s *SpeechService) StartListening() error {
 	}
 
 	s.isListening = true
+	s.captureErr = nil
 	s.mutex.Unlock()
 
 	// Start capturing in a separate goroutine
@@ -200,11 +478,12 @@ func (s *SpeechService) StopListening() error {
 
 	if !s.isListening {
 		s.mutex.Unlock()
-		return errors.New("not currently listening")
+		return ErrNotListening
 	}
 
 	// Set state first to prevent further audio processing
 	s.isListening = false
+	s.level = 0
 	s.mutex.Unlock()
 
 	// Signal the listening goroutine to stop
@@ -215,7 +494,7 @@ func (s *SpeechService) StopListening() error {
 	}
 
 	// Pause the audio device
-	sdl.PauseAudioDevice(s.deviceID, true)
+	s.backend.Pause(true)
 
 	log.Println("Stopped listening for voice input")
 	return nil
@@ -235,6 +514,30 @@ func (s *SpeechService) IsRecording() bool {
 	return s.isRecording
 }
 
+// CurrentLevel returns the average level of the most recently captured frame,
+// on the same scale as VadThreshold
+func (s *SpeechService) CurrentLevel() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.level
+}
+
+// LastActivity returns when the capture loop last received audio from the device
+func (s *SpeechService) LastActivity() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastActivity
+}
+
+// IsCaptureStalled reports whether the service is listening but the capture loop
+// hasn't received audio for longer than timeout, e.g. because the device stopped
+// delivering data without reporting an error
+func (s *SpeechService) IsCaptureStalled(timeout time.Duration) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isListening && !s.isInactive && !s.lastActivity.IsZero() && time.Since(s.lastActivity) > timeout
+}
+
 // IsTranscribing returns the current transcribing state
 func (s *SpeechService) IsTranscribing() bool {
 	s.mutex.Lock()
@@ -242,24 +545,55 @@ func (s *SpeechService) IsTranscribing() bool {
 	return s.isTranscribing
 }
 
-// SetTranscribing sets the transcribing state for status display
+// SetTranscribing marks a transcription as started or finished, for status
+// display. Calls nest, so overlapping transcriptions keep the service
+// transcribing until the last of them finishes.
 func (s *SpeechService) SetTranscribing(transcribing bool) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.isTranscribing = transcribing
+	if transcribing {
+		s.transcribing++
+	} else if s.transcribing > 0 {
+		s.transcribing--
+	}
+	s.isTranscribing = s.transcribing > 0
 }
 
 // captureAudio continuously captures audio and detects voice activity
 func (s *SpeechService) captureAudio() {
+	// A panic stops listening like StopListening, via the cleanup below,
+	// rather than crashing the process. Waiters get the error instead.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Audio capture panicked: %v\n%s", r, debug.Stack())
+			s.mutex.Lock()
+			s.captureErr = fmt.Errorf("%w: %v", ErrCaptureFailed, r)
+			s.mutex.Unlock()
+		}
+	}()
+
 	// Start audio capture
-	sdl.PauseAudioDevice(s.deviceID, false)
+	s.backend.Pause(false)
 
-	buffer := make([]byte, AudioSamples*2) // 16-bit samples = 2 bytes per sample
-	silenceFrames := 0
+	s.mutex.Lock()
+	s.lastActivity = time.Now()
+	s.recordingCancelled = false
+	s.mutex.Unlock()
+
+	buffer := make([]byte, s.frameSize*2) // 16-bit samples = 2 bytes per sample
+	silentSamples := 0
+	silenceLimit := s.silenceSampleLimit()
+	extensionLimit := int(s.endpointExtension.Seconds() * AudioFrequency)
+	appliedThreshold := s.CurrentThreshold()
+	trailingOff := false // Near-threshold energy since the speech stopped
 	isRecording := false
+	lastVoice := time.Now()
+	held, released := false, false // Push-to-talk state
 
-	// Use a lower VAD threshold for testing
-	localVadThreshold := int64(VadThreshold) // Already lowered in the constant
+	// A finished recording held back in case speech resumes within mergeGap
+	var pending *AudioData
+	var pendingUntil time.Time
+	s.vad.Reset()
 
 	defer func() {
 		// Ensure we always clean up properly
@@ -268,7 +602,7 @@ func (s *SpeechService) captureAudio() {
 		s.isListening = false
 		s.mutex.Unlock()
 
-		sdl.PauseAudioDevice(s.deviceID, true)
+		s.backend.Pause(true)
 		log.Println("Audio capture goroutine exited")
 	}()
 
@@ -290,8 +624,31 @@ func (s *SpeechService) captureAudio() {
 			return // Exit the goroutine
 		}
 
+		// Push-to-talk overrides voice detection while the key is held
+		select {
+		case down := <-s.pushToTalk:
+			released = released || (held && !down)
+			held = down
+		default:
+		}
+
+		// The pause outlasted the merge gap, the held recording stands alone
+		if pending != nil && !isRecording && time.Now().After(pendingUntil) {
+			s.deliverRecording(pending)
+			pending = nil
+		}
+
+		// Nobody has spoken for a while, stop capturing until resumed
+		if s.inactivityTimeout > 0 && !isRecording && !held && time.Since(lastVoice) >= s.inactivityTimeout {
+			if !s.pauseForInactivity() {
+				return
+			}
+			lastVoice = time.Now()
+			continue
+		}
+
 		// Read audio data
-		bytesRead, err := sdl.DequeueAudio(s.deviceID, buffer)
+		bytesRead, err := s.backend.Dequeue(buffer)
 		if err != nil {
 			// Check if we're shutting down
 			s.mutex.Lock()
@@ -312,6 +669,30 @@ func (s *SpeechService) captureAudio() {
 			continue
 		}
 
+		// Record liveness only when audio actually arrives, so a device that
+		// silently stops delivering data shows up as a stall
+		s.mutex.Lock()
+		s.lastActivity = time.Now()
+		s.mutex.Unlock()
+
+		// Ignore the mic while the caller is playing audio, dropping any clip it interrupted
+		if s.outputSuppressed() {
+			if isRecording {
+				isRecording = false
+				silentSamples = 0
+				trailingOff = false
+				s.mutex.Lock()
+				s.isRecording = false
+				s.recordingCancelled = false
+				s.audioData.Samples = s.audioData.Samples[:0]
+				s.mutex.Unlock()
+				s.vad.Reset()
+				log.Println("Audio output active, recording discarded")
+			}
+			pending = nil
+			continue
+		}
+
 		// Debug - always show audio data being received
 		s.debugLog(DebugCapture, "Audio bytes read: %d", bytesRead)
 
@@ -322,28 +703,59 @@ func (s *SpeechService) captureAudio() {
 			samples[i] = int16(buffer[i*2]) | (int16(buffer[i*2+1]) << 8)
 		}
 
-		// Calculate average energy for debug
-		var sum int64
-		for _, sample := range samples {
-			value := int64(sample)
-			if value < 0 {
-				value = -value
+		// Boost quiet input before voice detection so it sees the amplified level
+		s.mutex.Lock()
+		gain := s.gain
+		s.mutex.Unlock()
+		if gain != 1.0 {
+			applyGain(samples, limitGain(samples, gain))
+		}
+
+		if s.rolling != nil {
+			s.rolling.Write(samples)
+		}
+
+		// Detect voice activity
+		average, clipped := frameLevel(samples)
+		s.mutex.Lock()
+		s.level = average
+		threshold := s.vadThreshold
+		s.mutex.Unlock()
+
+		// Pick up a threshold changed while capturing
+		if threshold != appliedThreshold {
+			if energy, ok := s.vad.(*EnergyVAD); ok {
+				energy.Threshold = threshold
 			}
-			sum += value
+			appliedThreshold = threshold
+		}
+		voice := s.vad.IsVoice(samples)
+		if !voice && !isRecording {
+			s.mutex.Lock()
+			s.updateNoiseFloor(average)
+			s.mutex.Unlock()
 		}
-		average := sum / int64(len(samples))
 
 		// Print audio level for debugging
-		s.debugLog(DebugCapture, "Audio level: %d (threshold: %d)", average, localVadThreshold)
+		s.debugLog(DebugCapture, "Audio level: %d (voice: %v)", average, voice)
 
-		// Detect voice activity
 		if !isRecording {
-			if average > localVadThreshold {
+			if voice || held {
 				// Voice detected, start recording
 				isRecording = true
+				lastVoice = time.Now()
 				s.mutex.Lock()
 				s.isRecording = true
 				s.audioData.Samples = s.audioData.Samples[:0] // Clear buffer
+				s.audioData.Clipped = 0
+				s.recordVadEvent(true, average)
+				// Speech resumed within the merge gap, continue the held recording
+				if pending != nil {
+					s.audioData.Samples = append(s.audioData.Samples, pending.Samples...)
+					s.audioData.Clipped = pending.Clipped
+					s.debugLog(DebugCapture, "Merging with the previous recording (%d samples)", len(pending.Samples))
+					pending = nil
+				}
 				s.mutex.Unlock()
 
 				// Notify that recording has started
@@ -353,89 +765,374 @@ func (s *SpeechService) captureAudio() {
 					// Channel full, skip
 				}
 
-				s.debugLog(DebugCapture, "Voice detected (level: %d), started recording", average)
+				s.debugLog(DebugCapture, "Voice detected (level: %d, held: %v), started recording", average, held)
 			}
 		}
 
 		if isRecording {
-			// Add samples to buffer
+			// Add samples to buffer, unless the recording was cancelled
 			s.mutex.Lock()
-			s.audioData.Samples = append(s.audioData.Samples, samples...)
+			cancelled := s.recordingCancelled
+			s.recordingCancelled = false
+			if !cancelled {
+				s.audioData.Samples = append(s.audioData.Samples, samples...)
+				s.audioData.Clipped += clipped
+			}
 			s.mutex.Unlock()
+			if cancelled {
+				isRecording = false
+				silentSamples = 0
+				trailingOff = false
+				s.vad.Reset()
+				continue
+			}
 
-			// Check for end of speech
-			if average > localVadThreshold {
-				silenceFrames = 0
+			// Check for end of speech, or the push-to-talk key being released
+			if voice || held {
+				silentSamples = 0
+				trailingOff = false
 			} else {
-				silenceFrames++
-				if silenceFrames >= VadSilenceFrames {
-					// Silence detected for long enough, stop recording
-					isRecording = false
-					s.mutex.Lock()
-					s.isRecording = false
-
-					// Only process if we got enough data
-					if len(s.audioData.Samples) > AudioFrequency/4 { // At least 0.25s of audio
-						// Create a copy of the audio data
-						audioData := &AudioData{
-							Samples:    make([]int16, len(s.audioData.Samples)),
-							SampleRate: s.audioData.SampleRate,
-						}
-						copy(audioData.Samples, s.audioData.Samples)
-						s.mutex.Unlock()
-
-						// Notify that recording has stopped with the captured audio
-						select {
-						case s.recordingStopped <- audioData:
-							log.Printf("End of speech detected (recorded %d samples), stopped recording",
-								len(audioData.Samples))
-						default:
-							log.Println("Warning: recordingStopped channel full, dropping audio")
-						}
-					} else {
-						s.mutex.Unlock()
-						log.Println("Recording too short, discarded")
-					}
-
-					silenceFrames = 0
+				silentSamples += len(samples)
+				// Energy just under the threshold is speech trailing off, give it longer to resume
+				if extensionLimit > 0 && average >= int64(s.endpointBand*float64(threshold)) {
+					trailingOff = true
+				}
+			}
+			limit := silenceLimit
+			if trailingOff {
+				limit += extensionLimit
+			}
+			if released || (!held && silentSamples >= limit) {
+				isRecording = false
+				silentSamples = 0
+				trailingOff = false
+				s.mutex.Lock()
+				s.recordVadEvent(false, average)
+				s.mutex.Unlock()
+				switch clip := s.finishRecording(); {
+				case clip == nil:
+					// Cancelled just as the speech ended
+				case s.mergeGap > 0 && !released:
+					pending, pendingUntil = clip, time.Now().Add(s.mergeGap)
+				default:
+					s.deliverRecording(clip)
 				}
 			}
 		}
+		released = false
 
 		// Small sleep to prevent consuming 100% CPU
 		time.Sleep(10 * time.Millisecond)
 	}
 }
 
-// detectVoice implements a simple voice activity detection algorithm
-func detectVoice(samples []int16) bool {
-	// Calculate average energy
-	var sum int64
-	for _, sample := range samples {
-		// Take absolute value
-		value := int64(sample)
-		if value < 0 {
-			value = -value
+// finishRecording ends the current recording and returns a copy of it,
+// trimmed of silence if enabled, or nil if it was cancelled
+func (s *SpeechService) finishRecording() *AudioData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.isRecording = false
+	s.vad.Reset()
+	if s.recordingCancelled {
+		s.recordingCancelled = false
+		return nil
+	}
+
+	// Drop the trailing silence window (and any quiet pre-roll) if enabled
+	recorded := s.audioData.Samples
+	if s.trimSilence {
+		recorded = trimSilence(recorded, int(s.vadThreshold))
+		s.debugLog(DebugCapture, "Trimmed recording from %d to %d samples",
+			len(s.audioData.Samples), len(recorded))
+	}
+
+	return &AudioData{
+		Samples:    append([]int16(nil), recorded...),
+		SampleRate: s.audioData.SampleRate,
+		Clipped:    s.audioData.Clipped,
+	}
+}
+
+// deliverRecording sends a finished recording on recordingStopped, or reports
+// it as discarded if it is too short
+func (s *SpeechService) deliverRecording(audioData *AudioData) {
+	// Only process if we got enough data
+	if len(audioData.Samples) <= AudioFrequency/4 { // At least 0.25s of audio
+		duration := samplesDuration(len(audioData.Samples))
+		log.Printf("Recording too short (%v), discarded", duration)
+
+		// Let the UI explain why nothing happened, dropping the
+		// event if the previous one hasn't been picked up yet
+		select {
+		case s.recordingDiscarded <- duration:
+		default:
 		}
-		sum += value
+		return
 	}
 
-	average := sum / int64(len(samples))
-	return average > VadThreshold
+	if clipped := audioData.ClippedFraction(); clipped > ClipWarningFraction {
+		log.Printf("Input too loud, clipping detected in %.1f%% of samples, lower the mic gain", clipped*100)
+	}
+
+	if s.agc != nil {
+		s.agc.Process(audioData.Samples)
+	}
+
+	s.mutex.Lock()
+	s.lastRecording = audioData
+	s.mutex.Unlock()
+
+	// Notify that recording has stopped with the captured audio
+	select {
+	case s.recordingStopped <- audioData:
+		log.Printf("End of speech detected (recorded %d samples), stopped recording",
+			len(audioData.Samples))
+	default:
+		log.Println("Warning: recordingStopped channel full, dropping audio")
+	}
+}
+
+// BeginRecording starts a push-to-talk recording: capture records from the
+// next frame regardless of voice activity until EndRecording is called. A
+// capture paused for inactivity is resumed.
+func (s *SpeechService) BeginRecording() {
+	if s.IsInactive() {
+		s.Resume()
+	}
+	select {
+	case s.pushToTalk <- true:
+	default:
+	}
+}
+
+// EndRecording ends a push-to-talk recording, delivering it like one ended by silence
+func (s *SpeechService) EndRecording() {
+	select {
+	case s.pushToTalk <- false:
+	default:
+	}
+}
+
+// CancelRecording discards the recording in progress and goes back to
+// listening, without delivering anything. It returns ErrNotRecording when
+// there is nothing to cancel, including when the recording just ended.
+func (s *SpeechService) CancelRecording() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRecording {
+		return ErrNotRecording
+	}
+	// The capture loop sees the flag before it next touches the buffer
+	s.isRecording = false
+	s.recordingCancelled = true
+	s.audioData.Samples = s.audioData.Samples[:0]
+	log.Println("Recording cancelled")
+	return nil
+}
+
+// applyGain scales samples in place by factor, clamping to the int16 range to avoid wraparound
+func applyGain(samples []int16, factor float64) {
+	for i, sample := range samples {
+		value := float64(sample) * factor
+		if value > 32767 {
+			value = 32767
+		} else if value < -32767 {
+			value = -32767
+		}
+		samples[i] = int16(value)
+	}
+}
+
+// trimSilence removes leading and trailing low-energy regions from samples,
+// keeping a small margin around the detected speech
+func trimSilence(samples []int16, threshold int) []int16 {
+	// Find the first and last windows whose average energy exceeds the threshold
+	first, last := -1, -1
+	for start := 0; start < len(samples); start += TrimWindowSamples {
+		end := start + TrimWindowSamples
+		if end > len(samples) {
+			end = len(samples)
+		}
+
+		if averageLevel(samples[start:end]) > int64(threshold) {
+			if first < 0 {
+				first = start
+			}
+			last = end
+		}
+	}
+
+	// Nothing above the threshold, the whole clip is silence
+	if first < 0 {
+		return samples[:0]
+	}
+
+	// Keep a margin on both sides so word onsets and tails aren't clipped
+	first -= TrimMarginSamples
+	if first < 0 {
+		first = 0
+	}
+	last += TrimMarginSamples
+	if last > len(samples) {
+		last = len(samples)
+	}
+
+	return samples[first:last]
+}
+
+// OutputReleaseDelay is how long capture stays suppressed after output stops
+const OutputReleaseDelay = 300 * time.Millisecond
+
+// SetOutputActive tells the service that audio is (or is no longer) being
+// played, e.g. around a text-to-speech call. While active, and for
+// OutputReleaseDelay after, captured audio is ignored and a recording in
+// progress is discarded, so speaker output isn't transcribed. This is a simple
+// echo-avoidance mitigation, not echo cancellation: the user can't be heard
+// while output is active either.
+func (s *SpeechService) SetOutputActive(active bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.outputActive && !active {
+		s.outputReleaseAt = time.Now().Add(OutputReleaseDelay)
+	}
+	s.outputActive = active
+}
+
+// outputSuppressed reports whether capture should be ignored because of audio output
+func (s *SpeechService) outputSuppressed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.outputActive || time.Now().Before(s.outputReleaseAt)
+}
+
+// pauseForInactivity pauses the device until Resume, the resume timer, or a
+// request to stop listening. It returns false if capture should exit.
+func (s *SpeechService) pauseForInactivity() bool {
+	s.backend.Pause(true)
+	s.mutex.Lock()
+	s.isInactive = true
+	s.mutex.Unlock()
+	log.Printf("No speech for %v, pausing capture", s.inactivityTimeout)
+
+	select {
+	case s.inactiveEvents <- struct{}{}:
+	default:
+	}
+
+	var timer <-chan time.Time
+	if s.inactivityResume > 0 {
+		timer = time.After(s.inactivityResume)
+	}
+
+	resumed := true
+	select {
+	case <-s.resume:
+	case <-timer:
+	case <-s.stopListening:
+		resumed = false
+	}
+
+	s.mutex.Lock()
+	s.isInactive = false
+	s.lastActivity = time.Now()
+	s.mutex.Unlock()
+
+	if resumed {
+		// Drop whatever was queued around the pause and start fresh
+		s.backend.Clear()
+		s.backend.Pause(false)
+		log.Println("Resumed capture")
+	}
+	return resumed
+}
+
+// Resume restarts capture paused for inactivity. It does nothing otherwise.
+func (s *SpeechService) Resume() {
+	if !s.IsInactive() {
+		return
+	}
+	select {
+	case s.resume <- struct{}{}:
+	default:
+	}
+}
+
+// IsInactive reports whether capture is paused for inactivity
+func (s *SpeechService) IsInactive() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isInactive
+}
+
+// InactiveEvents returns a channel that receives each time capture pauses for inactivity
+func (s *SpeechService) InactiveEvents() <-chan struct{} {
+	return s.inactiveEvents
+}
+
+// RollingBuffer returns how much audio the rolling buffer holds, or zero if
+// it is disabled
+func (s *SpeechService) RollingBuffer() time.Duration {
+	if s.rolling == nil {
+		return 0
+	}
+	return time.Duration(len(s.rolling.samples)) * time.Second / AudioFrequency
+}
+
+// TranscribeRollingBuffer transcribes the audio in the rolling buffer, like an
+// instant replay of the last few seconds
+func (s *SpeechService) TranscribeRollingBuffer() (*WhisperServerResult, error) {
+	if s.rolling == nil {
+		return nil, errors.New("rolling buffer is not enabled")
+	}
+	if s.transcriber == nil {
+		return nil, errors.New("no transcriber configured")
+	}
+
+	samples := s.rolling.Snapshot()
+	if len(samples) == 0 {
+		return nil, errors.New("rolling buffer is empty")
+	}
+
+	s.SetTranscribing(true)
+	defer s.SetTranscribing(false)
+	return s.transcriber.Transcribe(&AudioData{Samples: samples, SampleRate: AudioFrequency})
+}
+
+// RecordingDiscarded returns a channel that receives the duration of each clip
+// dropped for being too short to transcribe
+func (s *SpeechService) RecordingDiscarded() <-chan time.Duration {
+	return s.recordingDiscarded
+}
+
+// samplesDuration converts a sample count at the capture rate to a duration
+func samplesDuration(samples int) time.Duration {
+	return time.Duration(samples) * time.Second / AudioFrequency
 }
 
 // WaitForRecording blocks until speech is detected and recorded
 func (s *SpeechService) WaitForRecording() (*AudioData, error) {
+	return s.WaitForRecordingContext(context.Background())
+}
+
+// WaitForRecordingContext blocks until speech is detected and recorded, or until
+// ctx is done, in which case the returned error wraps ctx.Err()
+func (s *SpeechService) WaitForRecordingContext(ctx context.Context) (*AudioData, error) {
 	// Check if we're shutting down or not listening
 	s.mutex.Lock()
 	if s.isShutdown {
 		s.mutex.Unlock()
-		return nil, errors.New("service is shutting down")
+		return nil, ErrShuttingDown
 	}
 
 	if !s.isListening {
+		err := s.captureErr
 		s.mutex.Unlock()
-		return nil, errors.New("not currently listening")
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrNotListening
 	}
 	s.mutex.Unlock()
 
@@ -447,7 +1144,7 @@ func (s *SpeechService) WaitForRecording() (*AudioData, error) {
 		s.mutex.Lock()
 		if s.isShutdown {
 			s.mutex.Unlock()
-			return nil, errors.New("service is shutting down")
+			return nil, ErrShuttingDown
 		}
 		s.mutex.Unlock()
 
@@ -455,18 +1152,24 @@ func (s *SpeechService) WaitForRecording() (*AudioData, error) {
 		select {
 		case audioData := <-s.recordingStopped:
 			return audioData, nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for recording: %w", ctx.Err())
 		case <-time.After(timeoutDuration):
 			// Check shutdown state again
 			s.mutex.Lock()
 			if s.isShutdown {
 				s.mutex.Unlock()
-				return nil, errors.New("service is shutting down")
+				return nil, ErrShuttingDown
 			}
 
 			// Then check listening state
 			if !s.isListening {
+				err := s.captureErr
 				s.mutex.Unlock()
-				return nil, errors.New("listening stopped")
+				if err != nil {
+					return nil, err
+				}
+				return nil, ErrListeningStopped
 			}
 			s.mutex.Unlock()
 
@@ -476,15 +1179,73 @@ func (s *SpeechService) WaitForRecording() (*AudioData, error) {
 	}
 }
 
-// Cleanup releases SDL resources
-func (s *SpeechService) Cleanup() error {
-	log.Println("Starting SpeechService cleanup...")
+// Transcriptions returns a channel delivering the transcription of every recording,
+// in recording order, using the configured Transcriber. The first call starts the
+// transcription loop; later calls return the same channel. The channel is closed
+// on shutdown.
+func (s *SpeechService) Transcriptions() <-chan *TranscriptionResult {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.transcriptions == nil {
+		s.transcriptions = make(chan *TranscriptionResult, 1)
+		go s.transcribeLoop(s.transcriptions)
+	}
+	return s.transcriptions
+}
+
+// transcribeLoop waits for recordings and queues them for transcription until shutdown
+func (s *SpeechService) transcribeLoop(out chan<- *TranscriptionResult) {
+	queue := NewTranscriptionQueue(s.transcriber, s.transcribeConcurrency).OnBusy(s.SetTranscribing)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for result := range queue.Results() {
+			out <- result
+		}
+	}()
+
+	// Deliver what was already recorded before closing the channel
+	defer func() {
+		queue.Close()
+		<-forwarded
+		close(out)
+	}()
+
+	for {
+		audioData, err := s.WaitForRecording()
+		if err != nil {
+			if errors.Is(err, ErrShuttingDown) {
+				return
+			}
+			// Listening may be started or resumed later, wait for it
+			if errors.Is(err, ErrNotListening) || errors.Is(err, ErrListeningStopped) || errors.Is(err, ErrCaptureFailed) {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			log.Printf("Error waiting for recording: %v", err)
+			continue
+		}
 
-	// Mark as shutting down first thing
+		if _, err := queue.Submit(audioData); err != nil {
+			log.Printf("Dropped a recording: %v", err)
+		}
+	}
+}
+
+// Cleanup releases SDL resources. Calls after the first do nothing.
+func (s *SpeechService) Cleanup() error {
+	// Mark as shutting down first thing, only the first call does the work
 	s.mutex.Lock()
+	if s.isShutdown {
+		s.mutex.Unlock()
+		return nil
+	}
 	s.isShutdown = true
 	s.mutex.Unlock()
 
+	log.Println("Starting SpeechService cleanup...")
+
 	// First stop listening if needed, but don't hold the main lock during this
 	listening := s.IsListening()
 	if listening {
@@ -497,7 +1258,7 @@ func (s *SpeechService) Cleanup() error {
 
 	// Clean up SDL resources
 	s.mutex.Lock()
-	isInit := s.isInitialized
+	isInit := s.isInitialized && s.synthetic == nil
 	deviceID := s.deviceID
 	s.isInitialized = false // Prevent reuse
 	s.mutex.Unlock()
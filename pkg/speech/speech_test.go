@@ -0,0 +1,442 @@
+package speech
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// synthesizeClip builds a clip of silence, a constant-amplitude "speech" burst, and silence
+func synthesizeClip(leading, speech, trailing int, amplitude int16) []int16 {
+	samples := make([]int16, 0, leading+speech+trailing)
+	samples = append(samples, make([]int16, leading)...)
+	for i := 0; i < speech; i++ {
+		// Alternate the sign so the burst looks like a waveform rather than DC
+		if i%2 == 0 {
+			samples = append(samples, amplitude)
+		} else {
+			samples = append(samples, -amplitude)
+		}
+	}
+	samples = append(samples, make([]int16, trailing)...)
+	return samples
+}
+
+// TestTrimSilence verifies leading and trailing silence is removed with a margin kept
+func TestTrimSilence(t *testing.T) {
+	leading := AudioFrequency    // 1s of silence
+	speech := AudioFrequency / 2 // 0.5s of speech
+	trailing := AudioFrequency   // 1s of silence
+	samples := synthesizeClip(leading, speech, trailing, 1000)
+
+	trimmed := trimSilence(samples, VadThreshold)
+
+	expected := speech + 2*TrimMarginSamples
+	if len(trimmed) != expected {
+		t.Fatalf("Expected %d samples after trimming, got %d", expected, len(trimmed))
+	}
+
+	// The first and last samples should come from the margin, which is silent
+	if trimmed[0] != 0 || trimmed[len(trimmed)-1] != 0 {
+		t.Errorf("Expected trimmed clip to start and end in the silent margin")
+	}
+}
+
+// TestTrimSilenceEdges verifies the margin is clamped at the clip boundaries
+func TestTrimSilenceEdges(t *testing.T) {
+	samples := synthesizeClip(0, AudioFrequency/2, TrimMarginSamples/2, 1000)
+
+	trimmed := trimSilence(samples, VadThreshold)
+	if len(trimmed) != len(samples) {
+		t.Errorf("Expected no trimming when speech fills the clip, got %d of %d samples",
+			len(trimmed), len(samples))
+	}
+}
+
+// TestTrimSilenceAllQuiet verifies a fully silent clip is trimmed to nothing
+func TestTrimSilenceAllQuiet(t *testing.T) {
+	samples := synthesizeClip(AudioFrequency, 0, 0, 0)
+
+	trimmed := trimSilence(samples, VadThreshold)
+	if len(trimmed) != 0 {
+		t.Errorf("Expected silent clip to be trimmed to 0 samples, got %d", len(trimmed))
+	}
+}
+
+// TestApplyGain verifies samples are scaled and clamped instead of overflowing
+func TestApplyGain(t *testing.T) {
+	samples := []int16{0, 100, -100, 10000, -10000, 32767, -32768}
+	applyGain(samples, 4.0)
+
+	expected := []int16{0, 400, -400, 32767, -32767, 32767, -32767}
+	for i := range expected {
+		if samples[i] != expected[i] {
+			t.Errorf("Sample %d: expected %d, got %d", i, expected[i], samples[i])
+		}
+	}
+}
+
+// TestApplyGainUnity verifies a gain of 1.0 leaves in-range samples unchanged
+func TestApplyGainUnity(t *testing.T) {
+	samples := []int16{0, 1, -1, 12345, -12345, 32767}
+	original := make([]int16, len(samples))
+	copy(original, samples)
+
+	applyGain(samples, 1.0)
+
+	for i := range original {
+		if samples[i] != original[i] {
+			t.Errorf("Sample %d: expected %d, got %d", i, original[i], samples[i])
+		}
+	}
+}
+
+// TestLimitGain verifies gain is held back only where it would clip
+func TestLimitGain(t *testing.T) {
+	if got := limitGain([]int16{100, -200}, 4); got != 4 {
+		t.Errorf("Expected a quiet frame to get the full gain, got %v", got)
+	}
+	if got := limitGain([]int16{16000, -16000}, 4); got != gainHeadroomPeak/16000.0 {
+		t.Errorf("Expected a loud frame to be limited to the headroom, got %v", got)
+	}
+	if got := limitGain([]int16{32767}, 4); got != 1 {
+		t.Errorf("Expected clipped input to keep unity gain, got %v", got)
+	}
+	if got := limitGain([]int16{32767}, 0.5); got != 0.5 {
+		t.Errorf("Expected attenuation to pass through, got %v", got)
+	}
+
+	svc := NewSpeechService()
+	svc.SetInputGain(100)
+	if got := svc.InputGain(); got != MaxInputGain {
+		t.Errorf("Expected gain clamped to %v, got %v", MaxInputGain, got)
+	}
+}
+
+// TestSilenceSampleLimit verifies silence durations convert to sample counts
+func TestSilenceSampleLimit(t *testing.T) {
+	svc := NewSpeechService()
+
+	// The default matches VadSilenceFrames of default-sized frames
+	if got, expected := svc.silenceSampleLimit(), VadSilenceFrames*AudioSamples; got != expected {
+		t.Errorf("Expected default limit of %d samples, got %d", expected, got)
+	}
+
+	svc.WithSilenceDuration(800 * time.Millisecond)
+	if got, expected := svc.silenceSampleLimit(), AudioFrequency*8/10; got != expected {
+		t.Errorf("Expected 800ms limit of %d samples, got %d", expected, got)
+	}
+}
+
+// TestCheckFrameSize verifies only powers of two in SDL's range are accepted
+func TestCheckFrameSize(t *testing.T) {
+	for _, n := range []int{MinFrameSize, 1024, AudioSamples, MaxFrameSize} {
+		if err := checkFrameSize(n); err != nil {
+			t.Errorf("Expected %d to be accepted, got %v", n, err)
+		}
+	}
+	for _, n := range []int{0, -1024, 128, 1000, 3072, 65536} {
+		if err := checkFrameSize(n); !errors.Is(err, ErrInvalidFrameSize) {
+			t.Errorf("Expected %d to be rejected, got %v", n, err)
+		}
+	}
+}
+
+// fakeTranscriber returns a fixed result for any audio
+type fakeTranscriber struct {
+	text string
+}
+
+func (f *fakeTranscriber) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	return &WhisperServerResult{Text: f.text, Success: true}, nil
+}
+
+// TestTranscriptions verifies recordings are transcribed and delivered on the channel
+func TestTranscriptions(t *testing.T) {
+	transcriber := &fakeTranscriber{text: "hello"}
+	svc := NewSpeechService().WithTranscriber(transcriber)
+
+	// Pretend capture is running and a recording just finished
+	svc.isListening = true
+	recording := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	svc.recordingStopped <- recording
+
+	results := svc.Transcriptions()
+	if svc.Transcriptions() != results {
+		t.Fatal("Expected repeated calls to return the same channel")
+	}
+
+	select {
+	case r := <-results:
+		if r.Err != nil {
+			t.Fatalf("Unexpected transcription error: %v", r.Err)
+		}
+		if r.Result.Text != "hello" || r.Audio != recording {
+			t.Errorf("Unexpected result: %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for transcription")
+	}
+
+	if svc.IsTranscribing() {
+		t.Error("Expected transcribing state to be cleared after the result")
+	}
+
+	// Shutting down closes the channel
+	svc.mutex.Lock()
+	svc.isShutdown = true
+	svc.mutex.Unlock()
+
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Error("Expected channel to be closed on shutdown")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for the channel to close")
+	}
+}
+
+// TestDeliverRecording verifies short clips are reported as discarded and longer ones delivered
+func TestDeliverRecording(t *testing.T) {
+	svc := NewSpeechService()
+	if err := svc.PlayLastRecording(); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Expected ErrNoRecording before any recording, got %v", err)
+	}
+
+	svc.deliverRecording(&AudioData{Samples: make([]int16, AudioFrequency/8), SampleRate: AudioFrequency})
+	select {
+	case d := <-svc.RecordingDiscarded():
+		if d != 125*time.Millisecond {
+			t.Errorf("Expected a 125ms discard, got %v", d)
+		}
+	default:
+		t.Error("Expected the short clip to be discarded")
+	}
+
+	clip := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	svc.deliverRecording(clip)
+	select {
+	case got := <-svc.recordingStopped:
+		if got != clip {
+			t.Error("Expected the delivered clip")
+		}
+	default:
+		t.Error("Expected the clip to be delivered")
+	}
+	if svc.LastRecording() != clip {
+		t.Error("Expected the delivered clip to be kept for playback")
+	}
+}
+
+// TestSetTranscribingNests verifies overlapping transcriptions keep the
+// service transcribing until the last one finishes
+func TestSetTranscribingNests(t *testing.T) {
+	svc := NewSpeechService()
+	svc.SetTranscribing(true)
+	svc.SetTranscribing(true)
+	svc.SetTranscribing(false)
+	if !svc.IsTranscribing() {
+		t.Error("Expected the service to be transcribing while one transcription is left")
+	}
+	svc.SetTranscribing(false)
+	svc.SetTranscribing(false)
+	if svc.IsTranscribing() {
+		t.Error("Expected the service to stop transcribing after the last one")
+	}
+	svc.SetTranscribing(true)
+	if !svc.IsTranscribing() {
+		t.Error("Expected an extra false not to be counted against the next transcription")
+	}
+}
+
+// TestState verifies the state precedence of recording over transcribing over listening
+func TestState(t *testing.T) {
+	svc := NewSpeechService()
+	steps := []struct {
+		set  func()
+		want State
+	}{
+		{func() {}, StateIdle},
+		{func() { svc.isListening = true }, StateListening},
+		{func() { svc.isTranscribing = true }, StateTranscribing},
+		{func() { svc.isRecording = true }, StateRecording},
+		{func() { svc.isShutdown = true }, StateShuttingDown},
+	}
+	for _, step := range steps {
+		step.set()
+		if got := svc.State(); got != step.want {
+			t.Errorf("Expected %v, got %v", step.want, got)
+		}
+	}
+}
+
+// TestIsCaptureStalled verifies the capture watchdog only fires while listening
+func TestIsCaptureStalled(t *testing.T) {
+	svc := NewSpeechService()
+
+	svc.lastActivity = time.Now().Add(-10 * time.Second)
+	if svc.IsCaptureStalled(time.Second) {
+		t.Error("Expected no stall when not listening")
+	}
+
+	svc.isListening = true
+	if !svc.IsCaptureStalled(time.Second) {
+		t.Error("Expected a stall after 10s without audio")
+	}
+
+	svc.lastActivity = time.Now()
+	if svc.IsCaptureStalled(time.Second) {
+		t.Error("Expected no stall right after audio arrived")
+	}
+}
+
+// TestWaitForRecordingContext verifies the wait is bounded by the context
+func TestWaitForRecordingContext(t *testing.T) {
+	svc := NewSpeechService()
+	svc.isListening = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := svc.WaitForRecordingContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a deadline exceeded error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to return at the deadline, took %v", elapsed)
+	}
+
+	// Without a deadline, a queued recording is returned as before
+	recording := &AudioData{Samples: make([]int16, 10), SampleRate: AudioFrequency}
+	svc.recordingStopped <- recording
+	got, err := svc.WaitForRecordingContext(context.Background())
+	if err != nil || got != recording {
+		t.Errorf("Expected the queued recording, got %v, %v", got, err)
+	}
+}
+
+// TestSetOutputActive verifies capture stays suppressed during output and its release delay
+func TestSetOutputActive(t *testing.T) {
+	svc := NewSpeechService()
+	if svc.outputSuppressed() {
+		t.Fatal("Expected capture not to be suppressed initially")
+	}
+
+	svc.SetOutputActive(true)
+	if !svc.outputSuppressed() {
+		t.Error("Expected capture to be suppressed while output is active")
+	}
+
+	svc.SetOutputActive(false)
+	if !svc.outputSuppressed() {
+		t.Error("Expected capture to stay suppressed during the release delay")
+	}
+
+	svc.outputReleaseAt = time.Now()
+	if svc.outputSuppressed() {
+		t.Error("Expected capture to resume after the release delay")
+	}
+}
+
+// TestPauseForInactivity verifies a paused capture reports inactivity and resumes on request
+func TestPauseForInactivity(t *testing.T) {
+	svc := NewSpeechService().WithInactivityTimeout(time.Minute)
+
+	resumed := make(chan bool, 1)
+	go func() { resumed <- svc.pauseForInactivity() }()
+
+	select {
+	case <-svc.InactiveEvents():
+	case <-time.After(time.Second):
+		t.Fatal("Expected an inactive event")
+	}
+	if !svc.IsInactive() {
+		t.Error("Expected the service to report inactivity while paused")
+	}
+
+	svc.Resume()
+	select {
+	case ok := <-resumed:
+		if !ok {
+			t.Error("Expected capture to continue after Resume")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Resume to end the pause")
+	}
+	if svc.IsInactive() {
+		t.Error("Expected inactivity to clear after resuming")
+	}
+}
+
+// TestPauseForInactivityResumeTimer verifies the resume timer ends the pause on its own
+func TestPauseForInactivityResumeTimer(t *testing.T) {
+	svc := NewSpeechService().WithInactivityResume(50 * time.Millisecond)
+
+	if !svc.pauseForInactivity() {
+		t.Error("Expected capture to continue after the resume timer")
+	}
+}
+
+// TestCheckObtainedSpec verifies a device format the capture loop can't decode is rejected
+func TestCheckObtainedSpec(t *testing.T) {
+	desired := sdl.AudioSpec{Freq: AudioFrequency, Format: AudioFormat, Channels: AudioChannels, Samples: AudioSamples}
+
+	tests := []struct {
+		name    string
+		modify  func(*sdl.AudioSpec)
+		wantErr bool
+	}{
+		{"identical", func(*sdl.AudioSpec) {}, false},
+		{"buffer size", func(s *sdl.AudioSpec) { s.Samples = 1024 }, false},
+		{"frequency", func(s *sdl.AudioSpec) { s.Freq = 48000 }, true},
+		{"format", func(s *sdl.AudioSpec) { s.Format = sdl.AUDIO_F32LSB }, true},
+		{"channels", func(s *sdl.AudioSpec) { s.Channels = 2 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obtained := desired
+			tt.modify(&obtained)
+			if err := checkObtainedSpec(&desired, &obtained); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestShutdownTwice verifies concurrent and repeated shutdowns are safe
+func TestShutdownTwice(t *testing.T) {
+	svc := NewSpeechService()
+	whisper := NewWhisperServerService()
+	whisper.isRunning = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			svc.Shutdown()
+		}()
+		go func() {
+			defer wg.Done()
+			whisper.Shutdown()
+		}()
+	}
+	wg.Wait()
+
+	if err := svc.Shutdown(); err != nil {
+		t.Errorf("Repeated speech Shutdown failed: %v", err)
+	}
+	if err := whisper.Shutdown(); err != nil {
+		t.Errorf("Repeated whisper Shutdown failed: %v", err)
+	}
+	if whisper.IsRunning() {
+		t.Error("Expected whisper service to be stopped")
+	}
+}
@@ -0,0 +1,49 @@
+package speech
+
+// State is the speech service's overall state, for status displays
+type State int
+
+const (
+	StateIdle State = iota
+	StateListening
+	StateRecording
+	StateTranscribing
+	StateShuttingDown
+)
+
+// String returns the state's lowercase name
+func (st State) String() string {
+	switch st {
+	case StateListening:
+		return "listening"
+	case StateRecording:
+		return "recording"
+	case StateTranscribing:
+		return "transcribing"
+	case StateShuttingDown:
+		return "shutting_down"
+	default:
+		return "idle"
+	}
+}
+
+// State returns the service's current state. Recording takes precedence over
+// transcribing, which takes precedence over listening, so a recording made
+// while the previous one is still transcribing shows as recording.
+func (s *SpeechService) State() State {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case s.isShutdown:
+		return StateShuttingDown
+	case s.isRecording:
+		return StateRecording
+	case s.isTranscribing:
+		return StateTranscribing
+	case s.isListening:
+		return StateListening
+	default:
+		return StateIdle
+	}
+}
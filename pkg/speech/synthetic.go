@@ -0,0 +1,107 @@
+package speech
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// Test tone defaults: bursts long enough to be kept as recordings, with gaps
+// long enough to end them
+const (
+	DefaultToneOn        = time.Second
+	DefaultToneOff       = 3 * time.Second // Longer than DefaultSilenceDuration
+	toneFrequency        = 440             // Hz
+	toneAmplitude        = 8000            // Well above VadThreshold, well below clipping
+	toneFrameSamples     = 800             // 50ms frames
+	toneBackgroundSpread = 10              // Peak of the low noise between bursts
+	toneNoiseSeed        = 12345           // Fixed so runs are reproducible
+)
+
+// WithSyntheticInput captures from generator instead of a microphone, for
+// smoke tests in headless environments. Each call returns the next frame of
+// 16kHz mono samples, which are fed to voice detection and recording in real
+// time like microphone audio. SDL is not used for capture, but playback
+// still needs an audio device.
+func (s *SpeechService) WithSyntheticInput(generator func() []int16) *SpeechService {
+	s.synthetic = generator
+	return s
+}
+
+// ToneBursts returns a generator for WithSyntheticInput alternating a sine
+// tone for on with faint noise for off, so every burst becomes a recording
+func ToneBursts(on, off time.Duration) func() []int16 {
+	onSamples := int(on.Seconds() * AudioFrequency)
+	period := onSamples + int(off.Seconds()*AudioFrequency)
+	position := 0
+	noise := uint32(toneNoiseSeed)
+
+	return func() []int16 {
+		frame := make([]int16, toneFrameSamples)
+		for i := range frame {
+			if position%period < onSamples {
+				phase := 2 * math.Pi * toneFrequency * float64(position) / AudioFrequency
+				frame[i] = int16(toneAmplitude * math.Sin(phase))
+			} else {
+				// xorshift keeps the background deterministic
+				noise ^= noise << 13
+				noise ^= noise >> 17
+				noise ^= noise << 5
+				frame[i] = int16(int(noise%(2*toneBackgroundSpread+1)) - toneBackgroundSpread)
+			}
+			position++
+		}
+		return frame
+	}
+}
+
+// syntheticBackend feeds generated frames to the capture loop at the rate a
+// microphone would deliver them
+type syntheticBackend struct {
+	mu       sync.Mutex
+	generate func() []int16
+	paused   bool
+	pending  []int16   // Generated samples not read yet
+	due      time.Time // When the next samples are available
+}
+
+func newSyntheticBackend(generate func() []int16) *syntheticBackend {
+	return &syntheticBackend{generate: generate, paused: true}
+}
+
+func (b *syntheticBackend) Pause(pause bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused && !pause {
+		b.due = time.Now()
+	}
+	b.paused = pause
+}
+
+func (b *syntheticBackend) Dequeue(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.paused || now.Before(b.due) {
+		return 0, nil
+	}
+
+	if len(b.pending) == 0 {
+		b.pending = b.generate()
+	}
+	n := min(len(data)/2, len(b.pending))
+	for i, sample := range b.pending[:n] {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	b.pending = b.pending[n:]
+	b.due = b.due.Add(time.Duration(n) * time.Second / AudioFrequency)
+	return n * 2, nil
+}
+
+func (b *syntheticBackend) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = nil
+	b.due = time.Now()
+}
@@ -0,0 +1,120 @@
+package speech
+
+import "time"
+
+// maxVadEvents is how many recent VAD trigger events are kept for tuning displays
+const maxVadEvents = 8
+
+// noiseFloorSmoothing is the weight of each non-voice frame in the noise floor estimate
+const noiseFloorSmoothing = 0.05
+
+// Input gain range accepted by SetInputGain
+const (
+	MinInputGain = 0.1
+	MaxInputGain = 20.0
+)
+
+// gainHeadroomPeak is the highest peak input gain may raise a frame to, so
+// boosting a quiet microphone doesn't clip its loud moments
+const gainHeadroomPeak = 32000
+
+// VadEvent is a recording started or ended by voice activity detection
+type VadEvent struct {
+	Time    time.Time
+	Started bool  // Recording started, otherwise it ended
+	Level   int64 // Level of the frame that triggered it
+}
+
+// WithVadThreshold sets the level the default energy VAD treats as voice
+func (s *SpeechService) WithVadThreshold(threshold int64) *SpeechService {
+	s.vadThreshold = threshold
+	if energy, ok := s.vad.(*EnergyVAD); ok {
+		energy.Threshold = threshold
+	}
+	return s
+}
+
+// SetVadThreshold changes the voice threshold while capturing, for tuning it
+// live. It applies to the default energy VAD, the endpointing band and
+// silence trimming; a VAD set with WithVAD keeps its own settings.
+func (s *SpeechService) SetVadThreshold(threshold int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.vadThreshold = max(threshold, 1)
+}
+
+// CurrentThreshold returns the voice threshold, on the same scale as CurrentLevel
+func (s *SpeechService) CurrentThreshold() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.vadThreshold
+}
+
+// NoiseFloor returns the running average level of frames without voice
+func (s *SpeechService) NoiseFloor() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return int64(s.noiseFloor)
+}
+
+// SetInputGain changes the digital gain applied to captured samples while
+// capturing, clamped to MinInputGain..MaxInputGain
+func (s *SpeechService) SetInputGain(gain float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gain = min(max(gain, MinInputGain), MaxInputGain)
+}
+
+// InputGain returns the digital gain applied to captured samples
+func (s *SpeechService) InputGain() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.gain
+}
+
+// limitGain lowers a boosting gain for a frame it would push past
+// gainHeadroomPeak, but never below unity, so input that is already clipped
+// stays visible as clipping
+func limitGain(samples []int16, gain float64) float64 {
+	if gain <= 1 {
+		return gain
+	}
+	var peak int
+	for _, sample := range samples {
+		value := int(sample)
+		if value < 0 {
+			value = -value
+		}
+		peak = max(peak, value)
+	}
+	if peak == 0 {
+		return gain
+	}
+	return max(min(gain, gainHeadroomPeak/float64(peak)), 1)
+}
+
+// RecentVadEvents returns the latest recordings started and ended by VAD, oldest first
+func (s *SpeechService) RecentVadEvents() []VadEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]VadEvent(nil), s.vadEvents...)
+}
+
+// recordVadEvent adds an event, dropping the oldest beyond maxVadEvents. The
+// caller holds the mutex.
+func (s *SpeechService) recordVadEvent(started bool, level int64) {
+	s.vadEvents = append(s.vadEvents, VadEvent{Time: time.Now(), Started: started, Level: level})
+	if over := len(s.vadEvents) - maxVadEvents; over > 0 {
+		s.vadEvents = append([]VadEvent(nil), s.vadEvents[over:]...)
+	}
+}
+
+// updateNoiseFloor folds a frame without voice into the noise floor estimate.
+// The caller holds the mutex.
+func (s *SpeechService) updateNoiseFloor(level int64) {
+	if s.noiseFloor == 0 {
+		s.noiseFloor = float64(level)
+		return
+	}
+	s.noiseFloor += noiseFloorSmoothing * (float64(level) - s.noiseFloor)
+}
@@ -0,0 +1,56 @@
+package speech
+
+import "math"
+
+// VAD decides whether a frame of captured audio contains speech. Implementations
+// may keep state across frames (e.g. smoothing or hangover), which Reset clears
+// at the start of capture and after each recording.
+type VAD interface {
+	IsVoice(frame []int16) bool
+	Reset()
+}
+
+// EnergyVAD is the default VAD, comparing a frame's mean absolute amplitude to a threshold
+type EnergyVAD struct {
+	Threshold int64
+}
+
+// NewEnergyVAD creates an energy-threshold VAD
+func NewEnergyVAD(threshold int64) *EnergyVAD {
+	return &EnergyVAD{Threshold: threshold}
+}
+
+// IsVoice reports whether the frame's average level exceeds the threshold
+func (v *EnergyVAD) IsVoice(frame []int16) bool {
+	return len(frame) > 0 && averageLevel(frame) > v.Threshold
+}
+
+// Reset implements VAD, the energy detector is stateless
+func (v *EnergyVAD) Reset() {}
+
+// averageLevel returns the mean absolute amplitude of samples
+func averageLevel(samples []int16) int64 {
+	level, _ := frameLevel(samples)
+	return level
+}
+
+// frameLevel returns the mean absolute amplitude of samples along with how
+// many of them are at full scale, i.e. clipped
+func frameLevel(samples []int16) (level int64, clipped int) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	var sum int64
+	for _, sample := range samples {
+		value := int64(sample)
+		if value < 0 {
+			value = -value
+		}
+		if value >= math.MaxInt16 {
+			clipped++
+		}
+		sum += value
+	}
+	return sum / int64(len(samples)), clipped
+}
@@ -0,0 +1,40 @@
+package speech
+
+import (
+	"testing"
+)
+
+// TestEnergyVAD verifies the default detector against synthetic frames
+func TestEnergyVAD(t *testing.T) {
+	vad := NewEnergyVAD(VadThreshold)
+
+	tests := []struct {
+		name     string
+		frame    []int16
+		expected bool
+	}{
+		{"silence", make([]int16, 160), false},
+		{"quiet noise", synthesizeClip(0, 160, 0, VadThreshold/2), false},
+		{"at threshold", synthesizeClip(0, 160, 0, VadThreshold), false},
+		{"speech", synthesizeClip(0, 160, 0, 1000), true},
+		{"empty frame", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vad.IsVoice(tt.frame); got != tt.expected {
+				t.Errorf("IsVoice() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAverageLevel verifies the mean absolute amplitude calculation
+func TestAverageLevel(t *testing.T) {
+	if got := averageLevel([]int16{100, -300, 200, -400}); got != 250 {
+		t.Errorf("Expected average level 250, got %d", got)
+	}
+	if got := averageLevel(nil); got != 0 {
+		t.Errorf("Expected average level 0 for no samples, got %d", got)
+	}
+}
@@ -0,0 +1,355 @@
+package speech
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// WAV format codes from the fmt chunk
+const (
+	wavFormatPCM        = 1
+	wavFormatIEEEFloat  = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// ErrWavTooLarge is returned for audio too long for a WAV file's 32-bit sizes
+var ErrWavTooLarge = errors.New("audio too long for a WAV file")
+
+// maxWavSamples is the most 16-bit samples whose data chunk and RIFF sizes
+// still fit in a uint32, a little over 37 hours at 16kHz
+const maxWavSamples = (math.MaxUint32 - 36) / 2
+
+// wavStreamThreshold is the length in samples above which recordings are
+// streamed through a WavWriter instead of encoded in one go, about a minute
+// at 16kHz
+const wavStreamThreshold = 1 << 20
+
+// wavChunkSamples is how many samples a WavWriter encodes at a time
+const wavChunkSamples = 32 * 1024
+
+// maxWavFmtSize bounds the fmt chunk, which is 40 bytes at most for the
+// extensible format, so a corrupt size can't drive the read
+const maxWavFmtSize = 64
+
+// maxWavPrealloc is the most data chunk bytes samples are allocated for up
+// front, beyond which the header's size isn't trusted and samples grow as read
+const maxWavPrealloc = 1 << 20
+
+// wavFormat holds the fields of a WAV fmt chunk needed to decode samples
+type wavFormat struct {
+	AudioFormat   uint16
+	NumChannels   uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// wavHeader is the canonical 44-byte header of a 16-bit mono PCM WAV file
+type wavHeader struct {
+	ChunkID       [4]byte // "RIFF"
+	ChunkSize     uint32  // 36 + SubChunk2Size
+	Format        [4]byte // "WAVE"
+	SubChunk1ID   [4]byte // "fmt "
+	SubChunk1Size uint32  // 16 for PCM
+	AudioFormat   uint16  // 1 for PCM
+	NumChannels   uint16  // 1 for mono, 2 for stereo
+	SampleRate    uint32  // 16000, 44100, etc.
+	ByteRate      uint32  // SampleRate * NumChannels * BitsPerSample/8
+	BlockAlign    uint16  // NumChannels * BitsPerSample/8
+	BitsPerSample uint16  // 8, 16, etc.
+	SubChunk2ID   [4]byte // "data"
+	SubChunk2Size uint32  // NumSamples * NumChannels * BitsPerSample/8
+}
+
+// checkWavLength rejects sample counts whose sizes would wrap in the header
+func checkWavLength(numSamples, sampleRate int) error {
+	if numSamples > maxWavSamples {
+		duration := time.Duration(numSamples) * time.Second / time.Duration(max(sampleRate, 1))
+		return fmt.Errorf("%w: %v of audio, the limit is %d samples", ErrWavTooLarge, duration.Round(time.Second), maxWavSamples)
+	}
+	return nil
+}
+
+// newWavHeader returns the header of a 16-bit mono PCM WAV file holding numSamples
+func newWavHeader(numSamples, sampleRate int) wavHeader {
+	dataSize := uint32(numSamples * 2) // 16-bit samples = 2 bytes per sample
+
+	return wavHeader{
+		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + dataSize,
+		Format:        [4]byte{'W', 'A', 'V', 'E'},
+		SubChunk1ID:   [4]byte{'f', 'm', 't', ' '},
+		SubChunk1Size: 16,
+		AudioFormat:   wavFormatPCM,
+		NumChannels:   1, // Mono
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate * 1 * 16 / 8),
+		BlockAlign:    2, // 1 channel * 16 bits per sample / 8
+		BitsPerSample: 16,
+		SubChunk2ID:   [4]byte{'d', 'a', 't', 'a'},
+		SubChunk2Size: dataSize,
+	}
+}
+
+// WriteWav encodes samples as a 16-bit mono PCM WAV stream. Audio too long for
+// the header's 32-bit sizes is rejected with ErrWavTooLarge before anything is written.
+func WriteWav(w io.Writer, samples []int16, sampleRate int) error {
+	if err := checkWavLength(len(samples), sampleRate); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, newWavHeader(len(samples), sampleRate)); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, samples)
+}
+
+// WavWriter streams 16-bit mono PCM samples to a WAV file whose length isn't
+// known up front. Samples are encoded a chunk at a time, so memory use doesn't
+// grow with the recording. The header is written with zero sizes, which
+// readers take as "until the end of the file", and Close patches them.
+type WavWriter struct {
+	w          io.WriteSeeker
+	sampleRate int
+	samples    int
+	buffer     []byte
+}
+
+// NewWavWriter writes a placeholder header to w and returns a writer for the samples
+func NewWavWriter(w io.WriteSeeker, sampleRate int) (*WavWriter, error) {
+	if err := binary.Write(w, binary.LittleEndian, newWavHeader(0, sampleRate)); err != nil {
+		return nil, err
+	}
+	return &WavWriter{w: w, sampleRate: sampleRate, buffer: make([]byte, wavChunkSamples*2)}, nil
+}
+
+// Write appends samples. Audio growing too long for a WAV file's sizes is
+// rejected with ErrWavTooLarge.
+func (ww *WavWriter) Write(samples []int16) error {
+	if err := checkWavLength(ww.samples+len(samples), ww.sampleRate); err != nil {
+		return err
+	}
+	for len(samples) > 0 {
+		n := min(len(samples), wavChunkSamples)
+		for i, sample := range samples[:n] {
+			binary.LittleEndian.PutUint16(ww.buffer[i*2:], uint16(sample))
+		}
+		if _, err := ww.w.Write(ww.buffer[:n*2]); err != nil {
+			return err
+		}
+		ww.samples += n
+		samples = samples[n:]
+	}
+	return nil
+}
+
+// Close patches the header sizes for the samples written. It doesn't close
+// the underlying writer.
+func (ww *WavWriter) Close() error {
+	header := newWavHeader(ww.samples, ww.sampleRate)
+	if err := ww.patch(4, header.ChunkSize); err != nil {
+		return err
+	}
+	if err := ww.patch(40, header.SubChunk2Size); err != nil {
+		return err
+	}
+	_, err := ww.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+// patch overwrites the header size field at offset
+func (ww *WavWriter) patch(offset int64, size uint32) error {
+	if _, err := ww.w.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to WAV header: %v", err)
+	}
+	return binary.Write(ww.w, binary.LittleEndian, size)
+}
+
+// WriteWavFile writes samples to path as a 16-bit mono PCM WAV file
+func WriteWavFile(path string, samples []int16, sampleRate int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteWav(file, samples, sampleRate); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadWavFile reads a WAV file and converts it to 16-bit mono AudioData
+func LoadWavFile(path string) (*AudioData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadWav(file)
+}
+
+// ReadWav decodes a WAV stream into 16-bit mono AudioData. 8-bit unsigned,
+// 16/24/32-bit signed and 32-bit float PCM are supported; multi-channel audio
+// is downmixed. Chunks are read sequentially, so r doesn't need to be seekable.
+func ReadWav(r io.Reader) (*AudioData, error) {
+	// RIFF header: "RIFF", size, "WAVE"
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("failed to read WAV header: %v", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+
+	var format *wavFormat
+	for {
+		// Chunk header: 4-byte ID and little-endian size
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("failed to find WAV data chunk: %v", err)
+		}
+		id := string(header[0:4])
+		size := binary.LittleEndian.Uint32(header[4:8])
+
+		switch id {
+		case "fmt ":
+			f, err := readWavFormat(r, size)
+			if err != nil {
+				return nil, err
+			}
+			format = f
+
+		case "data":
+			if format == nil {
+				return nil, errors.New("WAV data chunk before fmt chunk")
+			}
+			return readWavData(r, size, format)
+
+		default:
+			// Skip unknown chunks, which are padded to an even size
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return nil, fmt.Errorf("failed to skip WAV chunk %q: %v", id, err)
+			}
+		}
+	}
+}
+
+// readWavFormat parses a fmt chunk of the given size and validates the encoding
+func readWavFormat(r io.Reader, size uint32) (*wavFormat, error) {
+	if size < 16 {
+		return nil, fmt.Errorf("WAV fmt chunk too small: %d bytes", size)
+	}
+	if size > maxWavFmtSize {
+		return nil, fmt.Errorf("WAV fmt chunk too large: %d bytes", size)
+	}
+
+	data := make([]byte, int64(size)+int64(size%2))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read WAV fmt chunk: %v", err)
+	}
+
+	f := &wavFormat{
+		AudioFormat:   binary.LittleEndian.Uint16(data[0:2]),
+		NumChannels:   binary.LittleEndian.Uint16(data[2:4]),
+		SampleRate:    binary.LittleEndian.Uint32(data[4:8]),
+		ByteRate:      binary.LittleEndian.Uint32(data[8:12]),
+		BlockAlign:    binary.LittleEndian.Uint16(data[12:14]),
+		BitsPerSample: binary.LittleEndian.Uint16(data[14:16]),
+	}
+
+	// Extensible format stores the real format code at the start of the sub-format GUID
+	if f.AudioFormat == wavFormatExtensible {
+		if size < 26 {
+			return nil, errors.New("WAV extensible fmt chunk too small")
+		}
+		f.AudioFormat = binary.LittleEndian.Uint16(data[24:26])
+	}
+
+	if f.NumChannels == 0 {
+		return nil, errors.New("WAV file has no channels")
+	}
+
+	switch {
+	case f.AudioFormat == wavFormatPCM && (f.BitsPerSample == 8 || f.BitsPerSample == 16 ||
+		f.BitsPerSample == 24 || f.BitsPerSample == 32):
+	case f.AudioFormat == wavFormatIEEEFloat && f.BitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("unsupported WAV encoding: format %d with %d bits per sample",
+			f.AudioFormat, f.BitsPerSample)
+	}
+
+	return f, nil
+}
+
+// readWavData decodes a data chunk to 16-bit mono samples
+func readWavData(r io.Reader, size uint32, f *wavFormat) (*AudioData, error) {
+	bytesPerSample := int(f.BitsPerSample / 8)
+	frameSize := bytesPerSample * int(f.NumChannels)
+
+	// Some encoders write a placeholder size when streaming, read until EOF then
+	var reader io.Reader = r
+	var samples []int16
+	if size != 0 && size != math.MaxUint32 {
+		reader = io.LimitReader(r, int64(size))
+		samples = make([]int16, 0, min(int64(size), maxWavPrealloc)/int64(frameSize))
+	}
+
+	buffer := make([]byte, frameSize*1024)
+	for {
+		n, err := io.ReadFull(reader, buffer)
+		// Only whole frames are decoded, a trailing partial frame is dropped
+		for offset := 0; offset+frameSize <= n; offset += frameSize {
+			var sum int32
+			for ch := 0; ch < int(f.NumChannels); ch++ {
+				start := offset + ch*bytesPerSample
+				sum += int32(decodeWavSample(buffer[start:start+bytesPerSample], f.AudioFormat))
+			}
+			samples = append(samples, int16(sum/int32(f.NumChannels)))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WAV data: %v", err)
+		}
+	}
+
+	return &AudioData{
+		Samples:    samples,
+		SampleRate: int(f.SampleRate),
+	}, nil
+}
+
+// decodeWavSample converts one little-endian sample of any supported depth to int16
+func decodeWavSample(b []byte, audioFormat uint16) int16 {
+	switch len(b) {
+	case 1:
+		// 8-bit PCM is unsigned with a 128 midpoint
+		return int16(int(b[0])-128) << 8
+	case 2:
+		return int16(binary.LittleEndian.Uint16(b))
+	case 3:
+		// Sign-extend the 24-bit value, then keep the top 16 bits
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return int16(v >> 8)
+	default:
+		bits := binary.LittleEndian.Uint32(b)
+		if audioFormat == wavFormatIEEEFloat {
+			v := float64(math.Float32frombits(bits)) * 32767
+			if v > 32767 {
+				v = 32767
+			} else if v < -32767 {
+				v = -32767
+			}
+			return int16(v)
+		}
+		return int16(int32(bits) >> 16)
+	}
+}
@@ -0,0 +1,296 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildWav assembles a WAV file from raw sample bytes with the given encoding
+func buildWav(audioFormat, channels, bitsPerSample uint16, sampleRate uint32, data []byte) []byte {
+	var buf bytes.Buffer
+	blockAlign := channels * bitsPerSample / 8
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, audioFormat)
+	binary.Write(&buf, binary.LittleEndian, channels)
+	binary.Write(&buf, binary.LittleEndian, sampleRate)
+	binary.Write(&buf, binary.LittleEndian, sampleRate*uint32(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, blockAlign)
+	binary.Write(&buf, binary.LittleEndian, bitsPerSample)
+
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+// TestReadWavBitDepths verifies each supported sample depth converts to int16
+func TestReadWavBitDepths(t *testing.T) {
+	// Each case encodes full-scale positive, zero, and half-scale negative samples
+	tests := []struct {
+		name          string
+		audioFormat   uint16
+		bitsPerSample uint16
+		data          []byte
+	}{
+		{"8-bit unsigned", wavFormatPCM, 8, []byte{255, 128, 64}},
+		{"16-bit", wavFormatPCM, 16, []byte{0xFF, 0x7F, 0x00, 0x00, 0x00, 0xC0}},
+		{"24-bit", wavFormatPCM, 24, []byte{0xFF, 0xFF, 0x7F, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0}},
+		{"32-bit", wavFormatPCM, 32, []byte{0xFF, 0xFF, 0xFF, 0x7F, 0, 0, 0, 0, 0x00, 0x00, 0x00, 0xC0}},
+		{"32-bit float", wavFormatIEEEFloat, 32, func() []byte {
+			var b bytes.Buffer
+			for _, v := range []float32{1.0, 0, -0.5} {
+				binary.Write(&b, binary.LittleEndian, math.Float32bits(v))
+			}
+			return b.Bytes()
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wav := buildWav(tt.audioFormat, 1, tt.bitsPerSample, 16000, tt.data)
+			audio, err := ReadWav(bytes.NewReader(wav))
+			if err != nil {
+				t.Fatalf("Failed to read WAV: %v", err)
+			}
+
+			if audio.SampleRate != 16000 {
+				t.Errorf("Expected sample rate 16000, got %d", audio.SampleRate)
+			}
+			if len(audio.Samples) != 3 {
+				t.Fatalf("Expected 3 samples, got %d", len(audio.Samples))
+			}
+
+			// Allow for rounding differences between depths
+			expected := []int16{32767, 0, -16384}
+			for i, want := range expected {
+				diff := int(audio.Samples[i]) - int(want)
+				if diff < -256 || diff > 256 {
+					t.Errorf("Sample %d: expected ~%d, got %d", i, want, audio.Samples[i])
+				}
+			}
+		})
+	}
+}
+
+// TestReadWavStereoDownmix verifies multi-channel audio is averaged to mono
+func TestReadWavStereoDownmix(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{1000, 3000, -2000, 0})
+
+	audio, err := ReadWav(bytes.NewReader(buildWav(wavFormatPCM, 2, 16, 16000, data.Bytes())))
+	if err != nil {
+		t.Fatalf("Failed to read WAV: %v", err)
+	}
+
+	expected := []int16{2000, -1000}
+	if len(audio.Samples) != len(expected) {
+		t.Fatalf("Expected %d samples, got %d", len(expected), len(audio.Samples))
+	}
+	for i := range expected {
+		if audio.Samples[i] != expected[i] {
+			t.Errorf("Sample %d: expected %d, got %d", i, expected[i], audio.Samples[i])
+		}
+	}
+}
+
+// TestReadWavUnsupported verifies unsupported encodings are rejected clearly
+func TestReadWavUnsupported(t *testing.T) {
+	wav := buildWav(wavFormatIEEEFloat, 1, 64, 16000, make([]byte, 16))
+	if _, err := ReadWav(bytes.NewReader(wav)); err == nil {
+		t.Error("Expected an error for 64-bit float WAV")
+	}
+
+	if _, err := ReadWav(bytes.NewReader([]byte("not a wav file at all"))); err == nil {
+		t.Error("Expected an error for non-WAV input")
+	}
+}
+
+// TestReadWavCorruptSizes verifies chunk sizes from a corrupt or hostile
+// header neither crash the decoder nor size its allocations
+func TestReadWavCorruptSizes(t *testing.T) {
+	for _, size := range []uint32{math.MaxUint32, 1000} {
+		var wav bytes.Buffer
+		wav.WriteString("RIFF")
+		binary.Write(&wav, binary.LittleEndian, uint32(20))
+		wav.WriteString("WAVEfmt ")
+		binary.Write(&wav, binary.LittleEndian, size)
+		wav.Write(make([]byte, 8))
+		if _, err := ReadWav(&wav); err == nil {
+			t.Errorf("Expected an error for a %d byte fmt chunk", size)
+		}
+	}
+
+	// A truncated data chunk claiming almost 4GB decodes what's there
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{1, 2, 3, 4})
+	wav := buildWav(wavFormatPCM, 1, 16, 16000, data.Bytes())
+	binary.LittleEndian.PutUint32(wav[40:44], 0xFFFFFFF0)
+	audio, err := ReadWav(bytes.NewReader(wav))
+	if err != nil {
+		t.Fatalf("ReadWav failed: %v", err)
+	}
+	if len(audio.Samples) != 4 {
+		t.Errorf("Expected 4 samples, got %d", len(audio.Samples))
+	}
+	if cap(audio.Samples) > maxWavPrealloc {
+		t.Errorf("Expected at most %d samples preallocated, got %d", maxWavPrealloc, cap(audio.Samples))
+	}
+}
+
+// TestLoadWavFile verifies loading from disk
+func TestLoadWavFile(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{1, 2, 3, 4})
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := os.WriteFile(path, buildWav(wavFormatPCM, 1, 16, 8000, data.Bytes()), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	audio, err := LoadWavFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load WAV file: %v", err)
+	}
+	if audio.SampleRate != 8000 || len(audio.Samples) != 4 || audio.Samples[3] != 4 {
+		t.Errorf("Unexpected audio data: rate %d, samples %v", audio.SampleRate, audio.Samples)
+	}
+}
+
+// TestWriteWavRoundTrip verifies samples written by WriteWavFile read back unchanged
+func TestWriteWavRoundTrip(t *testing.T) {
+	samples := []int16{0, 1, -1, 32767, -32768, 1234, -4321}
+	path := filepath.Join(t.TempDir(), "roundtrip.wav")
+
+	if err := WriteWavFile(path, samples, 22050); err != nil {
+		t.Fatalf("WriteWavFile failed: %v", err)
+	}
+
+	audio, err := LoadWavFile(path)
+	if err != nil {
+		t.Fatalf("LoadWavFile failed: %v", err)
+	}
+	if audio.SampleRate != 22050 {
+		t.Errorf("Expected sample rate 22050, got %d", audio.SampleRate)
+	}
+	if len(audio.Samples) != len(samples) {
+		t.Fatalf("Expected %d samples, got %d", len(samples), len(audio.Samples))
+	}
+	for i, want := range samples {
+		if audio.Samples[i] != want {
+			t.Errorf("Sample %d: expected %d, got %d", i, want, audio.Samples[i])
+		}
+	}
+}
+
+// TestWavWriter verifies samples streamed in several writes produce the same
+// file as encoding them at once
+func TestWavWriter(t *testing.T) {
+	samples := make([]int16, wavChunkSamples*2+123)
+	for i := range samples {
+		samples[i] = int16(i * 7)
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "stream.wav"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+	writer, err := NewWavWriter(file, AudioFrequency)
+	if err != nil {
+		t.Fatalf("NewWavWriter failed: %v", err)
+	}
+	for _, part := range [][]int16{samples[:100], samples[100 : wavChunkSamples+500], samples[wavChunkSamples+500:]} {
+		if err := writer.Write(part); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	streamed, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	var encoded bytes.Buffer
+	if err := WriteWav(&encoded, samples, AudioFrequency); err != nil {
+		t.Fatalf("WriteWav failed: %v", err)
+	}
+	if !bytes.Equal(streamed, encoded.Bytes()) {
+		t.Error("Expected the streamed file to match the one encoded at once")
+	}
+}
+
+// TestSaveWavFileLong verifies recordings above the streaming threshold are saved whole
+func TestSaveWavFileLong(t *testing.T) {
+	samples := make([]int16, wavStreamThreshold+10)
+	samples[len(samples)-1] = 1234
+
+	path, err := saveWavFile(samples, WhisperSampleRate)
+	if err != nil {
+		t.Fatalf("saveWavFile failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	audio, err := LoadWavFile(path)
+	if err != nil {
+		t.Fatalf("LoadWavFile failed: %v", err)
+	}
+	if len(audio.Samples) != len(samples) || audio.Samples[len(samples)-1] != 1234 {
+		t.Errorf("Expected %d samples ending in 1234, got %d", len(samples), len(audio.Samples))
+	}
+}
+
+// TestCheckWavLength verifies audio whose sizes would wrap the header is rejected
+func TestCheckWavLength(t *testing.T) {
+	if err := checkWavLength(maxWavSamples, AudioFrequency); err != nil {
+		t.Errorf("Expected the longest WAV to be accepted, got %v", err)
+	}
+	err := checkWavLength(maxWavSamples+1, AudioFrequency)
+	if !errors.Is(err, ErrWavTooLarge) {
+		t.Fatalf("Expected ErrWavTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "37h16m") {
+		t.Errorf("Expected the duration in the error, got %v", err)
+	}
+}
+
+// TestReadWavPipe verifies a piped stream with placeholder sizes, as ffmpeg
+// writes to stdout, is read to EOF without seeking
+func TestReadWavPipe(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{100, -200, 300})
+
+	wav := buildWav(wavFormatPCM, 1, 16, 16000, data.Bytes())
+	binary.LittleEndian.PutUint32(wav[4:8], math.MaxUint32)   // RIFF size
+	binary.LittleEndian.PutUint32(wav[40:44], math.MaxUint32) // data size
+
+	// An io.Pipe reader has no Seek method
+	r, w := io.Pipe()
+	go func() {
+		w.Write(wav)
+		w.Close()
+	}()
+
+	audio, err := ReadWav(r)
+	if err != nil {
+		t.Fatalf("Failed to read piped WAV: %v", err)
+	}
+	if len(audio.Samples) != 3 || audio.Samples[2] != 300 {
+		t.Errorf("Expected 3 samples ending in 300, got %v", audio.Samples)
+	}
+}
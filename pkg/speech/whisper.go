@@ -2,23 +2,59 @@ package speech
 
 import (
 	"bytes"
-	"encoding/binary"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
+// Errors returned by Initialize and Transcribe, so callers can decide whether to retry, restart or give up
+var (
+	ErrServerNotFound    = errors.New("whisper-server executable not found")
+	ErrServerNotRunning  = errors.New("whisper server not running")
+	ErrTranscribeTimeout = errors.New("transcription request timed out")
+	ErrTransport         = errors.New("failed to reach whisper server")
+	ErrBadResponse       = errors.New("bad response from whisper server")
+	ErrServerExited      = errors.New("whisper server exited during startup")
+	ErrStartTimeout      = errors.New("whisper server not ready in time")
+	ErrPortInUse         = errors.New("whisper server port in use")
+	ErrServerGone        = errors.New("whisper server closed the connection mid-response")
+	ErrTranscribeFailed  = errors.New("transcription failed unexpectedly")
+	ErrInvalidHost       = errors.New("invalid whisper server host")
+	ErrEmptyAudio        = errors.New("no audio data to transcribe")
+)
+
+// Server readiness probing defaults. Large models on slow machines can take
+// tens of seconds to load before the server starts answering.
+const (
+	DefaultReadyTimeout       = 30 * time.Second
+	DefaultReadyProbeInterval = 100 * time.Millisecond
+
+	// Request shape of whisper.cpp's server
+	DefaultFileField     = "file"
+	DefaultInferencePath = "/inference"
+
+	// DefaultReconnectWindow is how long a refusing server gets to come back
+	// before it is considered gone rather than restarting
+	DefaultReconnectWindow = 5 * time.Second
+)
+
 // WhisperServerConfig contains configuration for the whisper server
 type WhisperServerConfig struct {
 	ModelPath      string  // Path to the whisper model file
@@ -37,23 +73,28 @@ type WhisperServerConfig struct {
 	InitialPrompt  string  // Initial prompt for the model
 	Temperature    float64 // Initial temperature for sampling
 	TemperatureInc float64 // Temperature increment for fallbacks
+
+	// GPU offload, both need a whisper-server built with CUDA, Metal or
+	// Vulkan support. Off by default.
+	GPULayers int  // Number of layers to offload to the GPU (-ngl), 0 disables
+	FlashAttn bool // Enable flash attention (--flash-attn)
 }
 
 // NewDefaultWhisperServerConfig creates a new WhisperServerConfig with default settings
 func NewDefaultWhisperServerConfig() *WhisperServerConfig {
 	// Use the user's home directory for the model path
-	homedir, _ := os.UserHomeDir()
-	
+	homedir := defaultBaseDir()
+
 	// Default paths
 	defaultModelPath := filepath.Join(homedir, "dev/whisper.cpp/models/ggml-large-v3-turbo.bin")
 	defaultServerPath := filepath.Join(homedir, "dev/whisper.cpp/build/bin/whisper-server")
-	
+
 	// Override defaults with environment variables if set
 	modelPath := getEnvOrDefault("WHISPER_MODEL", defaultModelPath)
 	serverPath := getEnvOrDefault("WHISPER_BIN", defaultServerPath)
 
 	return &WhisperServerConfig{
-		ModelPath:      modelPath, // Path to model (configurable via env var)
+		ModelPath:      modelPath,  // Path to model (configurable via env var)
 		ServerPath:     serverPath, // Path to whisper-server (configurable via env var)
 		Host:           "127.0.0.1",
 		Port:           8080,
@@ -72,6 +113,36 @@ func NewDefaultWhisperServerConfig() *WhisperServerConfig {
 	}
 }
 
+// defaultBaseDir returns the directory the default whisper.cpp paths are
+// relative to: the home directory, else the current user's home from the
+// system user database, else the working directory. The last two are logged
+// since the defaults are unlikely to exist there.
+func defaultBaseDir() string {
+	homedir, err := os.UserHomeDir()
+	if err == nil {
+		return homedir
+	}
+
+	// Only worth a warning when the defaults are actually used
+	explicit := os.Getenv("WHISPER_MODEL") != "" && os.Getenv("WHISPER_BIN") != ""
+
+	if u, userErr := user.Current(); userErr == nil && u.HomeDir != "" {
+		if !explicit {
+			log.Printf("Warning: %v, using %s for the default whisper.cpp paths. Set WHISPER_MODEL and WHISPER_BIN if they aren't there.", err, u.HomeDir)
+		}
+		return u.HomeDir
+	}
+
+	wd, wdErr := os.Getwd()
+	if wdErr != nil {
+		wd = "."
+	}
+	if !explicit {
+		log.Printf("Warning: %v and no user home found, default whisper.cpp paths are relative to %s. Set WHISPER_MODEL and WHISPER_BIN.", err, wd)
+	}
+	return wd
+}
+
 // getEnvOrDefault returns the value of the environment variable or the default value
 func getEnvOrDefault(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists && value != "" {
@@ -86,6 +157,28 @@ type WhisperServerResult struct {
 	Segments []WhisperSegment `json:"segments,omitempty"`
 	Language string           `json:"language,omitempty"`
 	Success  bool
+
+	// English translation of Text, set when both are requested
+	Translation string `json:"translation,omitempty"`
+
+	// Filled in by Transcribe rather than the server
+	Model              string           `json:"-"` // Path of the model that produced the result
+	AudioDuration      time.Duration    `json:"-"` // Length of the transcribed audio
+	ProcessingDuration time.Duration    `json:"-"` // Time the server took to respond
+	Timing             TranscribeTiming `json:"-"`
+}
+
+// TranscribeTiming breaks down where the time of a transcription request went
+type TranscribeTiming struct {
+	Encode    time.Duration // Writing the audio as a WAV file
+	Build     time.Duration // Preparing the request, whose body streams while sending
+	FirstByte time.Duration // Sending the request until the response headers arrive, mostly server compute
+	Decode    time.Duration // Reading and parsing the response
+}
+
+// String formats the timing as key=value fields
+func (t TranscribeTiming) String() string {
+	return fmt.Sprintf("encode=%v build=%v first_byte=%v decode=%v", t.Encode, t.Build, t.FirstByte, t.Decode)
 }
 
 // WhisperSegment represents a segment of transcribed audio
@@ -96,27 +189,145 @@ type WhisperSegment struct {
 	Text       string  `json:"text"`
 	Tokens     []int   `json:"tokens,omitempty"`
 	Confidence float64 `json:"confidence,omitempty"`
+	AvgLogprob float64 `json:"avg_logprob,omitempty"` // Reported by verbose_json
+}
+
+// RTF returns the real-time factor: processing time divided by audio length.
+// Below 1 the model keeps up with speech. Zero if either duration is unknown.
+func (r *WhisperServerResult) RTF() float64 {
+	if r.AudioDuration <= 0 || r.ProcessingDuration <= 0 {
+		return 0
+	}
+	return r.ProcessingDuration.Seconds() / r.AudioDuration.Seconds()
+}
+
+// Confidence returns the mean confidence of the result's segments, and false
+// if the server reported none
+func (r *WhisperServerResult) Confidence() (float64, bool) {
+	var sum float64
+	var n int
+	for _, segment := range r.Segments {
+		if segment.Confidence > 0 {
+			sum += segment.Confidence
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
 }
 
 // WhisperServerService handles transcription using a local whisper.cpp server
 type WhisperServerService struct {
-	config     *WhisperServerConfig
-	cmd        *exec.Cmd
-	serverURL  string
-	isRunning  bool
-	debugMode  DebugMode
-	mutex      sync.Mutex
-	startTime  time.Time
-	maxRetries int
+	config    *WhisperServerConfig
+	cmd       *exec.Cmd
+	serverURL string
+	isRunning bool
+	debugMode DebugMode
+	mutex     sync.Mutex
+	startTime time.Time
+
+	// Serializes recovery when requests find the server refusing connections
+	reconnectMu     sync.Mutex
+	reconnectWindow time.Duration
+	maxRetries      int
+	pid             int
+	exited          chan struct{} // Closed once the spawned process is gone
+
+	// Done once Cleanup starts, so a recovery in progress doesn't start a
+	// server during shutdown
+	closing     context.Context
+	stopClosing context.CancelFunc
+
+	// Periodic health check of the running server, disabled when zero
+	healthInterval time.Duration
+	healthStop     chan struct{}
+	health         ServerHealth
+
+	// Transcriptions in progress, which shutdown waits up to drainTimeout for
+	inFlight     sync.WaitGroup
+	drainTimeout time.Duration
+	draining     bool
+
+	// How long Initialize waits for the server to answer, and how often it checks
+	readyTimeout       time.Duration
+	readyProbeInterval time.Duration
+
+	// Persistent server mode: leave the server running on exit and
+	// reuse it on the next launch via a lockfile. attached is also set for
+	// a server found on the configured port.
+	persistent bool
+	attached   bool
+	lockPath   string
+
+	// Move to the next free port instead of sharing a taken one
+	autoPort bool
+
+	// Request shape, adjustable for other whisper-compatible servers
+	fileField     string
+	inferencePath string
+
+	// An already running server to use instead of spawning one
+	externalURL string
+
+	// OpenAI audio API instead of whisper.cpp's, and the credentials and
+	// model name it needs
+	openAI      bool
+	openAIModel string
+	apiKey      string
+
+	// Response handling
+	responseFormat string
+
+	// Appended to the whisper-server command line
+	extraArgs []string
+
+	// Post-processing options
+	autoCapitalize bool
+	derepeat       bool
+	maxRepeats     int
+
+	// Salvage the text of truncated or malformed JSON responses
+	lenientParse bool
+
+	// Also request an English translation alongside each transcript
+	bothTranslation bool
+
+	// Results with a mean confidence below this are re-run once with a wider
+	// beam search, zero disables the retry
+	minConfidence float64
+
+	// Called after each readiness probe while the server starts up
+	startupProgress func(attempt int, ready bool)
+
+	// Called with every successful transcription
+	resultHandlers []func(*WhisperServerResult)
+
+	// Progress of the current transcription in percent, -1 when unknown.
+	// Atomic because it is written from the server output copier goroutine.
+	progress atomic.Int32
 }
 
 // NewWhisperServerService creates a new WhisperServerService with default configuration
 func NewWhisperServerService() *WhisperServerService {
-	return &WhisperServerService{
-		config:     NewDefaultWhisperServerConfig(),
-		isRunning:  false,
-		maxRetries: 3,
+	s := &WhisperServerService{
+		config:             NewDefaultWhisperServerConfig(),
+		isRunning:          false,
+		maxRetries:         3,
+		readyTimeout:       DefaultReadyTimeout,
+		readyProbeInterval: DefaultReadyProbeInterval,
+		reconnectWindow:    DefaultReconnectWindow,
+		lockPath:           defaultServerLockPath(),
+		responseFormat:     ResponseFormatJSON,
+		fileField:          DefaultFileField,
+		inferencePath:      DefaultInferencePath,
+		openAIModel:        DefaultOpenAIModel,
+		maxRepeats:         DefaultMaxRepeats,
 	}
+	s.closing, s.stopClosing = context.WithCancel(context.Background())
+	s.progress.Store(-1)
+	return s
 }
 
 // WithConfig sets the configuration for the WhisperServerService
@@ -131,6 +342,417 @@ func (s *WhisperServerService) WithDebug(mode DebugMode) *WhisperServerService {
 	return s
 }
 
+// WithReadyTimeout sets how long Initialize waits for a newly started server to
+// answer before giving up and stopping it
+func (s *WhisperServerService) WithReadyTimeout(d time.Duration) *WhisperServerService {
+	s.readyTimeout = d
+	return s
+}
+
+// WithReadyProbeInterval sets how often Initialize checks whether the server is ready
+func (s *WhisperServerService) WithReadyProbeInterval(d time.Duration) *WhisperServerService {
+	s.readyProbeInterval = d
+	return s
+}
+
+// WithPersistentServer keeps the server running after conch exits and
+// attaches to it on the next launch instead of spawning a new one
+func (s *WhisperServerService) WithPersistentServer(enabled bool) *WhisperServerService {
+	s.persistent = enabled
+	return s
+}
+
+// SetInitialPrompt sets the prompt sent with each transcription request, used to
+// bias the model towards domain vocabulary. It can be changed mid-session.
+func (s *WhisperServerService) SetInitialPrompt(prompt string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config.InitialPrompt = prompt
+}
+
+// WithResponseFormat sets the response format requested from the server:
+// json (default), verbose_json, text, srt or vtt. Unknown formats are ignored.
+func (s *WhisperServerService) WithResponseFormat(format string) *WhisperServerService {
+	if !validResponseFormat(format) {
+		log.Printf("Ignoring unsupported response format %q", format)
+		return s
+	}
+	s.responseFormat = format
+	return s
+}
+
+// WithStartupProgress registers a callback invoked after each readiness probe in
+// Initialize, with ready set once the server answers. It is called with the
+// service lock held, so it must not call back into the service.
+func (s *WhisperServerService) WithStartupProgress(fn func(attempt int, ready bool)) *WhisperServerService {
+	s.startupProgress = fn
+	return s
+}
+
+// WithLanguage sets the spoken language as a code like "en", or LanguageAuto to detect it
+func (s *WhisperServerService) WithLanguage(language string) *WhisperServerService {
+	s.config.Language = language
+	return s
+}
+
+// OnResult registers fn to be called with every successful transcription,
+// including its metadata, for side effects like logging or forwarding. It runs
+// synchronously before Transcribe returns, so slow work belongs in a goroutine.
+func (s *WhisperServerService) OnResult(fn func(*WhisperServerResult)) *WhisperServerService {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resultHandlers = append(s.resultHandlers, fn)
+	return s
+}
+
+// notifyResult passes result to the registered result handlers
+func (s *WhisperServerService) notifyResult(result *WhisperServerResult) {
+	s.mutex.Lock()
+	handlers := s.resultHandlers
+	s.mutex.Unlock()
+
+	for _, fn := range handlers {
+		fn(result)
+	}
+}
+
+// sendInference posts a body from newBody to url, retrying up to maxRetries
+// times on transport errors with a fresh body each time
+func (s *WhisperServerService) sendInference(url string, newBody func() (io.ReadCloser, string)) (*http.Response, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	var err error
+	for attempt := 0; attempt < s.maxRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Retrying transcription request (attempt %d/%d)", attempt+1, s.maxRetries)
+			s.debugLog(DebugTranscribe, "Retrying request (attempt %d/%d)", attempt+1, s.maxRetries)
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+
+		body, contentType := newBody()
+		req, reqErr := http.NewRequest("POST", url, body)
+		if reqErr != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to create request: %v", reqErr)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if s.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+s.apiKey)
+		}
+
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+// reconnect recovers from a server that refuses connections. A server that is
+// merely restarting answers again within the reconnect window; one that doesn't
+// is restarted if we spawned it. External and attached servers can only be waited for.
+func (s *WhisperServerService) reconnect() error {
+	// Concurrent requests all see the refusal, only one should restart the server
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+
+	s.mutex.Lock()
+	url := s.serverURL
+	spawned := s.cmd != nil && !s.attached
+	s.mutex.Unlock()
+
+	deadline := time.Now().Add(s.reconnectWindow)
+	for time.Now().Before(deadline) {
+		if err := s.closing.Err(); err != nil {
+			return fmt.Errorf("reconnect abandoned: %w", err)
+		}
+		if serverHealthy(url) {
+			log.Printf("Whisper server is answering again")
+			return nil
+		}
+		time.Sleep(s.readyProbeInterval)
+	}
+
+	if !spawned {
+		return fmt.Errorf("server at %s not answering after %v", url, s.reconnectWindow)
+	}
+
+	log.Printf("Whisper server not answering after %v, restarting it", s.reconnectWindow)
+	return s.restartSpawned()
+}
+
+// restartSpawned stops the server we spawned and starts a new one. It stops
+// the process even once recoverServer has marked it not running, since a hung
+// server still holds the port. Nothing is started once Cleanup has begun. The
+// caller holds reconnectMu.
+func (s *WhisperServerService) restartSpawned() error {
+	s.mutex.Lock()
+	if err := s.closing.Err(); err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("restart abandoned: %w", err)
+	}
+	s.stopHealthCheck()
+	s.isRunning = false
+	cmd, exited := s.cmd, s.exited
+	s.mutex.Unlock()
+
+	stopProcess(cmd, exited)
+	return s.InitializeContext(s.closing)
+}
+
+// connectionClosed reports whether err means the server hung up mid-exchange,
+// which is how a crash while answering looks from the client
+func connectionClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// serverGone marks the server as not running after it dropped a request and
+// recovers it in the background
+func (s *WhisperServerService) serverGone(cause error) error {
+	s.recoverServer("Whisper server closed the connection mid-response")
+	return fmt.Errorf("%w: %v", ErrServerGone, cause)
+}
+
+// recoverServer marks the server as not running and recovers it in the
+// background, restarting it if we spawned it and it doesn't answer. Requests
+// fail with ErrServerNotRunning until it is back.
+func (s *WhisperServerService) recoverServer(reason string) {
+	s.mutex.Lock()
+	wasRunning := s.isRunning
+	s.isRunning = false
+	s.mutex.Unlock()
+
+	if !wasRunning {
+		return
+	}
+	log.Printf("%s, recovering it", reason)
+	go func() {
+		if err := s.reconnect(); err != nil {
+			log.Printf("Failed to recover whisper server: %v", err)
+			return
+		}
+		s.mutex.Lock()
+		if s.closing.Err() == nil {
+			s.isRunning = true
+		}
+		s.mutex.Unlock()
+	}()
+}
+
+// Config returns a copy of the configuration the server is started and queried with
+func (s *WhisperServerService) Config() WhisperServerConfig {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return *s.config
+}
+
+// DetectsLanguage reports whether the server is set to auto-detect the spoken language
+func (s *WhisperServerService) DetectsLanguage() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.config.Language == LanguageAuto
+}
+
+// WithTemperatureSchedule sets the decoding temperatures whisper falls back
+// through when a result fails its quality checks. whisper.cpp steps from the
+// first temperature by a fixed increment until it reaches 1.0, so the schedule
+// must be evenly spaced and, if it has more than one entry, end at 1.0. A
+// single entry disables fallback. Invalid schedules are ignored.
+//
+// Each fallback re-decodes the whole recording, so a longer schedule improves
+// accuracy on hard audio at the cost of latency; greedy decoding with no
+// fallback is fastest but can leave repetitions or gibberish in place.
+func (s *WhisperServerService) WithTemperatureSchedule(schedule []float64) *WhisperServerService {
+	if err := validateTemperatureSchedule(schedule); err != nil {
+		log.Printf("Ignoring temperature schedule %v: %v", schedule, err)
+		return s
+	}
+
+	s.config.Temperature = schedule[0]
+	s.config.TemperatureInc = 0
+	if len(schedule) > 1 {
+		s.config.TemperatureInc = schedule[1] - schedule[0]
+	}
+	return s
+}
+
+// WithMaxFallbacks caps how many times whisper retries at a higher temperature,
+// spacing the increments so the last fallback lands on 1.0. Zero disables
+// fallback. See WithTemperatureSchedule for the accuracy/latency tradeoff.
+func (s *WhisperServerService) WithMaxFallbacks(n int) *WhisperServerService {
+	if n <= 0 || s.config.Temperature >= 1.0 {
+		s.config.TemperatureInc = 0
+		return s
+	}
+	s.config.TemperatureInc = (1.0 - s.config.Temperature) / float64(n)
+	return s
+}
+
+// WithConfidenceRetry re-transcribes a clip once with double the beam size and
+// best-of candidates when its mean segment confidence is below minConfidence,
+// keeping whichever result scores higher. Zero disables the retry. Confidence
+// needs segment details, so json responses are upgraded to verbose_json.
+func (s *WhisperServerService) WithConfidenceRetry(minConfidence float64) *WhisperServerService {
+	s.minConfidence = minConfidence
+	return s
+}
+
+// validateTemperatureSchedule checks a schedule can be expressed as whisper's
+// starting temperature and increment
+func validateTemperatureSchedule(schedule []float64) error {
+	const epsilon = 1e-6
+
+	if len(schedule) == 0 {
+		return errors.New("schedule is empty")
+	}
+	for _, t := range schedule {
+		if t < 0 || t > 1.0+epsilon {
+			return fmt.Errorf("temperature %g outside [0, 1]", t)
+		}
+	}
+	if len(schedule) == 1 {
+		return nil
+	}
+
+	step := schedule[1] - schedule[0]
+	if step <= 0 {
+		return errors.New("temperatures must increase")
+	}
+	for i := 2; i < len(schedule); i++ {
+		if math.Abs(schedule[i]-schedule[i-1]-step) > epsilon {
+			return errors.New("temperatures must be evenly spaced")
+		}
+	}
+	if math.Abs(schedule[len(schedule)-1]-1.0) > epsilon {
+		return errors.New("whisper always falls back up to 1.0, so the schedule must end there")
+	}
+	return nil
+}
+
+// formatTemperature formats a temperature for a form field without losing
+// precision, rounding away float noise from derived increments like 0.95-0.9
+func formatTemperature(t float64) string {
+	return strconv.FormatFloat(math.Round(t*1e6)/1e6, 'g', -1, 64)
+}
+
+// WithAutoCapitalize applies rule-based sentence casing and punctuation to results
+func (s *WhisperServerService) WithAutoCapitalize(enabled bool) *WhisperServerService {
+	s.autoCapitalize = enabled
+	return s
+}
+
+// WithDerepeat collapses repetition loops whisper sometimes gets stuck in,
+// e.g. "thank you thank you thank you ...", to a single instance
+func (s *WhisperServerService) WithDerepeat(enabled bool) *WhisperServerService {
+	s.derepeat = enabled
+	return s
+}
+
+// WithDerepeatLimit sets how many times in a row a phrase may repeat before
+// WithDerepeat collapses it. The default is DefaultMaxRepeats. Limits below 1
+// would collapse every word, and are ignored.
+func (s *WhisperServerService) WithDerepeatLimit(maxRepeats int) *WhisperServerService {
+	if maxRepeats < 1 {
+		log.Printf("Ignoring derepeat limit %d: must be at least 1", maxRepeats)
+		return s
+	}
+	s.maxRepeats = maxRepeats
+	return s
+}
+
+// WithBothTranscriptAndTranslation makes each transcription also request an
+// English translation, returned in the result's Translation. The two requests
+// run concurrently but the server still does twice the work. Requests that set
+// TranscribeOptions.Translate themselves are sent as a single request.
+func (s *WhisperServerService) WithBothTranscriptAndTranslation(enabled bool) *WhisperServerService {
+	s.bothTranslation = enabled
+	return s
+}
+
+// WithLenientParse recovers whatever text it can from a JSON response that
+// fails to parse, e.g. one truncated by a server crash, instead of failing.
+// Recovered results are returned with Success false to mark them as partial.
+func (s *WhisperServerService) WithLenientParse(enabled bool) *WhisperServerService {
+	s.lenientParse = enabled
+	return s
+}
+
+// WithFileFieldName sets the multipart form field the audio is sent in,
+// for whisper-compatible servers that expect e.g. "audio_file"
+func (s *WhisperServerService) WithFileFieldName(name string) *WhisperServerService {
+	s.fileField = name
+	return s
+}
+
+// WithInferenceEndpoint sets the path transcription requests are posted to
+func (s *WhisperServerService) WithInferenceEndpoint(path string) *WhisperServerService {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.inferencePath = path
+	return s
+}
+
+// WithExtraServerArgs appends args to the whisper-server command line, for
+// server flags conch has no option for (e.g. --flash-attn, -ngl 99)
+func (s *WhisperServerService) WithExtraServerArgs(args []string) *WhisperServerService {
+	s.extraArgs = args
+	return s
+}
+
+// serverFlagNames maps the short spellings of the flags conch sets to their long form
+var serverFlagNames = map[string]string{
+	"-t":   "--threads",
+	"-m":   "--model",
+	"-pp":  "--print-progress",
+	"-ngl": "--gpu-layers",
+	"-fa":  "--flash-attn",
+}
+
+// canonicalFlag returns the long form of a server flag, dropping any =value
+func canonicalFlag(arg string) string {
+	name, _, _ := strings.Cut(arg, "=")
+	if long, ok := serverFlagNames[name]; ok {
+		return long
+	}
+	return name
+}
+
+// gpuOptionHint explains a startup failure caused by a server that doesn't
+// understand the GPU options, returning "" when they aren't the likely cause
+func (s *WhisperServerService) gpuOptionHint(output string) string {
+	if s.config.GPULayers <= 0 && !s.config.FlashAttn {
+		return ""
+	}
+	lower := strings.ToLower(output)
+	if !strings.Contains(lower, "unknown argument") && !strings.Contains(lower, "invalid argument") &&
+		!strings.Contains(lower, "-ngl") && !strings.Contains(lower, "flash-attn") {
+		return ""
+	}
+	return "the GPU layers and flash attention options need a whisper-server built with GPU support"
+}
+
+// checkExtraServerArgs rejects extra args that repeat a flag conch already
+// passes, since the server would silently take one of the two values
+func checkExtraServerArgs(args, extra []string) error {
+	managed := make(map[string]bool)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			managed[canonicalFlag(arg)] = true
+		}
+	}
+	for _, arg := range extra {
+		if flag := canonicalFlag(arg); strings.HasPrefix(arg, "-") && managed[flag] {
+			return fmt.Errorf("extra server arg %s conflicts with %s set by conch", arg, flag)
+		}
+	}
+	return nil
+}
+
 // debugLog logs a message if the specified debug mode is enabled
 func (s *WhisperServerService) debugLog(mode DebugMode, format string, args ...interface{}) {
 	if s.debugMode&mode != 0 {
@@ -140,19 +762,53 @@ func (s *WhisperServerService) debugLog(mode DebugMode, format string, args ...i
 
 // Initialize starts the whisper server and loads the model
 func (s *WhisperServerService) Initialize() error {
+	return s.InitializeContext(context.Background())
+}
+
+// InitializeContext is Initialize, abandoning the startup when ctx is done.
+// A server it started is killed, and the error wraps ctx.Err().
+func (s *WhisperServerService) InitializeContext(ctx context.Context) (err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	defer func() {
+		if err == nil {
+			s.startHealthCheck()
+		}
+	}()
 
 	if s.isRunning {
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("whisper server startup cancelled: %w", err)
+	}
+
+	if s.externalURL != "" {
+		s.useExternalServer()
+		return nil
+	}
+
+	if err := checkHost(s.config.Host); err != nil {
+		return err
+	}
+
+	// Reuse a server left running by a previous persistent session
+	if s.persistent && s.attachToPersistentServer() {
+		return nil
+	}
 
 	// Check if server executable exists
 	if _, err := os.Stat(s.config.ServerPath); err != nil {
-		return fmt.Errorf("whisper-server executable not found at %s: %v", s.config.ServerPath, err)
+		return fmt.Errorf("%w at %s: %v", ErrServerNotFound, s.config.ServerPath, err)
+	}
+
+	// A second server on a taken port can't bind, and the readiness probe
+	// would mistake the first one for it
+	if attached, err := s.claimPort(); attached || err != nil {
+		return err
 	}
 	// Construct server URL
-	s.serverURL = fmt.Sprintf("http://%s:%d", s.config.Host, s.config.Port)
+	s.serverURL = serverURLFor(s.config.Host, s.config.Port)
 
 	// Build command arguments - include model path directly in server startup
 	args := []string{
@@ -165,6 +821,16 @@ func (s *WhisperServerService) Initialize() error {
 	if s.config.PrintProgress {
 		args = append(args, "-pp")
 	}
+	if s.config.GPULayers > 0 {
+		args = append(args, "-ngl", strconv.Itoa(s.config.GPULayers))
+	}
+	if s.config.FlashAttn {
+		args = append(args, "--flash-attn")
+	}
+	if err := checkExtraServerArgs(args, s.extraArgs); err != nil {
+		return err
+	}
+	args = append(args, s.extraArgs...)
 
 	// Create and start the command
 	s.cmd = exec.Command(s.config.ServerPath, args...)
@@ -177,13 +843,19 @@ func (s *WhisperServerService) Initialize() error {
 		return fmt.Errorf("failed to create whisper server log file: %v", err)
 	}
 
-	// Write to both log file and buffer
 	var stderr, stdout bytes.Buffer
-	stderrWriter := io.MultiWriter(logFile, &stderr)
-	stdoutWriter := io.MultiWriter(logFile, &stdout)
-
-	s.cmd.Stdout = stdoutWriter
-	s.cmd.Stderr = stderrWriter
+	if s.persistent {
+		// A persistent server outlives us, so it gets its own process group (terminal
+		// signals don't reach it) and writes straight to the log file rather than
+		// through pipes that close when we exit
+		s.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		s.cmd.Stdout = logFile
+		s.cmd.Stderr = logFile
+	} else {
+		// Write to both log file and buffer, and watch for progress reports
+		s.cmd.Stdout = io.MultiWriter(logFile, &stdout, &progressWriter{progress: &s.progress})
+		s.cmd.Stderr = io.MultiWriter(logFile, &stderr, &progressWriter{progress: &s.progress})
+	}
 
 	// Start the server
 	if err := s.cmd.Start(); err != nil {
@@ -192,17 +864,24 @@ func (s *WhisperServerService) Initialize() error {
 	}
 
 	// Log process ID
-	log.Printf("Whisper server started with PID: %d", s.cmd.Process.Pid)
+	s.pid = s.cmd.Process.Pid
+	log.Printf("Whisper server started with PID: %d", s.pid)
 
 	// Mark as running
 	s.isRunning = true
 	s.startTime = time.Now()
 
-	// Monitor process in background
+	// Monitor process in background, exited is closed as soon as it is gone
+	// so the readiness loop below can tell a crash from a slow model load
+	exited := make(chan struct{})
+	s.exited = exited
+	cmd := s.cmd
 	go func() {
-		err := s.cmd.Wait()
+		err := cmd.Wait()
+		close(exited)
 		s.mutex.Lock()
-		if s.isRunning {
+		// A restart may already have replaced the process
+		if s.isRunning && s.cmd == cmd {
 			if err != nil {
 				log.Printf("Whisper server process exited with error: %v", err)
 				log.Printf("See whisper-server.log for details")
@@ -215,41 +894,147 @@ func (s *WhisperServerService) Initialize() error {
 		s.mutex.Unlock()
 	}()
 
-	// Wait for the server to be ready
-	s.debugLog(DebugTranscribe, "Waiting for server to be ready at %s", s.serverURL)
-	var serverReady bool
-	for i := 0; i < 30; i++ {
-		time.Sleep(100 * time.Millisecond)
+	// Wait for the server to be ready. Only the process exiting is a real failure,
+	// until the timeout a server that isn't answering yet is still loading.
+	s.debugLog(DebugTranscribe, "Waiting up to %v for server to be ready at %s", s.readyTimeout, s.serverURL)
+	deadline := time.NewTimer(s.readyTimeout)
+	defer deadline.Stop()
+	probe := time.NewTicker(s.readyProbeInterval)
+	defer probe.Stop()
+	probeClient := &http.Client{Timeout: time.Second}
 
-		// Check if the process is still running
-		if s.cmd.ProcessState != nil && s.cmd.ProcessState.Exited() {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-exited:
+			s.isRunning = false
 			errMsg := stderr.String()
 			if errMsg == "" {
 				errMsg = stdout.String()
 			}
-			return fmt.Errorf("whisper server exited unexpectedly: %s", errMsg)
+			if errMsg == "" {
+				errMsg = "see whisper-server.log for details"
+			}
+			if hint := s.gpuOptionHint(errMsg); hint != "" {
+				return fmt.Errorf("%w: %s (%s)", ErrServerExited, strings.TrimSpace(errMsg), hint)
+			}
+			return fmt.Errorf("%w: %s", ErrServerExited, errMsg)
+
+		case <-deadline.C:
+			// The monitor goroutine can't take the lock while we hold it,
+			// so stop the process directly rather than through Cleanup
+			s.isRunning = false
+			if err := s.cmd.Process.Kill(); err != nil {
+				log.Printf("Failed to kill whisper server: %v", err)
+			}
+			return fmt.Errorf("%w after %v", ErrStartTimeout, s.readyTimeout)
+
+		case <-ctx.Done():
+			s.isRunning = false
+			if err := s.cmd.Process.Kill(); err != nil {
+				log.Printf("Failed to kill whisper server: %v", err)
+			}
+			return fmt.Errorf("whisper server startup cancelled: %w", ctx.Err())
+
+		case <-probe.C:
 		}
 
 		// Try to connect to the server
-		_, err := http.Get(s.serverURL)
+		resp, err := probeClient.Get(s.serverURL)
 		if err == nil {
-			serverReady = true
+			resp.Body.Close()
+		}
+		serverReady := err == nil
+		if s.startupProgress != nil {
+			s.startupProgress(attempt, serverReady)
+		}
+		if serverReady {
 			s.debugLog(DebugTranscribe, "Whisper server ready after %v", time.Since(s.startTime))
 			break
 		}
 	}
 
-	if !serverReady {
-		s.Cleanup()
-		return fmt.Errorf("whisper server failed to start in time")
+	s.debugLog(DebugTranscribe, "Server ready with model: %s", s.config.ModelPath)
+
+	// Record the server so the next launch can attach to it
+	if s.persistent {
+		lock := &serverLock{URL: s.serverURL, PID: s.pid, ModelPath: s.config.ModelPath, ServerPath: s.config.ServerPath}
+		if err := writeServerLock(s.lockPath, lock); err != nil {
+			log.Printf("Warning: failed to write whisper server lockfile: %v", err)
+		}
 	}
+	return nil
+}
 
-	s.debugLog(DebugTranscribe, "Server ready with model: %s", s.config.ModelPath)
+// SwitchModel reconfigures the running server without a restart, loading
+// config.ModelPath through the server's /load endpoint. Inference defaults that
+// differ from the current configuration (threads, language, translate, initial
+// prompt) are sent along. Per-request settings take effect regardless since
+// Transcribe reads them from the config. If the server only accepts the model,
+// the server-wide settings (threads, translate) keep their current values and
+// need a restart to change.
+func (s *WhisperServerService) SwitchModel(config *WhisperServerConfig) error {
+	s.mutex.Lock()
+	if !s.isRunning {
+		s.mutex.Unlock()
+		return ErrServerNotRunning
+	}
+	fields := reloadFields(s.config, config)
+	s.mutex.Unlock()
+
+	dropped, err := s.loadModel(config.ModelPath, fields)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	updated := *config
+	if dropped {
+		updated.NumThreads = s.config.NumThreads
+		updated.Translate = s.config.Translate
+		if updated != *config {
+			log.Printf("Whisper server kept its threads and translate settings, restart it to apply threads=%d translate=%v",
+				config.NumThreads, config.Translate)
+		}
+	}
+	s.config = &updated
+	s.mutex.Unlock()
 	return nil
 }
 
-// loadModel loads a model into the running server
-func (s *WhisperServerService) loadModel(modelPath string) error {
+// reloadFields returns the /load form fields for the settings that changed
+func reloadFields(current, next *WhisperServerConfig) map[string]string {
+	fields := map[string]string{}
+	if next.NumThreads != current.NumThreads {
+		fields["threads"] = strconv.Itoa(next.NumThreads)
+	}
+	if next.Language != current.Language {
+		fields["language"] = next.Language
+	}
+	if next.Translate != current.Translate {
+		fields["translate"] = strconv.FormatBool(next.Translate)
+	}
+	if next.InitialPrompt != current.InitialPrompt {
+		fields["prompt"] = next.InitialPrompt
+	}
+	return fields
+}
+
+// loadModel loads a model into the running server along with any extra form
+// fields. If the server rejects the request, the reload is retried with the
+// model alone so an unsupported parameter doesn't fail the whole reload, and
+// dropped reports that the fields weren't applied.
+func (s *WhisperServerService) loadModel(modelPath string, fields map[string]string) (dropped bool, err error) {
+	err = s.postLoad(modelPath, fields)
+	if err == nil || len(fields) == 0 {
+		return false, err
+	}
+
+	log.Printf("Warning: whisper server rejected reload parameters %v (%v), retrying with the model only", fields, err)
+	return true, s.postLoad(modelPath, nil)
+}
+
+// postLoad sends a single /load request
+func (s *WhisperServerService) postLoad(modelPath string, fields map[string]string) error {
 	// Prepare the multipart form
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
@@ -259,9 +1044,11 @@ func (s *WhisperServerService) loadModel(modelPath string) error {
 		return fmt.Errorf("failed to write model path field: %v", err)
 	}
 
-	// Add other config parameters
-	if err := writer.WriteField("language", s.config.Language); err != nil {
-		return fmt.Errorf("failed to write language field: %v", err)
+	// Add the changed config parameters
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to write %s field: %v", name, err)
+		}
 	}
 
 	// Close the writer
@@ -297,177 +1084,465 @@ func (s *WhisperServerService) loadModel(modelPath string) error {
 	return nil
 }
 
-// saveWavFile saves audio data to a temporary WAV file
+// saveWavFile saves audio data to a temporary WAV file. Long recordings are
+// streamed in chunks rather than encoded in one go, which would need a second
+// copy of them in memory.
 func saveWavFile(samples []int16, sampleRate int) (string, error) {
-	// Create a temporary file
 	file, err := os.CreateTemp("", "whisper_*.wav")
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
-	// WAV header structure
-	type wavHeader struct {
-		ChunkID       [4]byte // "RIFF"
-		ChunkSize     uint32  // 36 + SubChunk2Size
-		Format        [4]byte // "WAVE"
-		SubChunk1ID   [4]byte // "fmt "
-		SubChunk1Size uint32  // 16 for PCM
-		AudioFormat   uint16  // 1 for PCM
-		NumChannels   uint16  // 1 for mono, 2 for stereo
-		SampleRate    uint32  // 16000, 44100, etc.
-		ByteRate      uint32  // SampleRate * NumChannels * BitsPerSample/8
-		BlockAlign    uint16  // NumChannels * BitsPerSample/8
-		BitsPerSample uint16  // 8, 16, etc.
-		SubChunk2ID   [4]byte // "data"
-		SubChunk2Size uint32  // NumSamples * NumChannels * BitsPerSample/8
-	}
-
-	// Calculate sizes
-	dataSize := uint32(len(samples) * 2) // 16-bit samples = 2 bytes per sample
-
-	// Create header
-	header := wavHeader{
-		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
-		ChunkSize:     36 + dataSize,
-		Format:        [4]byte{'W', 'A', 'V', 'E'},
-		SubChunk1ID:   [4]byte{'f', 'm', 't', ' '},
-		SubChunk1Size: 16,
-		AudioFormat:   1, // PCM
-		NumChannels:   1, // Mono
-		SampleRate:    uint32(sampleRate),
-		ByteRate:      uint32(sampleRate * 1 * 16 / 8),
-		BlockAlign:    2, // 1 channel * 16 bits per sample / 8
-		BitsPerSample: 16,
-		SubChunk2ID:   [4]byte{'d', 'a', 't', 'a'},
-		SubChunk2Size: dataSize,
-	}
-
-	// Write header
-	if err := binary.Write(file, binary.LittleEndian, header); err != nil {
+	if len(samples) <= wavStreamThreshold {
+		err = WriteWav(file, samples, sampleRate)
+	} else {
+		err = streamWav(file, samples, sampleRate)
+	}
+	if err != nil {
+		os.Remove(file.Name())
 		return "", err
 	}
+	return file.Name(), nil
+}
 
-	// Write audio data
-	if err := binary.Write(file, binary.LittleEndian, samples); err != nil {
-		return "", err
+// multipartBody streams a multipart form holding the WAV file at path in
+// field, followed by the fields writeFields adds. The file is read as the
+// request is sent, so the body is never held in memory.
+func multipartBody(path, field string, writeFields func(*multipart.Writer)) (io.ReadCloser, string) {
+	reader, pipe := io.Pipe()
+	writer := multipart.NewWriter(pipe)
+	go func() {
+		// Fails once the request gives up on the body and closes the reader
+		pipe.CloseWithError(writeMultipart(writer, path, field, writeFields))
+	}()
+	return reader, writer.FormDataContentType()
+}
+
+// writeMultipart writes the form for multipartBody
+func writeMultipart(writer *multipart.Writer, path, field string, writeFields func(*multipart.Writer)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open temporary file: %v", err)
 	}
+	defer file.Close()
 
-	return file.Name(), nil
+	part, err := writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %v", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy file data: %v", err)
+	}
+
+	writeFields(writer)
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %v", err)
+	}
+	return nil
+}
+
+// streamWav writes samples to file through a WavWriter
+func streamWav(file *os.File, samples []int16, sampleRate int) error {
+	writer, err := NewWavWriter(file, sampleRate)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(samples); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// TranscribeOptions overrides the service configuration for a single
+// transcription. Nil fields keep the configured value.
+type TranscribeOptions struct {
+	Language    *string  // Language code, or LanguageAuto to detect it
+	Temperature *float64 // Starting temperature; the fallback increment is kept
+	Prompt      *string  // Initial prompt, empty to send none
+	Translate   *bool    // Translate to English
+	BeamSize    *int     // Beam search width
+	BestOf      *int     // Candidates kept when sampling
+
+	// Ask for segment confidences, which needs a verbose_json response
+	WantConfidence bool
+}
+
+// transcribePanicked logs a panic recovered from a transcription with its
+// stack and returns it as an error wrapping ErrTranscribeFailed. A panic
+// during the request may have left it half done, so then the server is
+// checked and restarted if it doesn't answer. That runs in the background in
+// case the panic left the mutex held. A panic in a result handler leaves the
+// server alone.
+func (s *WhisperServerService) transcribePanicked(r any, duringRequest bool) error {
+	log.Printf("Transcription panicked: %v\n%s", r, debug.Stack())
+	if duringRequest {
+		go s.recoverServer("Transcription panicked")
+	}
+	return fmt.Errorf("%w: %v", ErrTranscribeFailed, r)
 }
 
 // Transcribe sends audio data to the whisper server for transcription
 func (s *WhisperServerService) Transcribe(audioData *AudioData) (*WhisperServerResult, error) {
+	return s.TranscribeWithOptions(audioData, TranscribeOptions{})
+}
+
+// transcribeAndTranslate runs a transcription and an English translation of
+// the same audio concurrently. A failed translation is logged and leaves the
+// transcript without one.
+func (s *WhisperServerService) transcribeAndTranslate(audioData *AudioData, opts TranscribeOptions) (*WhisperServerResult, error) {
+	original, translate := false, true
+	translateOpts := opts
+	opts.Translate, translateOpts.Translate = &original, &translate
+
+	type translation struct {
+		result *WhisperServerResult
+		err    error
+	}
+	translated := make(chan translation, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				translated <- translation{err: s.transcribePanicked(r, true)}
+			}
+		}()
+		result, err := s.transcribe(audioData, translateOpts)
+		translated <- translation{result, err}
+	}()
+
+	result, err := s.transcribeWithRetry(audioData, opts)
+	t := <-translated
+	if err != nil {
+		return nil, err
+	}
+	if t.err != nil {
+		log.Printf("Translation failed: %v", t.err)
+	} else {
+		result.Translation = strings.TrimSpace(t.result.Text)
+	}
+	return result, nil
+}
+
+// TranscribeWithOptions transcribes audio data like Transcribe, with opts
+// overlaid on the service configuration for just this request
+func (s *WhisperServerService) TranscribeWithOptions(audioData *AudioData, opts TranscribeOptions) (result *WhisperServerResult, err error) {
+	duringRequest := true
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, s.transcribePanicked(r, duringRequest)
+		}
+	}()
+	// Capture features like pre-roll, merging and cancelling can cut a
+	// recording down to nothing, and there is nothing to send then
+	if emptyAudio(audioData) {
+		return nil, ErrEmptyAudio
+	}
+	if err := s.beginTranscription(); err != nil {
+		return nil, err
+	}
+	defer s.inFlight.Done()
+
+	if s.bothTranslation && opts.Translate == nil {
+		result, err = s.transcribeAndTranslate(audioData, opts)
+	} else {
+		result, err = s.transcribeWithRetry(audioData, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	duringRequest = false
+	s.notifyResult(result)
+	return result, nil
+}
+
+// emptyAudio reports whether audioData has no samples to transcribe, or no
+// sample rate to interpret them with
+func emptyAudio(audioData *AudioData) bool {
+	return audioData == nil || len(audioData.Samples) == 0 || audioData.SampleRate <= 0
+}
+
+// transcribeWithRetry transcribes audioData, re-running a low confidence
+// result with a wider beam search if enabled
+func (s *WhisperServerService) transcribeWithRetry(audioData *AudioData, opts TranscribeOptions) (*WhisperServerResult, error) {
+	result, err := s.transcribe(audioData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.minConfidence > 0 {
+		if confidence, ok := result.Confidence(); ok && confidence < s.minConfidence {
+			result = s.retryLowConfidence(audioData, opts, result, confidence)
+		}
+	}
+	return result, nil
+}
+
+// retryLowConfidence re-runs a low-confidence transcription once with a wider
+// beam search and returns the better of the two results
+func (s *WhisperServerService) retryLowConfidence(audioData *AudioData, opts TranscribeOptions, first *WhisperServerResult, confidence float64) *WhisperServerResult {
+	s.mutex.Lock()
+	beamSize, bestOf := s.config.BeamSize, s.config.BestOf
+	s.mutex.Unlock()
+	if opts.BeamSize != nil {
+		beamSize = *opts.BeamSize
+	}
+	if opts.BestOf != nil {
+		bestOf = *opts.BestOf
+	}
+	beamSize, bestOf = max(beamSize, 1)*2, max(bestOf, 1)*2
+	opts.BeamSize, opts.BestOf = &beamSize, &bestOf
+
+	log.Printf("Confidence %.2f below %.2f, retrying with beam size %d", confidence, s.minConfidence, beamSize)
+	retry, err := s.transcribe(audioData, opts)
+	if err != nil {
+		log.Printf("Confidence retry failed, keeping the first result: %v", err)
+		return first
+	}
+
+	retryConfidence, _ := retry.Confidence()
+	if retryConfidence <= confidence {
+		return first
+	}
+	log.Printf("Confidence retry improved the score from %.2f to %.2f", confidence, retryConfidence)
+	return retry
+}
+
+// transcribe sends a single transcription request with opts overlaid on the config
+func (s *WhisperServerService) transcribe(audioData *AudioData, opts TranscribeOptions) (*WhisperServerResult, error) {
 	s.mutex.Lock()
 	if !s.isRunning {
 		s.mutex.Unlock()
-		return nil, errors.New("whisper server not running")
+		return nil, ErrServerNotRunning
 	}
+	prompt := s.config.InitialPrompt
+	language := s.config.Language
+	model := s.config.ModelPath
+	temperature := s.config.Temperature
+	temperatureInc := s.config.TemperatureInc
+	printProgress := s.config.PrintProgress
+	// A restart, e.g. by the health check, may move the server meanwhile
+	serverURL, pid := s.serverURL, s.pid
 	s.mutex.Unlock()
 
-	if audioData == nil || len(audioData.Samples) == 0 {
-		return nil, errors.New("no audio data to transcribe")
+	if opts.Language != nil {
+		language = *opts.Language
+	}
+	if opts.Temperature != nil {
+		temperature = *opts.Temperature
+	}
+	if opts.Prompt != nil {
+		prompt = *opts.Prompt
+	}
+
+	if emptyAudio(audioData) {
+		return nil, ErrEmptyAudio
+	}
+
+	// whisper.cpp only handles 16kHz input, so don't trust the capture rate
+	if audioData.SampleRate != WhisperSampleRate {
+		log.Printf("Warning: resampling audio from %d Hz to %d Hz for whisper", audioData.SampleRate, WhisperSampleRate)
+		audioData = &AudioData{
+			Samples:    resample(audioData.Samples, audioData.SampleRate, WhisperSampleRate),
+			SampleRate: WhisperSampleRate,
+		}
 	}
 
 	// Save audio to a temporary WAV file
+	var timing TranscribeTiming
+	stepStart := time.Now()
 	wavFile, err := saveWavFile(audioData.Samples, audioData.SampleRate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save audio data: %v", err)
+		return nil, fmt.Errorf("failed to save audio data: %w", err)
 	}
 	defer os.Remove(wavFile) // Clean up the temporary file when done
+	timing.Encode = time.Since(stepStart)
+	stepStart = time.Now()
 
 	s.debugLog(DebugTranscribe, "Saved audio to temporary file: %s", wavFile)
 
-	// Prepare the multipart form
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
-
-	// Add the file
-	file, err := os.Open(wavFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open temporary file: %v", err)
+	// Progress is only reported by the server when PrintProgress is enabled
+	if printProgress {
+		s.progress.Store(0)
 	}
-	defer file.Close()
+	defer s.progress.Store(-1)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(wavFile))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %v", err)
+	// Plain json carries only the text, so ask for the verbose form when the
+	// detected language or segment confidence is wanted
+	responseFormat := s.responseFormat
+	if (language == LanguageAuto || s.minConfidence > 0) && responseFormat == ResponseFormatJSON {
+		responseFormat = ResponseFormatVerboseJSON
 	}
-
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file data: %v", err)
+	if opts.WantConfidence {
+		responseFormat = ResponseFormatVerboseJSON
 	}
 
-	// Add other form fields
-	writer.WriteField("temperature", fmt.Sprintf("%.1f", s.config.Temperature))
-	writer.WriteField("temperature_inc", fmt.Sprintf("%.1f", s.config.TemperatureInc))
-	writer.WriteField("response_format", "json")
-
-	// Close the writer
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %v", err)
+	inferencePath := s.inferencePath
+	if s.openAI {
+		inferencePath = s.openAIPath(opts)
+	}
+	writeFields := func(writer *multipart.Writer) {
+		if s.openAI {
+			s.writeOpenAIFields(writer, language, prompt, responseFormat, temperature)
+			return
+		}
+		writer.WriteField("temperature", formatTemperature(temperature))
+		writer.WriteField("temperature_inc", formatTemperature(temperatureInc))
+		writer.WriteField("response_format", responseFormat)
+		writer.WriteField("language", language)
+		if prompt != "" {
+			writer.WriteField("prompt", prompt)
+		}
+		// Otherwise the server's --translate setting applies
+		if opts.Translate != nil {
+			writer.WriteField("translate", strconv.FormatBool(*opts.Translate))
+		}
+		if opts.BeamSize != nil {
+			writer.WriteField("beam_size", strconv.Itoa(*opts.BeamSize))
+		}
+		if opts.BestOf != nil {
+			writer.WriteField("best_of", strconv.Itoa(*opts.BestOf))
+		}
 	}
 
-	// Create the HTTP request
-	inferenceURL := fmt.Sprintf("%s/inference", s.serverURL)
-	req, err := http.NewRequest("POST", inferenceURL, &requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+	// Each attempt streams the file afresh, since a failed attempt consumes the body
+	newBody := func() (io.ReadCloser, string) {
+		return multipartBody(wavFile, s.fileField, writeFields)
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	timing.Build = time.Since(stepStart)
 
-	// Send the request
-	log.Printf("Sending transcription request to whisper server (PID: %d): %s", s.cmd.Process.Pid, inferenceURL)
+	inferenceURL := serverURL + inferencePath
+
+	log.Printf("Sending transcription request to whisper server (PID: %d): %s", pid, inferenceURL)
 	s.debugLog(DebugTranscribe, "Sending request to whisper server: %s", inferenceURL)
 	startTime := time.Now()
 
-	// Add retry logic
-	var resp *http.Response
-	var respErr error
+	resp, respErr := s.sendInference(inferenceURL, newBody)
 
-	for attempt := 0; attempt < s.maxRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("Retrying transcription request (attempt %d/%d)", attempt+1, s.maxRetries)
-			s.debugLog(DebugTranscribe, "Retrying request (attempt %d/%d)", attempt+1, s.maxRetries)
-			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
-		}
-
-		client := &http.Client{
-			Timeout: 30 * time.Second,
-		}
-		resp, respErr = client.Do(req)
-
-		if respErr == nil {
-			break
+	// Every attempt refused: the server is bouncing or gone. Wait for it to come
+	// back (or restart it if we own it) and give the request one more try.
+	if respErr != nil && errors.Is(respErr, syscall.ECONNREFUSED) {
+		log.Printf("Whisper server refused all connections, attempting to reconnect")
+		if err := s.reconnect(); err != nil {
+			return nil, fmt.Errorf("%w: server refused connections and could not be recovered: %v", ErrTransport, err)
 		}
+		// A restarted server may be on another port
+		s.mutex.Lock()
+		inferenceURL = s.serverURL + inferencePath
+		s.mutex.Unlock()
+		resp, respErr = s.sendInference(inferenceURL, newBody)
 	}
 
 	if respErr != nil {
-		return nil, fmt.Errorf("failed to send request after %d attempts: %v", s.maxRetries, respErr)
+		var netErr net.Error
+		if errors.As(respErr, &netErr) && netErr.Timeout() {
+			return nil, fmt.Errorf("%w after %d attempts: %v", ErrTranscribeTimeout, s.maxRetries, respErr)
+		}
+		if connectionClosed(respErr) {
+			return nil, s.serverGone(respErr)
+		}
+		return nil, fmt.Errorf("%w after %d attempts: %v", ErrTransport, s.maxRetries, respErr)
 	}
 	defer resp.Body.Close()
 
 	duration := time.Since(startTime)
+	timing.FirstByte = duration
 	log.Printf("Received response from whisper server after %v", duration)
 	s.debugLog(DebugTranscribe, "Received response from whisper server after %v", duration)
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("server returned error status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("%w: server returned error status %d: %s", ErrBadResponse, resp.StatusCode, string(body))
 	}
 
-	// Read and parse the response
-	var result WhisperServerResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		body, _ := io.ReadAll(resp.Body)
+	// Read and parse the response in the requested format
+	stepStart = time.Now()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if connectionClosed(err) {
+			return nil, s.serverGone(err)
+		}
+		return nil, fmt.Errorf("%w: failed to read server response: %v", ErrBadResponse, err)
+	}
+	result, err := parseResponse(responseFormat, body)
+	partial := false
+	if err != nil {
 		s.debugLog(DebugTranscribe, "Failed to parse response: %v\nBody: %s", err, string(body))
-		return nil, fmt.Errorf("failed to parse server response: %v", err)
+		text, ok := "", false
+		if s.lenientParse && (responseFormat == ResponseFormatJSON || responseFormat == ResponseFormatVerboseJSON) {
+			text, ok = recoverPartialText(body)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: failed to parse server response: %v", ErrBadResponse, err)
+		}
+		log.Printf("Warning: malformed server response (%v), recovered %d characters of partial text", err, len(text))
+		result = &WhisperServerResult{Text: text}
+		partial = true
+	}
+	timing.Decode = time.Since(stepStart)
+	result.Timing = timing
+	s.debugLog(DebugTranscribe, "Transcription timing: %v", timing)
+
+	if s.derepeat {
+		if text, collapsed := Derepeat(result.Text, s.maxRepeats); collapsed {
+			log.Printf("Collapsed a repetition loop in the transcription")
+			result.Text = text
+		}
 	}
+	if s.autoCapitalize {
+		result.Text = Capitalize(result.Text)
+	}
+
+	// Servers report the language as a full name, a code, or not at all
+	result.Language = languageCode(result.Language)
+
+	result.Model = model
+	result.AudioDuration = time.Duration(len(audioData.Samples)) * time.Second / time.Duration(audioData.SampleRate)
+	result.ProcessingDuration = duration
 
-	result.Success = true
+	result.Success = !partial
 	s.debugLog(DebugTranscribe, "Transcription result: %s", result.Text)
-	return &result, nil
+	return result, nil
+}
+
+// Progress returns the progress of the current transcription in percent,
+// or -1 if no transcription is running or the server doesn't report progress
+func (s *WhisperServerService) Progress() int {
+	return int(s.progress.Load())
+}
+
+// progressWriter scans whisper server output for progress reports
+// of the form "progress = 42%" and records the latest value
+type progressWriter struct {
+	progress *atomic.Int32
+	line     []byte
+}
+
+// Write implements io.Writer, buffering partial lines between calls
+func (w *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			w.line = append(w.line, b)
+			continue
+		}
+		w.parseLine(string(w.line))
+		w.line = w.line[:0]
+	}
+	return len(p), nil
+}
+
+// parseLine extracts a percentage from a single line of server output
+func (w *progressWriter) parseLine(line string) {
+	idx := strings.Index(line, "progress = ")
+	if idx < 0 {
+		return
+	}
+	value := strings.TrimSpace(line[idx+len("progress = "):])
+	value = strings.TrimSuffix(value, "%")
+	if percent, err := strconv.Atoi(value); err == nil && percent >= 0 && percent <= 100 {
+		w.progress.Store(int32(percent))
+	}
 }
 
 // IsRunning returns true if the server is running
@@ -477,6 +1552,62 @@ func (s *WhisperServerService) IsRunning() bool {
 	return s.isRunning
 }
 
+// stopProcess interrupts the server process, killing it if it doesn't exit
+// in time. exited is closed by the process monitor once it is gone, since the
+// monitor's Wait leaves nothing for a second Wait to wait for.
+func stopProcess(cmd *exec.Cmd, exited <-chan struct{}) {
+	if cmd == nil || cmd.Process == nil {
+		log.Println("No whisper server process to stop")
+		return
+	}
+	if exited == nil {
+		done := make(chan struct{})
+		go func() {
+			cmd.Wait()
+			close(done)
+		}()
+		exited = done
+	}
+
+	pid := cmd.Process.Pid
+	log.Printf("Terminating whisper server process (PID: %d)", pid)
+
+	// First attempt with SIGTERM
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		log.Printf("Failed to send interrupt signal: %v", err)
+	}
+
+	// Wait with short timeout for graceful shutdown
+	select {
+	case <-exited:
+		log.Println("Whisper server process exited normally")
+	case <-time.After(1 * time.Second):
+		log.Println("Whisper server did not exit gracefully, force killing...")
+
+		// Force kill with SIGKILL
+		if err := cmd.Process.Kill(); err != nil {
+			log.Printf("Failed to kill whisper server: %v", err)
+		} else {
+			log.Printf("Sent SIGKILL to whisper server process (PID: %d)", pid)
+		}
+
+		// Verify process is gone
+		select {
+		case <-exited:
+			log.Println("Whisper server process killed successfully")
+		case <-time.After(1 * time.Second):
+			// Use system kill as last resort
+			log.Println("Process not responding to SIGKILL, using system kill...")
+			killCmd := exec.Command("kill", "-9", fmt.Sprintf("%d", pid))
+			if err := killCmd.Run(); err != nil {
+				log.Printf("System kill failed: %v", err)
+			} else {
+				log.Printf("System kill sent to PID %d", pid)
+			}
+		}
+	}
+}
+
 // Name returns the service name for shutdown management
 func (s *WhisperServerService) Name() string {
 	return "WhisperServer"
@@ -484,73 +1615,43 @@ func (s *WhisperServerService) Name() string {
 
 // Shutdown implements the Shutdownable interface
 func (s *WhisperServerService) Shutdown() error {
-	return s.Cleanup()
+	return s.ShutdownContext(context.Background())
 }
 
-// Cleanup stops the whisper server and returns any error encountered
+// Cleanup stops the whisper server and returns any error encountered. A
+// server we spawned is stopped even while recoverServer has it marked not
+// running, since a hung one would otherwise outlive us holding the port.
 func (s *WhisperServerService) Cleanup() error {
-	// First check if we need to do anything
-	isRunning := s.IsRunning()
-	if !isRunning {
-		return nil
-	}
+	// Cancelled before taking the lock, which a restart's Initialize holds
+	// while it waits for the new server, so that it gives up
+	s.stopClosing()
 
-	log.Println("Stopping whisper server")
-
-	// Set running to false to prevent new operations
+	// Take the process, so only one of several concurrent calls stops it
 	s.mutex.Lock()
+	s.stopHealthCheck()
+	wasRunning := s.isRunning
 	s.isRunning = false
-	cmd := s.cmd
+	cmd, exited := s.cmd, s.exited
+	url, pid := s.serverURL, s.pid
+	keepRunning := s.persistent || s.attached
+	if !keepRunning {
+		s.cmd = nil
+	}
 	s.mutex.Unlock()
 
-	// Try termination if we have a process
-	if cmd != nil && cmd.Process != nil {
-		pid := cmd.Process.Pid
-		log.Printf("Terminating whisper server process (PID: %d)", pid)
-
-		// First attempt with SIGTERM
-		if err := cmd.Process.Signal(os.Interrupt); err != nil {
-			log.Printf("Failed to send interrupt signal: %v", err)
-		}
-
-		// Wait with short timeout for graceful shutdown
-		done := make(chan error, 1)
-		go func() {
-			done <- cmd.Wait()
-		}()
-
-		select {
-		case <-done:
-			log.Println("Whisper server process exited normally")
-		case <-time.After(1 * time.Second):
-			log.Println("Whisper server did not exit gracefully, force killing...")
-
-			// Force kill with SIGKILL
-			if err := cmd.Process.Kill(); err != nil {
-				log.Printf("Failed to kill whisper server: %v", err)
-			} else {
-				log.Printf("Sent SIGKILL to whisper server process (PID: %d)", pid)
-			}
-
-			// Verify process is gone
-			select {
-			case <-done:
-				log.Println("Whisper server process killed successfully")
-			case <-time.After(1 * time.Second):
-				// Use system kill as last resort
-				log.Println("Process not responding to SIGKILL, using system kill...")
-				killCmd := exec.Command("kill", "-9", fmt.Sprintf("%d", pid))
-				if err := killCmd.Run(); err != nil {
-					log.Printf("System kill failed: %v", err)
-				} else {
-					log.Printf("System kill sent to PID %d", pid)
-				}
-			}
+	// Never kill a server we attached to, or one meant to outlive us
+	if keepRunning {
+		if wasRunning {
+			log.Printf("Leaving whisper server running at %s (PID: %d) for reuse", url, pid)
 		}
-	} else {
-		log.Println("No whisper server process to stop")
+		return nil
+	}
+	if cmd == nil {
+		return nil
 	}
 
+	log.Println("Stopping whisper server")
+	stopProcess(cmd, exited)
 	log.Println("Whisper server shutdown complete")
 	return nil
 }
@@ -1,11 +1,21 @@
 package speech
 
 import (
-	"encoding/binary"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -24,11 +34,11 @@ func TestWhisperTranscription(t *testing.T) {
 
 	// Read the WAV file
 	t.Log("Reading WAV file...")
-	audioData, err := readWavFile(samplePath)
+	audioData, err := LoadWavFile(samplePath)
 	if err != nil {
 		t.Fatalf("Failed to read WAV file: %v", err)
 	}
-	t.Logf("Successfully read WAV file, got %d samples at %d Hz", 
+	t.Logf("Successfully read WAV file, got %d samples at %d Hz",
 		len(audioData.Samples), audioData.SampleRate)
 
 	// Create whisper service
@@ -37,19 +47,19 @@ func TestWhisperTranscription(t *testing.T) {
 	config := NewDefaultWhisperServerConfig()
 	t.Logf("Using model: %s", config.ModelPath)
 	t.Logf("Using server executable: %s", config.ServerPath)
-	
+
 	// Verify the server executable exists
 	if _, err := os.Stat(config.ServerPath); os.IsNotExist(err) {
 		t.Fatalf("Whisper server executable not found at: %s", config.ServerPath)
 	}
 	t.Log("Whisper server executable found")
-	
+
 	// Verify the model exists
 	if _, err := os.Stat(config.ModelPath); os.IsNotExist(err) {
 		t.Fatalf("Whisper model not found at: %s", config.ModelPath)
 	}
 	t.Log("Whisper model found")
-	
+
 	whisperSvc.WithConfig(config)
 
 	// Initialize the server
@@ -60,7 +70,7 @@ func TestWhisperTranscription(t *testing.T) {
 		t.Fatalf("Failed to initialize whisper server: %v", err)
 	}
 	t.Logf("Whisper server initialized in %v", time.Since(initStart))
-	
+
 	defer func() {
 		t.Log("Starting cleanup...")
 		cleanupStart := time.Now()
@@ -75,66 +85,1272 @@ func TestWhisperTranscription(t *testing.T) {
 	startTime := time.Now()
 	result, err := whisperSvc.Transcribe(audioData)
 	transcriptionTime := time.Since(startTime)
-	
+
 	if err != nil {
 		t.Fatalf("Failed to transcribe audio: %v", err)
 	}
 
 	// Expected text (partial match is fine)
 	expectedText := "ask not what your country can do for you"
-	
+
 	t.Logf("Transcription completed in %v", transcriptionTime)
 	t.Logf("Transcription result: %s", result.Text)
-	
+
 	if !strings.Contains(strings.ToLower(result.Text), strings.ToLower(expectedText)) {
-		t.Errorf("Transcription did not contain expected text.\nExpected to contain: %s\nGot: %s", 
+		t.Errorf("Transcription did not contain expected text.\nExpected to contain: %s\nGot: %s",
 			expectedText, result.Text)
 	}
 }
 
-// readWavFile reads a WAV file and returns AudioData
-func readWavFile(filePath string) (*AudioData, error) {
-	// Open the WAV file
-	file, err := os.Open(filePath)
+// TestProgressWriter verifies progress reports are parsed across partial writes
+func TestProgressWriter(t *testing.T) {
+	svc := NewWhisperServerService()
+	w := &progressWriter{progress: &svc.progress}
+
+	if svc.Progress() != -1 {
+		t.Fatalf("Expected initial progress -1, got %d", svc.Progress())
+	}
+
+	w.Write([]byte("whisper_print_progress_callback: progr"))
+	w.Write([]byte("ess = 35%\nsome other line\n"))
+	if svc.Progress() != 35 {
+		t.Errorf("Expected progress 35, got %d", svc.Progress())
+	}
+
+	w.Write([]byte("whisper_print_progress_callback: progress = 100%\n"))
+	if svc.Progress() != 100 {
+		t.Errorf("Expected progress 100, got %d", svc.Progress())
+	}
+
+	w.Write([]byte("progress = garbage\n"))
+	if svc.Progress() != 100 {
+		t.Errorf("Expected unparseable line to be ignored, got %d", svc.Progress())
+	}
+}
+
+// newTestServer starts a fake whisper server that records the fields of each
+// inference request, and a service pointed at it
+func newTestServer(t *testing.T, response string) (*WhisperServerService, map[string]string) {
+	fields := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse request form: %v", err)
+		}
+		for key, values := range r.MultipartForm.Value {
+			fields[key] = values[0]
+		}
+		if _, ok := r.MultipartForm.File["file"]; ok {
+			fields["file"] = "present"
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+	return svc, fields
+}
+
+// TestTranscribeStreamsBody verifies the request body is streamed from the WAV
+// file rather than built in memory with a known length, and arrives whole
+func TestTranscribeStreamsBody(t *testing.T) {
+	var contentLength int64
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Failed to read the uploaded file: %v", err)
+			return
+		}
+		received, _ = io.ReadAll(file)
+		w.Write([]byte(`{"text": "streamed"}`))
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+
+	samples := make([]int16, AudioFrequency)
+	for i := range samples {
+		samples[i] = int16(i)
+	}
+	result, err := svc.Transcribe(&AudioData{Samples: samples, SampleRate: AudioFrequency})
+	if err != nil || result.Text != "streamed" {
+		t.Fatalf("Expected the transcription to succeed, got %v, %v", result, err)
+	}
+	if contentLength != -1 {
+		t.Errorf("Expected a streamed body of unknown length, got Content-Length %d", contentLength)
+	}
+	audio, err := ReadWav(bytes.NewReader(received))
+	if err != nil || !reflect.DeepEqual(audio.Samples, samples) {
+		t.Errorf("Expected the recording to arrive intact, got error %v", err)
+	}
+}
+
+// TestTranscribeSendsPrompt verifies the initial prompt reaches the request body
+func TestTranscribeSendsPrompt(t *testing.T) {
+	svc, fields := newTestServer(t, `{"text": "kubectl apply"}`)
+	svc.SetInitialPrompt("kubectl, kubernetes, helm")
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	result, err := svc.Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if result.Text != "kubectl apply" {
+		t.Errorf("Unexpected result text: %q", result.Text)
+	}
+
+	if fields["prompt"] != "kubectl, kubernetes, helm" {
+		t.Errorf("Expected prompt in request, got fields %v", fields)
+	}
+	if fields["file"] != "present" {
+		t.Errorf("Expected audio file in request, got fields %v", fields)
+	}
+}
+
+// TestTranscribeRequestShape verifies the configured field name and endpoint are used
+func TestTranscribeRequestShape(t *testing.T) {
+	var path string
+	var files []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse request form: %v", err)
+		}
+		for key := range r.MultipartForm.File {
+			files = append(files, key)
+		}
+		w.Write([]byte(`{"text": "hello"}`))
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService().WithFileFieldName("audio_file").WithInferenceEndpoint("v1/transcribe")
+	svc.serverURL = server.URL
+	svc.isRunning = true
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if path != "/v1/transcribe" {
+		t.Errorf("Expected request to /v1/transcribe, got %s", path)
+	}
+	if !reflect.DeepEqual(files, []string{"audio_file"}) {
+		t.Errorf("Expected audio in the audio_file field, got %v", files)
+	}
+}
+
+// TestTranscribeOpenAICompatible verifies requests to an OpenAI-compatible
+// server use its endpoints, field names and API key, and that its response
+// shape is understood
+func TestTranscribeOpenAICompatible(t *testing.T) {
+	var paths []string
+	var auth string
+	fields := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse request form: %v", err)
+		}
+		for key, values := range r.MultipartForm.Value {
+			fields[key] = values[0]
+		}
+		w.Write([]byte(`{"task": "transcribe", "language": "english", "duration": 1.0, "text": "hello there",
+			"segments": [{"id": 0, "seek": 0, "start": 0.0, "end": 1.0, "text": "hello there", "tokens": [1, 2],
+			"temperature": 0.0, "avg_logprob": -0.1, "compression_ratio": 1.2, "no_speech_prob": 0.01}]}`))
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService().
+		WithServerURL(server.URL + "/").
+		WithOpenAICompatible(true).
+		WithOpenAIModel("Systran/faster-whisper-small").
+		WithAPIKey("secret").
+		WithLanguage(LanguageAuto)
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer svc.Cleanup()
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	result, err := svc.Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if result.Text != "hello there" || result.Language != "en" || len(result.Segments) != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the API key as a bearer token, got %q", auth)
+	}
+	want := map[string]string{"model": "Systran/faster-whisper-small", "response_format": ResponseFormatVerboseJSON, "temperature": "0"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected fields %v, got %v", want, fields)
+	}
+
+	translate := true
+	if _, err := svc.TranscribeWithOptions(audio, TranscribeOptions{Translate: &translate}); err != nil {
+		t.Fatalf("Translation failed: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{OpenAITranscriptionPath, OpenAITranslationPath}) {
+		t.Errorf("Expected the transcription and translation endpoints, got %v", paths)
+	}
+}
+
+// TestTranscribeTiming verifies the time to the response is attributed to
+// the server rather than the client steps
+func TestTranscribeTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"text": "hello"}`))
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	result, err := svc.Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	timing := result.Timing
+	if timing.FirstByte < 50*time.Millisecond {
+		t.Errorf("Expected at least 50ms to the first byte, got %v", timing.FirstByte)
+	}
+	if timing.Encode <= 0 || timing.Encode >= timing.FirstByte {
+		t.Errorf("Expected a short encode time, got %v", timing.Encode)
+	}
+	if !strings.Contains(timing.String(), "first_byte=") {
+		t.Errorf("Expected key=value fields, got %q", timing.String())
+	}
+}
+
+// TestTranscribePanic verifies a panic during a transcription is returned as
+// ErrTranscribeFailed rather than crashing
+func TestTranscribePanic(t *testing.T) {
+	svc, _ := newTestServer(t, `{"text": "hello"}`)
+	var panicked atomic.Bool
+	svc.OnResult(func(*WhisperServerResult) {
+		if !panicked.Swap(true) {
+			panic("handler bug")
+		}
+	})
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); !errors.Is(err, ErrTranscribeFailed) {
+		t.Errorf("Expected ErrTranscribeFailed, got %v", err)
+	}
+
+	// The handler's bug says nothing about the server, so it stays usable
+	if !svc.IsRunning() {
+		t.Error("Expected the server to stay running after a handler panic")
+	}
+	if result, err := svc.Transcribe(audio); err != nil || result.Text != "hello" {
+		t.Errorf("Expected the next transcription to succeed, got %v, %v", result, err)
+	}
+}
+
+// TestTranscribeDerepeat verifies a looped result is collapsed when enabled
+func TestTranscribeDerepeat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text": " Bye. Thank you. Thank you. Thank you. Thank you. Thank you."}`))
+	}))
+	defer server.Close()
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	for _, enabled := range []bool{false, true} {
+		svc := NewWhisperServerService().WithDerepeat(enabled)
+		svc.serverURL = server.URL
+		svc.isRunning = true
+
+		result, err := svc.Transcribe(audio)
+		if err != nil {
+			t.Fatalf("Transcribe failed: %v", err)
+		}
+		if collapsed := result.Text == "Bye. Thank you."; collapsed != enabled {
+			t.Errorf("With derepeat %v, got %q", enabled, result.Text)
+		}
+	}
+}
+
+// TestTranscribeAndTranslate verifies both requests are made and combined
+func TestTranscribeAndTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse request form: %v", err)
+		}
+		if r.FormValue("translate") == "true" {
+			w.Write([]byte(`{"text": " good morning"}`))
+		} else {
+			w.Write([]byte(`{"text": "guten Morgen"}`))
+		}
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService().WithBothTranscriptAndTranslation(true)
+	svc.serverURL = server.URL
+	svc.isRunning = true
+	var notified *WhisperServerResult
+	svc.OnResult(func(r *WhisperServerResult) { notified = r })
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	result, err := svc.Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if result.Text != "guten Morgen" || result.Translation != "good morning" {
+		t.Errorf("Expected transcript and translation, got %q and %q", result.Text, result.Translation)
+	}
+	if notified == nil || notified.Translation == "" {
+		t.Error("Expected result hooks to see the translation")
+	}
+}
+
+// TestTranscribeWithOptions verifies per-request options override the config
+// for that request only
+func TestTranscribeWithOptions(t *testing.T) {
+	svc, fields := newTestServer(t, `{"text": "bonjour"}`)
+	svc.SetInitialPrompt("glossary")
+
+	language, temperature, prompt, translate := "fr", 0.4, "", true
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	_, err := svc.TranscribeWithOptions(audio, TranscribeOptions{
+		Language:    &language,
+		Temperature: &temperature,
+		Prompt:      &prompt,
+		Translate:   &translate,
+	})
+	if err != nil {
+		t.Fatalf("TranscribeWithOptions failed: %v", err)
+	}
+
+	want := map[string]string{"language": "fr", "temperature": "0.4", "translate": "true"}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("Expected %s=%s, got fields %v", key, value, fields)
+		}
+	}
+	if _, ok := fields["prompt"]; ok {
+		t.Errorf("Expected the prompt to be cleared, got %q", fields["prompt"])
+	}
+
+	// The defaults are untouched for the next request
+	for key := range fields {
+		delete(fields, key)
+	}
+	if _, err := svc.Transcribe(audio); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if fields["language"] != "en" || fields["prompt"] != "glossary" || fields["temperature"] != "0" {
+		t.Errorf("Expected configured defaults, got fields %v", fields)
+	}
+	if _, ok := fields["translate"]; ok {
+		t.Errorf("Expected no translate field without an override, got fields %v", fields)
+	}
+}
+
+// TestConfidenceRetry verifies a low-confidence result is retried once with a
+// wider beam and the better result kept
+func TestConfidenceRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retryProb float64
+		wantText  string
+	}{
+		{"improved", -0.1, "retried"},
+		{"worse", -3, "first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseMultipartForm(1 << 20)
+				beam := r.FormValue("beam_size")
+				requests = append(requests, beam)
+				if beam == "" {
+					w.Write([]byte(`{"text": "first", "segments": [{"text": "first", "avg_logprob": -2}]}`))
+					return
+				}
+				fmt.Fprintf(w, `{"text": "retried", "segments": [{"text": "retried", "avg_logprob": %v}]}`, tt.retryProb)
+			}))
+			t.Cleanup(server.Close)
+
+			svc := NewWhisperServerService().WithConfidenceRetry(0.5)
+			svc.serverURL = server.URL
+			svc.isRunning = true
+
+			audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+			result, err := svc.Transcribe(audio)
+			if err != nil {
+				t.Fatalf("Transcribe failed: %v", err)
+			}
+			if result.Text != tt.wantText {
+				t.Errorf("Expected %q, got %q", tt.wantText, result.Text)
+			}
+
+			// The default beam size of 5 is doubled, and only one retry is made
+			if len(requests) != 2 || requests[1] != "10" {
+				t.Errorf("Expected one retry with beam size 10, got requests %q", requests)
+			}
+		})
+	}
+}
+
+// TestOnResult verifies result handlers see each successful transcription with its metadata
+func TestOnResult(t *testing.T) {
+	svc, _ := newTestServer(t, `{"text": "hello"}`)
+	svc.config.ModelPath = "/models/ggml-tiny.bin"
+
+	var results []*WhisperServerResult
+	svc.OnResult(func(result *WhisperServerResult) {
+		results = append(results, result)
+	})
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency*2), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+
+	// Failed transcriptions aren't reported
+	svc.Transcribe(&AudioData{SampleRate: AudioFrequency})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected one result, got %d", len(results))
+	}
+	result := results[0]
+	if result.Text != "hello" || result.Model != "/models/ggml-tiny.bin" || result.AudioDuration != 2*time.Second {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.ProcessingDuration <= 0 {
+		t.Errorf("Expected a processing time, got %v", result.ProcessingDuration)
+	}
+}
+
+// TestResultRTF verifies the real-time factor and its unknown case
+func TestResultRTF(t *testing.T) {
+	result := &WhisperServerResult{AudioDuration: 5 * time.Second, ProcessingDuration: 2 * time.Second}
+	if rtf := result.RTF(); rtf != 0.4 {
+		t.Errorf("Expected RTF 0.4, got %v", rtf)
+	}
+	if rtf := (&WhisperServerResult{ProcessingDuration: time.Second}).RTF(); rtf != 0 {
+		t.Errorf("Expected RTF 0 without audio duration, got %v", rtf)
+	}
+}
+
+// TestTranscribeLenientParse verifies a truncated response is salvaged only when enabled
+func TestTranscribeLenientParse(t *testing.T) {
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+
+	svc, _ := newTestServer(t, `{"text": "a long dictation that was cut`)
+	if _, err := svc.Transcribe(audio); !errors.Is(err, ErrBadResponse) {
+		t.Errorf("Expected ErrBadResponse without lenient parsing, got %v", err)
+	}
+
+	result, err := svc.WithLenientParse(true).Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if result.Text != "a long dictation that was cut" || result.Success {
+		t.Errorf("Expected partial text with Success false, got %+v", result)
+	}
+}
+
+// TestDefaultConfigWithoutHome verifies the default paths stay absolute when HOME is unset
+func TestDefaultConfigWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("WHISPER_MODEL", "")
+	t.Setenv("WHISPER_BIN", "")
+
+	config := NewDefaultWhisperServerConfig()
+	if !filepath.IsAbs(config.ModelPath) || !filepath.IsAbs(config.ServerPath) {
+		t.Errorf("Expected absolute default paths, got %q and %q", config.ModelPath, config.ServerPath)
+	}
+}
+
+// newFakeServerService returns a service whose server binary is a shell script,
+// with the server log written to a temp directory and a port nothing listens on
+func newFakeServerService(t *testing.T, script string) *WhisperServerService {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "whisper-server")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake server: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := NewDefaultWhisperServerConfig()
+	config.ServerPath = path
+	config.Port = port
+	return NewWhisperServerService().WithConfig(config).WithReadyProbeInterval(10 * time.Millisecond)
+}
+
+// TestInitializeServerExited verifies a crashing server fails fast instead of waiting out the timeout
+func TestInitializeServerExited(t *testing.T) {
+	svc := newFakeServerService(t, "echo 'failed to load model' >&2; exit 1").WithReadyTimeout(10 * time.Second)
+
+	start := time.Now()
+	err := svc.Initialize()
+	if !errors.Is(err, ErrServerExited) {
+		t.Fatalf("Expected ErrServerExited, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "failed to load model") {
+		t.Errorf("Expected server output in error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected exit to be detected before the timeout, took %v", elapsed)
+	}
+	if svc.IsRunning() {
+		t.Error("Expected service not to be running after the server exited")
+	}
+}
+
+// TestInitializeReadyTimeout verifies a server that never answers is stopped after the timeout
+func TestInitializeReadyTimeout(t *testing.T) {
+	svc := newFakeServerService(t, "exec sleep 30").WithReadyTimeout(200 * time.Millisecond)
+
+	var attempts int
+	svc.WithStartupProgress(func(attempt int, ready bool) {
+		attempts = attempt
+		if ready {
+			t.Error("Expected server never to report ready")
+		}
+	})
+
+	err := svc.Initialize()
+	if !errors.Is(err, ErrStartTimeout) {
+		t.Fatalf("Expected ErrStartTimeout, got %v", err)
+	}
+	if attempts == 0 {
+		t.Error("Expected readiness to be probed before timing out")
+	}
+	if svc.IsRunning() {
+		t.Error("Expected service not to be running after the timeout")
+	}
+}
+
+// TestInitializeContextCancelled verifies cancelling the context stops the
+// server promptly instead of waiting out the ready timeout
+func TestInitializeContextCancelled(t *testing.T) {
+	svc := newFakeServerService(t, "exec sleep 30").WithReadyTimeout(10 * time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := svc.InitializeContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a cancelled error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected startup to stop when cancelled, took %v", elapsed)
+	}
+	if svc.IsRunning() {
+		t.Error("Expected service not to be running after cancelling")
+	}
+	if err := svc.InitializeContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a done context to fail before starting, got %v", err)
+	}
+}
+
+// TestExtraServerArgs verifies extra args reach the server and conflicting ones are rejected
+func TestExtraServerArgs(t *testing.T) {
+	svc := newFakeServerService(t, "echo \"args: $*\" >&2; exit 1").
+		WithExtraServerArgs([]string{"--flash-attn", "-ngl", "99"})
+	err := svc.Initialize()
+	if !errors.Is(err, ErrServerExited) {
+		t.Fatalf("Expected ErrServerExited, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "--flash-attn -ngl 99") {
+		t.Errorf("Expected extra args on the command line, got %v", err)
+	}
+
+	for _, args := range [][]string{{"-t", "8"}, {"--port=9000"}, {"--model", "other.bin"}, {"--gpu-layers", "10"}} {
+		svc := newFakeServerService(t, "exit 1").WithExtraServerArgs(args)
+		svc.config.GPULayers = 20
+		if err := svc.Initialize(); err == nil || !strings.Contains(err.Error(), "conflicts") {
+			t.Errorf("Expected %v to be rejected as a conflict, got %v", args, err)
+		}
+	}
+}
+
+// TestGPUOptions verifies the GPU options reach the server and a server
+// without GPU support gets an explanation
+func TestGPUOptions(t *testing.T) {
+	svc := newFakeServerService(t, "echo \"error: unknown argument: $*\" >&2; exit 1")
+	svc.config.GPULayers = 99
+	svc.config.FlashAttn = true
+
+	err := svc.Initialize()
+	if !errors.Is(err, ErrServerExited) {
+		t.Fatalf("Expected ErrServerExited, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "-ngl 99 --flash-attn") {
+		t.Errorf("Expected GPU flags on the command line, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "GPU support") {
+		t.Errorf("Expected a hint about GPU support, got %v", err)
+	}
+}
+
+// TestSwitchModelSendsChangedFields verifies only changed settings accompany the reload
+func TestSwitchModelSendsChangedFields(t *testing.T) {
+	svc, fields := newTestServer(t, `{}`)
+
+	config := *svc.config
+	config.ModelPath = "/models/ggml-base.en.bin"
+	config.Translate = true
+	config.InitialPrompt = "glossary"
+
+	if err := svc.SwitchModel(&config); err != nil {
+		t.Fatalf("SwitchModel failed: %v", err)
+	}
+
+	want := map[string]string{"model": "/models/ggml-base.en.bin", "translate": "true", "prompt": "glossary"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected fields %v, got %v", want, fields)
+	}
+	if svc.config.ModelPath != config.ModelPath {
+		t.Errorf("Expected config to be updated after the switch")
+	}
+}
+
+// TestSwitchModelRetriesWithoutRejectedFields verifies a rejected parameter doesn't fail the reload
+func TestSwitchModelRetriesWithoutRejectedFields(t *testing.T) {
+	var requests []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		requests = append(requests, len(r.MultipartForm.Value))
+		if len(r.MultipartForm.Value) > 1 {
+			http.Error(w, "unknown parameter", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+
+	config := *svc.config
+	config.ModelPath = "/models/ggml-base.en.bin"
+	config.NumThreads = svc.config.NumThreads * 2
+	config.Language = "fr"
+
+	if err := svc.SwitchModel(&config); err != nil {
+		t.Fatalf("Expected reload to succeed without the rejected field, got %v", err)
+	}
+	if !reflect.DeepEqual(requests, []int{3, 1}) {
+		t.Errorf("Expected a full request then a model-only retry, got field counts %v", requests)
+	}
+	if svc.config.ModelPath != config.ModelPath || svc.config.Language != "fr" {
+		t.Errorf("Expected the model and per-request language recorded, got %q, %q", svc.config.ModelPath, svc.config.Language)
+	}
+	if svc.config.NumThreads == config.NumThreads {
+		t.Errorf("Expected the thread count the server never applied not to be recorded")
+	}
+}
+
+// TestTemperatureSchedule verifies schedules map to whisper's temperature fields
+func TestTemperatureSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule []float64
+		wantTemp string
+		wantInc  string
+	}{
+		{"quarter steps", []float64{0, 0.25, 0.5, 0.75, 1.0}, "0", "0.25"},
+		{"fine steps", []float64{0.9, 0.95, 1.0}, "0.9", "0.05"},
+		{"no fallback", []float64{0.05}, "0.05", "0"},
+		{"uneven is ignored", []float64{0, 0.1, 1.0}, "0", "0.2"},
+		{"not ending at 1.0 is ignored", []float64{0, 0.2, 0.4}, "0", "0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, fields := newTestServer(t, `{"text": ""}`)
+			svc.WithTemperatureSchedule(tt.schedule)
+
+			audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+			if _, err := svc.Transcribe(audio); err != nil {
+				t.Fatalf("Transcribe failed: %v", err)
+			}
+
+			if fields["temperature"] != tt.wantTemp || fields["temperature_inc"] != tt.wantInc {
+				t.Errorf("Expected temperature %s inc %s, got %s inc %s",
+					tt.wantTemp, tt.wantInc, fields["temperature"], fields["temperature_inc"])
+			}
+		})
+	}
+}
+
+// TestDerepeatLimit verifies limits below 1 are ignored
+func TestDerepeatLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{5, 5},
+		{1, 1},
+		{0, DefaultMaxRepeats},
+		{-2, DefaultMaxRepeats},
+	}
+
+	for _, tt := range tests {
+		svc := NewWhisperServerService().WithDerepeatLimit(tt.limit)
+		if svc.maxRepeats != tt.want {
+			t.Errorf("WithDerepeatLimit(%d) set %d, want %d", tt.limit, svc.maxRepeats, tt.want)
+		}
+	}
+}
+
+// TestMaxFallbacks verifies the increment is spaced to reach 1.0 in n steps
+func TestMaxFallbacks(t *testing.T) {
+	svc := NewWhisperServerService().WithMaxFallbacks(4)
+	if got := formatTemperature(svc.config.TemperatureInc); got != "0.25" {
+		t.Errorf("Expected increment 0.25 for 4 fallbacks, got %s", got)
+	}
+
+	svc.WithMaxFallbacks(0)
+	if svc.config.TemperatureInc != 0 {
+		t.Errorf("Expected no fallback, got increment %g", svc.config.TemperatureInc)
+	}
+}
+
+// TestTranscribeTemperaturePrecision verifies configured temperatures reach the form unrounded
+func TestTranscribeTemperaturePrecision(t *testing.T) {
+	svc, fields := newTestServer(t, `{"text": ""}`)
+	svc.config.Temperature = 0.05
+	svc.config.TemperatureInc = 0.25
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+
+	if fields["temperature"] != "0.05" {
+		t.Errorf("Expected temperature 0.05, got %s", fields["temperature"])
+	}
+	if fields["temperature_inc"] != "0.25" {
+		t.Errorf("Expected temperature_inc 0.25, got %s", fields["temperature_inc"])
+	}
+}
+
+// TestTranscribeDetectedLanguage verifies auto-detection requests the language and normalizes it
+func TestTranscribeDetectedLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		wantLanguage string
+	}{
+		{"full name", `{"text": "hola", "language": "Spanish"}`, "es"},
+		{"code", `{"text": "hola", "language": "es"}`, "es"},
+		{"missing", `{"text": "hola"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, fields := newTestServer(t, tt.response)
+			svc.config.Language = LanguageAuto
+
+			audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+			result, err := svc.Transcribe(audio)
+			if err != nil {
+				t.Fatalf("Transcribe failed: %v", err)
+			}
+
+			if result.Language != tt.wantLanguage {
+				t.Errorf("Expected language %q, got %q", tt.wantLanguage, result.Language)
+			}
+			if fields["language"] != LanguageAuto || fields["response_format"] != ResponseFormatVerboseJSON {
+				t.Errorf("Expected auto language with verbose_json, got fields %v", fields)
+			}
+		})
+	}
+}
+
+// TestTranscribeReconnectsAfterBounce verifies a server that briefly refuses connections is waited for
+func TestTranscribeReconnectsAfterBounce(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		return nil, err
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	// The server comes back on the same address after the first attempts are refused
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text": "back again"}`))
+	})
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("Failed to restart server: %v", err)
+			return
+		}
+		server := &http.Server{Handler: handler}
+		t.Cleanup(func() { server.Close() })
+		server.Serve(listener)
+	}()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = "http://" + addr
+	svc.isRunning = true
+	svc.maxRetries = 1
+	svc.WithReadyProbeInterval(50 * time.Millisecond)
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	result, err := svc.Transcribe(audio)
+	if err != nil {
+		t.Fatalf("Expected transcription to recover after the bounce, got %v", err)
+	}
+	if result.Text != "back again" {
+		t.Errorf("Unexpected result text: %q", result.Text)
+	}
+}
+
+// TestTranscribeDuringRestart verifies transcriptions read the server address
+// safely while a restart rewrites it; run with -race
+func TestTranscribeDuringRestart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text": "ok"}`))
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			svc.mutex.Lock()
+			svc.serverURL = server.URL
+			svc.pid++
+			svc.mutex.Unlock()
+		}
+	}()
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	for i := 0; i < 5; i++ {
+		if _, err := svc.Transcribe(audio); err != nil {
+			t.Errorf("Transcribe failed: %v", err)
+		}
+	}
+	close(stop)
+	<-done
+}
+
+// TestTranscribeServerGoneMidResponse verifies a response cut off by the server
+// hanging up fails with ErrServerGone, and the server is marked running again
+// once it answers
+func TestTranscribeServerGoneMidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"text": "cut`))
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = server.URL
+	svc.isRunning = true
+	svc.WithReadyProbeInterval(10 * time.Millisecond)
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); !errors.Is(err, ErrServerGone) {
+		t.Fatalf("Expected ErrServerGone, got %v", err)
 	}
-	defer file.Close()
 
-	// Skip the WAV header (44 bytes)
-	if _, err := file.Seek(44, io.SeekStart); err != nil {
-		return nil, err
+	deadline := time.Now().Add(2 * time.Second)
+	for !svc.IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the server to be marked running again once it answers")
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
+}
+
+// hangingServer is a fake whisper server script answering every request
+// until a "hang" file appears, when the first one it started stops answering
+// while still holding the port. Later ones keep answering.
+const hangingServer = `exec python3 -c '
+import http.server, os, sys, time
+port = int(sys.argv[sys.argv.index("--port") + 1])
+first = not os.path.exists("spawned")
+open("spawned", "a").write("x")
+class Handler(http.server.BaseHTTPRequestHandler):
+    def do_GET(self):
+        self.reply()
+    def do_POST(self):
+        self.reply()
+    def reply(self):
+        while first and os.path.exists("hang"):
+            time.sleep(0.05)
+        self.send_response(200)
+        self.end_headers()
+        self.wfile.write(b"{\"text\": \"ok\"}")
+    def log_message(self, *args):
+        pass
+http.server.HTTPServer(("127.0.0.1", port), Handler).serve_forever()
+' "$@"`
 
-	// Read the audio data
-	audioData := &AudioData{
-		SampleRate: 16000, // Assume 16kHz sample rate
+// TestRecoverHungServer verifies recovering a spawned server that hangs
+// stops the hung process and starts a new one in its place
+func TestRecoverHungServer(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is needed for the fake server")
+	}
+	svc := newFakeServerService(t, hangingServer).WithReadyTimeout(10 * time.Second)
+	svc.reconnectWindow = 200 * time.Millisecond
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Failed to start the fake server: %v", err)
 	}
+	defer svc.Cleanup()
+	svc.mutex.Lock()
+	hungPID := svc.pid
+	svc.mutex.Unlock()
 
-	// Create a buffer to read the file
-	const bufferSize = 1024
-	buffer := make([]byte, bufferSize)
-	samples := make([]int16, 0)
+	if err := os.WriteFile("hang", nil, 0o644); err != nil {
+		t.Fatalf("Failed to hang the server: %v", err)
+	}
+	svc.recoverServer("Test server hung")
 
+	deadline := time.Now().Add(15 * time.Second)
 	for {
-		// Read a chunk
-		bytesRead, err := file.Read(buffer)
-		if err == io.EOF {
+		svc.mutex.Lock()
+		pid, running := svc.pid, svc.isRunning
+		svc.mutex.Unlock()
+		if running && pid != hungPID {
 			break
 		}
-		if err != nil {
-			return nil, err
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the hung server to be replaced by a new one")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := syscall.Kill(hungPID, 0); err == nil {
+		t.Errorf("Expected the hung server (PID %d) to be stopped", hungPID)
+	}
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if result, err := svc.Transcribe(audio); err != nil || result.Text != "ok" {
+		t.Errorf("Expected the new server to transcribe, got %v, %v", result, err)
+	}
+}
+
+// TestCleanupDuringRecovery verifies quitting while a hung server is being
+// recovered stops it, and that the recovery doesn't start another
+func TestCleanupDuringRecovery(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is needed for the fake server")
+	}
+	svc := newFakeServerService(t, hangingServer).WithReadyTimeout(10 * time.Second)
+	svc.reconnectWindow = 500 * time.Millisecond
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Failed to start the fake server: %v", err)
+	}
+	svc.mutex.Lock()
+	hungPID := svc.pid
+	svc.mutex.Unlock()
+
+	if err := os.WriteFile("hang", nil, 0o644); err != nil {
+		t.Fatalf("Failed to hang the server: %v", err)
+	}
+	svc.recoverServer("Test server hung")
+	if err := svc.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	// Let the reconnect window pass, when a restart would have happened
+	time.Sleep(svc.reconnectWindow + 500*time.Millisecond)
+	svc.reconnectMu.Lock()
+	defer svc.reconnectMu.Unlock()
+
+	if err := syscall.Kill(hungPID, 0); err == nil {
+		t.Errorf("Expected the hung server (PID %d) to be stopped", hungPID)
+	}
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if svc.isRunning || svc.cmd != nil || svc.pid != hungPID {
+		t.Errorf("Expected no server started after Cleanup, got PID %d running=%v", svc.pid, svc.isRunning)
+	}
+}
+
+// TestHealthCheck verifies the health checker reports a server that stops
+// answering, and leaves a server it didn't spawn running
+func TestHealthCheck(t *testing.T) {
+	var hung atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hung.Load() {
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := NewWhisperServerService().WithHealthCheck(10 * time.Millisecond)
+	svc.serverURL = server.URL
+	svc.mutex.Lock()
+	svc.isRunning = true
+	svc.startHealthCheck()
+	svc.mutex.Unlock()
+	defer svc.Cleanup()
+
+	waitFor := func(what string, cond func(ServerHealth) bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond(svc.Health()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s, health %+v", what, svc.Health())
+			}
+			time.Sleep(5 * time.Millisecond)
 		}
+	}
+	waitFor("a healthy check", func(h ServerHealth) bool { return h.Healthy })
+
+	hung.Store(true)
+	waitFor("repeated failures", func(h ServerHealth) bool { return h.Failures >= DefaultHealthFailures })
+	if !svc.IsRunning() {
+		t.Error("Expected a server we didn't spawn to be left running")
+	}
+
+	hung.Store(false)
+	waitFor("recovery", func(h ServerHealth) bool { return h.Healthy && h.Failures == 0 })
+}
+
+// TestTranscribeExternalServerGone verifies a server that never returns fails with ErrTransport
+func TestTranscribeExternalServerGone(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	svc := NewWhisperServerService()
+	svc.serverURL = "http://" + addr
+	svc.isRunning = true
+	svc.maxRetries = 1
+	svc.reconnectWindow = 200 * time.Millisecond
+	svc.WithReadyProbeInterval(50 * time.Millisecond)
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	if _, err := svc.Transcribe(audio); !errors.Is(err, ErrTransport) {
+		t.Errorf("Expected ErrTransport, got %v", err)
+	}
+}
+
+// TestInitializePortInUse verifies a taken port is shared with the whisper
+// server on it, skipped with auto port, and rejected if something else has it
+func TestInitializePortInUse(t *testing.T) {
+	existing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer existing.Close()
+	port := existing.Listener.Addr().(*net.TCPAddr).Port
+
+	svc := newFakeServerService(t, "echo \"args: $*\" >&2; exit 1")
+	svc.config.Port = port
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Expected to use the running server, got %v", err)
+	}
+	if !svc.IsRunning() || svc.cmd != nil {
+		t.Error("Expected the running server to be used without spawning one")
+	}
+	svc.Cleanup()
+
+	svc = newFakeServerService(t, "echo \"args: $*\" >&2; exit 1").WithAutoPort(true)
+	svc.config.Port = port
+	err := svc.Initialize()
+	if !errors.Is(err, ErrServerExited) {
+		t.Fatalf("Expected the fake server to be spawned, got %v", err)
+	}
+	if strings.Contains(err.Error(), fmt.Sprintf("--port %d ", port)) || !strings.Contains(err.Error(), "--port ") {
+		t.Errorf("Expected the server started on another port, got %v", err)
+	}
 
-		// Convert bytes to int16 samples
-		for i := 0; i < bytesRead; i += 2 {
-			if i+1 < bytesRead {
-				sample := int16(binary.LittleEndian.Uint16(buffer[i : i+2]))
-				samples = append(samples, sample)
+	// A listener that drops every connection isn't a whisper server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
 			}
+			conn.Close()
+		}
+	}()
+	svc = newFakeServerService(t, "exit 1")
+	svc.config.Port = listener.Addr().(*net.TCPAddr).Port
+	if err := svc.Initialize(); !errors.Is(err, ErrPortInUse) {
+		t.Errorf("Expected ErrPortInUse, got %v", err)
+	}
+}
+
+// TestCheckHost verifies IPv6 hosts get bracketed URLs and bad hosts are
+// rejected before anything is spawned
+func TestCheckHost(t *testing.T) {
+	if got := serverURLFor("::1", 8080); got != "http://[::1]:8080" {
+		t.Errorf("Expected a bracketed IPv6 URL, got %s", got)
+	}
+	if got := serverURLFor("127.0.0.1", 8080); got != "http://127.0.0.1:8080" {
+		t.Errorf("Expected a plain IPv4 URL, got %s", got)
+	}
+
+	for _, host := range []string{"127.0.0.1", "::1", "localhost"} {
+		if err := checkHost(host); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", host, err)
+		}
+	}
+	for _, host := range []string{"", "[::1]", "bad host!"} {
+		if err := checkHost(host); !errors.Is(err, ErrInvalidHost) {
+			t.Errorf("Expected ErrInvalidHost for %q, got %v", host, err)
+		}
+	}
+
+	svc := newFakeServerService(t, "touch spawned")
+	svc.config.Host = "bad host!"
+	if err := svc.Initialize(); !errors.Is(err, ErrInvalidHost) {
+		t.Fatalf("Expected ErrInvalidHost, got %v", err)
+	}
+	if _, err := os.Stat("spawned"); err == nil {
+		t.Error("Expected the server not to be spawned")
+	}
+}
+
+// TestShutdownDrain verifies shutdown waits for a transcription in progress,
+// refusing new ones, and gives up when the shutdown context expires
+func TestShutdownDrain(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte(`{"text": "last words"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	audio := &AudioData{Samples: make([]int16, AudioFrequency), SampleRate: AudioFrequency}
+	newService := func() *WhisperServerService {
+		svc := NewWhisperServerService().WithDrain(time.Minute)
+		svc.serverURL = server.URL
+		svc.isRunning = true
+		svc.attached = true
+		return svc
+	}
+
+	svc := newService()
+	type outcome struct {
+		result *WhisperServerResult
+		err    error
+	}
+	transcribed := make(chan outcome, 1)
+	go func() {
+		result, err := svc.Transcribe(audio)
+		transcribed <- outcome{result, err}
+	}()
+	<-started
+
+	shutDown := make(chan error, 1)
+	go func() { shutDown <- svc.ShutdownContext(context.Background()) }()
+	// Probing before draining starts would block another request on release
+	deadline := time.Now().Add(time.Second)
+	for {
+		svc.mutex.Lock()
+		draining := svc.draining
+		svc.mutex.Unlock()
+		if draining {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected shutdown to start draining")
 		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := svc.Transcribe(audio); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("Expected new transcriptions to be refused while draining, got %v", err)
+	}
+	select {
+	case <-shutDown:
+		t.Fatal("Expected shutdown to wait for the transcription in progress")
+	case <-time.After(50 * time.Millisecond):
 	}
 
-	audioData.Samples = samples
-	return audioData, nil
-}
\ No newline at end of file
+	release <- struct{}{}
+	if got := <-transcribed; got.err != nil || got.result.Text != "last words" {
+		t.Errorf("Expected the drained transcription to succeed, got %+v", got)
+	}
+	if err := <-shutDown; err != nil {
+		t.Errorf("Expected shutdown to succeed, got %v", err)
+	}
+
+	// The shutdown context bounds the drain
+	svc = newService()
+	go svc.Transcribe(audio)
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	svc.ShutdownContext(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the drain to end with the context, took %v", elapsed)
+	}
+}
+
+// TestTranscribeEmptyAudio verifies recordings with nothing to send fail with
+// ErrEmptyAudio before reaching the server
+func TestTranscribeEmptyAudio(t *testing.T) {
+	svc, fields := newTestServer(t, `{"text": "unexpected"}`)
+
+	tests := []struct {
+		name  string
+		audio *AudioData
+	}{
+		{"nil", nil},
+		{"no samples", &AudioData{SampleRate: AudioFrequency}},
+		{"empty samples", &AudioData{Samples: []int16{}, SampleRate: AudioFrequency}},
+		{"no sample rate", &AudioData{Samples: make([]int16, AudioFrequency)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Transcribe(tt.audio); !errors.Is(err, ErrEmptyAudio) {
+				t.Errorf("Expected ErrEmptyAudio, got %v", err)
+			}
+		})
+	}
+	if len(fields) != 0 {
+		t.Errorf("Expected no request to reach the server, got %v", fields)
+	}
+}
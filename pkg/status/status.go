@@ -3,7 +3,9 @@ package status
 import (
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/marcinja/conch/pkg/speech"
@@ -14,6 +16,9 @@ type StatusService struct {
 	svc    *speech.SpeechService
 	done   chan struct{}
 	writer io.Writer
+
+	// Shutdown may be called by both the UI exit path and a signal
+	shutdownOnce sync.Once
 }
 
 // NewStatusService creates a new status service
@@ -34,20 +39,36 @@ func NewStatusServiceWithWriter(speechSvc *speech.SpeechService, writer io.Write
 func (s *StatusService) Start() {
 	// Status display goroutine
 	go func() {
+		stallReported := false
 		for {
 			select {
 			case <-s.done:
 				fmt.Fprint(s.writer, "\rStatus: SHUTDOWN 🛑\n")
 				return
 			default:
-				if s.svc.IsRecording() {
-					fmt.Fprint(s.writer, "\rStatus: RECORDING 🔴 ")
-				} else if s.svc.IsTranscribing() {
-					fmt.Fprint(s.writer, "\rStatus: TRANSCRIBING 🔄 ")
-				} else if s.svc.IsListening() {
-					fmt.Fprint(s.writer, "\rStatus: LISTENING 🔊 ")
+				// Watchdog for a wedged capture loop, logged once per stall
+				stalled := s.svc.IsCaptureStalled(speech.CaptureStallTimeout)
+				if stalled && !stallReported {
+					log.Printf("Warning: no audio received for %v, audio capture may be stuck (last activity %s)",
+						speech.CaptureStallTimeout, s.svc.LastActivity().Format(time.TimeOnly))
+				}
+				stallReported = stalled
+
+				if stalled {
+					fmt.Fprint(s.writer, "\rStatus: STALLED ⚠️  ")
 				} else {
-					fmt.Fprint(s.writer, "\rStatus: IDLE ⏸️  ")
+					switch s.svc.State() {
+					case speech.StateShuttingDown:
+						fmt.Fprint(s.writer, "\rStatus: SHUTTING DOWN 🛑 ")
+					case speech.StateRecording:
+						fmt.Fprint(s.writer, "\rStatus: RECORDING 🔴 ")
+					case speech.StateTranscribing:
+						fmt.Fprint(s.writer, "\rStatus: TRANSCRIBING 🔄 ")
+					case speech.StateListening:
+						fmt.Fprint(s.writer, "\rStatus: LISTENING 🔊 ")
+					default:
+						fmt.Fprint(s.writer, "\rStatus: IDLE ⏸️  ")
+					}
 				}
 				time.Sleep(100 * time.Millisecond)
 			}
@@ -60,8 +81,8 @@ func (s *StatusService) Name() string {
 	return "StatusService"
 }
 
-// Shutdown stops the status service
+// Shutdown stops the status service. It is safe to call more than once.
 func (s *StatusService) Shutdown() error {
-	close(s.done)
+	s.shutdownOnce.Do(func() { close(s.done) })
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package status
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// TestShutdownTwice verifies concurrent and repeated shutdowns don't panic
+func TestShutdownTwice(t *testing.T) {
+	s := NewStatusServiceWithWriter(speech.NewSpeechService(), io.Discard)
+	s.Start()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Shutdown(); err != nil {
+				t.Errorf("Shutdown failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := s.Shutdown(); err != nil {
+		t.Errorf("Repeated Shutdown failed: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrClipboardTimeout is returned when the clipboard tool doesn't finish in time
+var ErrClipboardTimeout = errors.New("clipboard tool timed out")
+
+// clipboardTimeout bounds how long a copy may block the UI
+var clipboardTimeout = 2 * time.Second
+
+// clipboardTool is a command that copies its stdin to the clipboard
+type clipboardTool struct {
+	name string
+	args []string
+	// The tool keeps running to serve the selection after reading its
+	// input, so it's started without waiting for it to exit
+	lingers bool
+}
+
+// detectClipboardTool picks the clipboard command for the platform and display server
+func detectClipboardTool() clipboardTool {
+	switch {
+	case runtime.GOOS == "darwin":
+		return clipboardTool{name: "pbcopy"}
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		return clipboardTool{name: "wl-copy", lingers: true}
+	case os.Getenv("DISPLAY") != "":
+		return clipboardTool{name: "xclip", args: []string{"-selection", "clipboard"}, lingers: true}
+	default:
+		return clipboardTool{name: "pbcopy"}
+	}
+}
+
+// copyToClipboard copies text to the system clipboard
+func copyToClipboard(text string) error {
+	return detectClipboardTool().copy(text)
+}
+
+// copy runs the tool with text on stdin. A tool that doesn't exit within
+// clipboardTimeout is killed, so a hung helper can't freeze the UI.
+func (t clipboardTool) copy(text string) error {
+	if t.lingers {
+		cmd := exec.Command(t.name, t.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("error starting %s: %w", t.name, err)
+		}
+		// Reap it whenever it lets go of the selection
+		go cmd.Wait()
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, t.name, t.args...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%w: %s still running after %v", ErrClipboardTimeout, t.name, clipboardTimeout)
+		}
+		return fmt.Errorf("error running %s: %w", t.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package terminal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestClipboardTimeout verifies a hung clipboard tool is killed instead of blocking
+func TestClipboardTimeout(t *testing.T) {
+	defer func(d time.Duration) { clipboardTimeout = d }(clipboardTimeout)
+	clipboardTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err := clipboardTool{name: "sleep", args: []string{"10"}}.copy("text")
+	if !errors.Is(err, ErrClipboardTimeout) {
+		t.Fatalf("Expected ErrClipboardTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the copy to give up quickly, took %v", elapsed)
+	}
+
+	// A lingering tool returns as soon as it has started
+	start = time.Now()
+	if err := (clipboardTool{name: "sleep", args: []string{"1"}, lingers: true}).copy("text"); err != nil {
+		t.Fatalf("Expected a lingering tool to start, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected not to wait for a lingering tool, took %v", elapsed)
+	}
+}
@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CompactWidth is the terminal width below which the compact layout is used
+const CompactWidth = 60
+
+// compactLayout reports whether to draw the compact layout, which is forced
+// with WithCompact or picked for narrow terminals
+func (m *terminalModel) compactLayout() bool {
+	return m.compact || m.width < CompactWidth
+}
+
+// buildCompactView draws a one-line status and the current text, without
+// borders or the history, for small terminals. Overlays are still shown.
+func (m *terminalModel) buildCompactView(statusText string) string {
+	fit := lipgloss.NewStyle().Width(m.width)
+
+	var view strings.Builder
+	statusStyle := m.styles.statusBar
+	if m.flashActive {
+		statusStyle = statusStyle.Reverse(true)
+	}
+	view.WriteString(lipgloss.NewStyle().MaxWidth(m.width).Render(statusStyle.Render(statusText)))
+	view.WriteString("\n\n")
+
+	switch {
+	case m.loadingModel:
+		view.WriteString(fit.Render(m.buildLoadingText()))
+		return view.String()
+	case m.showHelp:
+		view.WriteString(m.buildHelpView())
+		return view.String()
+	case m.showLogs:
+		view.WriteString(m.buildLogView())
+		return view.String()
+	case m.showTuning:
+		view.WriteString(m.buildTuningView())
+		return view.String()
+	}
+
+	if m.transcriptionErr != nil {
+		view.WriteString(m.styles.banner.Width(m.width).Render(m.buildUnavailableText()))
+		view.WriteString("\n\n")
+	}
+
+	if m.clipboardText != "" {
+		view.WriteString(fit.Render(m.styles.clipboardText.Render(m.clipboardText)))
+	} else {
+		view.WriteString(m.styles.dimText.Render("No text to copy"))
+	}
+	view.WriteString("\n\n")
+
+	hint := fmt.Sprintf("%s copy · %s clear · %s help", keyLabel(m.keys.Copy), keyLabel(m.keys.Clear), keyLabel(m.keys.Help))
+	view.WriteString(fit.Render(m.styles.dimText.Render(hint)))
+	return view.String()
+}
@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// VoiceConfirm configures confirming destructive actions by voice, for
+// hands-free use. The next transcription after the action's key must match
+// one of the confirm phrases within Window; anything else aborts the action.
+type VoiceConfirm struct {
+	Window  time.Duration
+	Confirm []string
+	Cancel  []string
+}
+
+// DefaultVoiceConfirm returns the default phrases and a 10 second window
+func DefaultVoiceConfirm() VoiceConfirm {
+	return VoiceConfirm{
+		Window:  10 * time.Second,
+		Confirm: []string{"yes", "confirm"},
+		Cancel:  []string{"no", "cancel"},
+	}
+}
+
+// confirmTimeoutMsg ends the confirmation with the given id if it's still waiting
+type confirmTimeoutMsg struct {
+	id int
+}
+
+// confirmVerbs describe the actions that need confirming in the prompt
+var confirmVerbs = map[keyAction]string{
+	actionClear: "clear",
+	actionQuit:  "quit",
+}
+
+// requestConfirmation waits for the next transcription to confirm action
+func (m *terminalModel) requestConfirmation(action keyAction) tea.Cmd {
+	m.pendingConfirm = action
+	m.confirmDeadline = time.Now().Add(m.voiceConfirm.Window)
+	m.confirmID++
+	id := m.confirmID
+	m.statusMessage = "Waiting for confirmation"
+	return tea.Tick(m.voiceConfirm.Window, func(time.Time) tea.Msg {
+		return confirmTimeoutMsg{id: id}
+	})
+}
+
+// confirmPrompt is the status bar prompt while a confirmation is pending
+func (m *terminalModel) confirmPrompt() string {
+	remaining := max(time.Until(m.confirmDeadline).Round(time.Second), 0)
+	return fmt.Sprintf("❓ say %q to %s, %q to cancel (%v)",
+		m.voiceConfirm.Confirm[0], confirmVerbs[m.pendingConfirm], m.voiceConfirm.Cancel[0], remaining)
+}
+
+// resolveConfirmation matches a transcription against the confirm phrases,
+// performing the pending action on a match and aborting it otherwise
+func (m *terminalModel) resolveConfirmation(text string) tea.Cmd {
+	action := m.pendingConfirm
+	m.pendingConfirm = actionNone
+
+	heard := normalizePhrase(text)
+	for _, phrase := range m.voiceConfirm.Confirm {
+		if heard == normalizePhrase(phrase) {
+			return m.performConfirmed(action)
+		}
+	}
+	for _, phrase := range m.voiceConfirm.Cancel {
+		if heard == normalizePhrase(phrase) {
+			m.showNotice("Cancelled")
+			return nil
+		}
+	}
+	m.showNotice(fmt.Sprintf("Not confirmed (heard %q), nothing done", strings.TrimSpace(text)))
+	return nil
+}
+
+// performConfirmed carries out a confirmed action
+func (m *terminalModel) performConfirmed(action keyAction) tea.Cmd {
+	switch action {
+	case actionClear:
+		m.clipboardText = ""
+		m.statusMessage = "Clipboard cleared"
+	case actionQuit:
+		return tea.Quit
+	}
+	return nil
+}
+
+// normalizePhrase lowercases text and drops punctuation, so "Yes." matches "yes"
+func normalizePhrase(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), notWordRune)
+	return strings.Join(words, " ")
+}
+
+// notWordRune reports whether r separates the words of a phrase
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
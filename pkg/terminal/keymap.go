@@ -0,0 +1,191 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyAction is a command a key can be bound to
+type keyAction int
+
+const (
+	actionNone keyAction = iota
+	actionQuit
+	actionCopy
+	actionClear
+	actionSave
+	actionReplay
+	actionPlay
+	actionCancel
+	actionLogs
+	actionTune
+	actionStoreRegister
+	actionRecallRegister
+	actionHelp
+)
+
+// KeyMap binds keys to the terminal's actions. Keys use bubbletea's names,
+// e.g. "enter", "ctrl+c" or "x". The number keys 1-9 always recall history.
+type KeyMap struct {
+	Quit  []string // Pressed twice to exit
+	Copy  []string
+	Clear []string
+	Save  []string
+	// Transcribes the speech service's rolling buffer
+	Replay []string
+	// Plays back the last recording as captured
+	Play []string
+	// Discards the recording in progress
+	Cancel []string
+	// Toggles the overlay of recent log lines
+	Logs []string
+	// Toggles the VAD tuning overlay
+	Tune []string
+	// Followed by a letter, store the current text in that register, or append to it for A-Z
+	StoreRegister []string
+	// Followed by a letter, add that register to the current text
+	RecallRegister []string
+	// Toggles the help overlay, which Escape also closes
+	Help []string
+}
+
+// DefaultKeyMap returns the default bindings
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Quit:           []string{"ctrl+c"},
+		Copy:           []string{"enter"},
+		Clear:          []string{"c", "C"},
+		Save:           []string{"s", "S"},
+		Replay:         []string{"b", "B"},
+		Play:           []string{"p", "P"},
+		Cancel:         []string{"esc"},
+		Logs:           []string{"l", "L"},
+		Tune:           []string{"v", "V"},
+		StoreRegister:  []string{"\""},
+		RecallRegister: []string{"'"},
+		Help:           []string{"?"},
+	}
+}
+
+// binding is an action with its keys, config name and help text
+type binding struct {
+	name   string
+	action keyAction
+	keys   []string
+	desc   string
+}
+
+// bindings pairs each action with its keys and config name
+func (k KeyMap) bindings() []binding {
+	return []binding{
+		{"quit", actionQuit, k.Quit, "Quit (press twice)"},
+		{"copy", actionCopy, k.Copy, "Copy text to clipboard"},
+		{"clear", actionClear, k.Clear, "Clear text"},
+		{"save", actionSave, k.Save, "Save session transcript (voice mode)"},
+		{"replay", actionReplay, k.Replay, "Transcribe the rolling buffer"},
+		{"play", actionPlay, k.Play, "Play back the last recording"},
+		{"cancel", actionCancel, k.Cancel, "Cancel the recording in progress"},
+		{"logs", actionLogs, k.Logs, "Toggle recent log messages"},
+		{"tune", actionTune, k.Tune, "Toggle the voice detection tuning overlay"},
+		{"store-register", actionStoreRegister, k.StoreRegister, "Store text in register a-z (A-Z appends)"},
+		{"recall-register", actionRecallRegister, k.RecallRegister, "Add register a-z to the text"},
+		{"help", actionHelp, k.Help, "Toggle this help"},
+	}
+}
+
+// Validate checks every action has a key and no key is bound twice
+func (k KeyMap) Validate() error {
+	seen := map[string]string{}
+	for _, b := range k.bindings() {
+		if len(b.keys) == 0 {
+			return fmt.Errorf("no key bound to %s", b.name)
+		}
+		for _, key := range b.keys {
+			if len(key) == 1 && key >= "1" && key <= "9" {
+				return fmt.Errorf("key %q for %s is reserved for recalling history", key, b.name)
+			}
+			if other, ok := seen[key]; ok {
+				return fmt.Errorf("key %q bound to both %s and %s", key, other, b.name)
+			}
+			seen[key] = b.name
+		}
+	}
+	return nil
+}
+
+// action returns the action bound to key
+func (k KeyMap) action(key string) keyAction {
+	for _, b := range k.bindings() {
+		for _, bound := range b.keys {
+			if bound == key {
+				return b.action
+			}
+		}
+	}
+	return actionNone
+}
+
+// ParseKeyMap applies overrides like "copy=y,clear=x/X" to the default
+// bindings. Each named action's keys are replaced by the '/'-separated list.
+func ParseKeyMap(spec string) (KeyMap, error) {
+	keys := DefaultKeyMap()
+	targets := map[string]*[]string{
+		"quit":            &keys.Quit,
+		"copy":            &keys.Copy,
+		"clear":           &keys.Clear,
+		"save":            &keys.Save,
+		"replay":          &keys.Replay,
+		"play":            &keys.Play,
+		"cancel":          &keys.Cancel,
+		"logs":            &keys.Logs,
+		"tune":            &keys.Tune,
+		"store-register":  &keys.StoreRegister,
+		"recall-register": &keys.RecallRegister,
+		"help":            &keys.Help,
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return KeyMap{}, fmt.Errorf("invalid key binding %q, expected action=key", entry)
+		}
+		target, ok := targets[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return KeyMap{}, fmt.Errorf("unknown action %q", name)
+		}
+		*target = strings.Split(strings.TrimSpace(value), "/")
+	}
+
+	if err := keys.Validate(); err != nil {
+		return KeyMap{}, err
+	}
+	return keys, nil
+}
+
+// keyLabel formats the first key bound to an action for the instructions line
+func keyLabel(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	return keyName(keys[0])
+}
+
+// keyName formats a key for display, e.g. "ctrl+c" as "Ctrl+C"
+func keyName(key string) string {
+	if len(key) == 1 {
+		return strings.ToUpper(key)
+	}
+
+	// Title-case each part of names like "enter" or "ctrl+c"
+	parts := strings.Split(key, "+")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
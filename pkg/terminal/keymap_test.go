@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseKeyMap verifies overrides replace the default keys of the named actions only
+func TestParseKeyMap(t *testing.T) {
+	keys, err := ParseKeyMap("copy=y, clear=x/X")
+	if err != nil {
+		t.Fatalf("ParseKeyMap failed: %v", err)
+	}
+
+	tests := map[string]keyAction{
+		"y":      actionCopy,
+		"X":      actionClear,
+		"enter":  actionNone,
+		"c":      actionNone,
+		"s":      actionSave,
+		"ctrl+c": actionQuit,
+		"b":      actionReplay,
+		"?":      actionHelp,
+	}
+	for key, want := range tests {
+		if got := keys.action(key); got != want {
+			t.Errorf("Key %q: expected action %d, got %d", key, want, got)
+		}
+	}
+}
+
+// TestParseKeyMapErrors verifies bad specs and conflicting bindings are rejected
+func TestParseKeyMapErrors(t *testing.T) {
+	tests := map[string]string{
+		"copy":         "expected action=key",
+		"paste=v":      "unknown action",
+		"copy=c":       "bound to both",
+		"save=3":       "reserved",
+		"clear=s/S/x":  "bound to both",
+		"quit=enter/q": "bound to both",
+	}
+
+	for spec, want := range tests {
+		if _, err := ParseKeyMap(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", spec, want, err)
+		}
+	}
+}
+
+// TestKeyLabel verifies key names are formatted for the instructions line
+func TestKeyLabel(t *testing.T) {
+	for keys, want := range map[string]string{"enter": "Enter", "ctrl+c": "Ctrl+C", "x": "X"} {
+		if got := keyLabel([]string{keys}); got != want {
+			t.Errorf("keyLabel(%q) = %q, want %q", keys, got, want)
+		}
+	}
+}
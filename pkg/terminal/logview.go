@@ -0,0 +1,49 @@
+package terminal
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultLogLines is how many recent log lines the log overlay keeps
+const DefaultLogLines = 200
+
+// logRing keeps the most recent log lines so they can be shown in the UI
+// instead of being written over it
+type logRing struct {
+	mu      sync.Mutex
+	lines   []string
+	limit   int
+	partial string
+}
+
+func newLogRing(limit int) *logRing {
+	return &logRing{limit: limit}
+}
+
+// Write implements io.Writer, splitting p into lines. An unterminated line is
+// held until the rest of it arrives.
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	text := r.partial + string(p)
+	lines := strings.Split(text, "\n")
+	r.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		r.lines = append(r.lines, line)
+	}
+	if over := len(r.lines) - r.limit; over > 0 {
+		r.lines = append([]string(nil), r.lines[over:]...)
+	}
+	return len(p), nil
+}
+
+// tail returns up to n of the most recent lines, oldest first
+func (r *logRing) tail(n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := max(len(r.lines)-n, 0)
+	return append([]string(nil), r.lines[start:]...)
+}
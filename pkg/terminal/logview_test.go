@@ -0,0 +1,30 @@
+package terminal
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+)
+
+// TestLogRing verifies lines are split on newlines, partial lines wait for the
+// rest and only the most recent lines are kept
+func TestLogRing(t *testing.T) {
+	ring := newLogRing(3)
+	ring.Write([]byte("one\ntw"))
+	ring.Write([]byte("o\n"))
+	if got := ring.tail(10); !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Errorf("Expected the partial line to be joined, got %q", got)
+	}
+
+	logger := log.New(ring, "", 0)
+	for i := 3; i <= 5; i++ {
+		logger.Print(fmt.Sprint("line ", i))
+	}
+	if got := ring.tail(10); !reflect.DeepEqual(got, []string{"line 3", "line 4", "line 5"}) {
+		t.Errorf("Expected the 3 newest lines, got %q", got)
+	}
+	if got := ring.tail(1); !reflect.DeepEqual(got, []string{"line 5"}) {
+		t.Errorf("Expected the newest line, got %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ClipboardNormalization controls how text is rewritten on its way to the
+// clipboard, for target applications that choke on some Unicode. History
+// keeps the original text.
+type ClipboardNormalization struct {
+	FoldPunctuation bool // Smart quotes, dashes and ellipses to their ASCII forms
+	StripSymbols    bool // Drop emoji, other pictographic symbols and non-printable characters
+}
+
+// punctuationFolds maps typographic punctuation to ASCII
+var punctuationFolds = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"«", `"`, "»", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "−", "-",
+	"—", "--", "―", "--",
+	"…", "...",
+	" ", " ", " ", " ", " ", " ",
+)
+
+// normalizeForClipboard applies opts to text
+func normalizeForClipboard(text string, opts ClipboardNormalization) string {
+	if opts.FoldPunctuation {
+		text = punctuationFolds.Replace(text)
+	}
+	if opts.StripSymbols {
+		text = strings.Map(func(r rune) rune {
+			if r == '\n' || r == '\t' {
+				return r
+			}
+			// Emoji and modifiers are symbols outside ASCII; joiners and
+			// variation selectors aren't printable
+			if !unicode.IsPrint(r) || (r > unicode.MaxASCII && unicode.In(r, unicode.So, unicode.Sk)) {
+				return -1
+			}
+			return r
+		}, text)
+		text = strings.TrimSpace(text)
+	}
+	return text
+}
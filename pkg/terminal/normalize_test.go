@@ -0,0 +1,27 @@
+package terminal
+
+import "testing"
+
+// TestNormalizeForClipboard verifies each option on its own and together
+func TestNormalizeForClipboard(t *testing.T) {
+	const text = "“It’s fine” — really… 👍🏽 ok"
+
+	tests := []struct {
+		name string
+		opts ClipboardNormalization
+		want string
+	}{
+		{"disabled", ClipboardNormalization{}, text},
+		{"fold", ClipboardNormalization{FoldPunctuation: true}, `"It's fine" -- really... 👍🏽 ok`},
+		{"strip", ClipboardNormalization{StripSymbols: true}, "“It’s fine” — really…  ok"},
+		{"both", ClipboardNormalization{FoldPunctuation: true, StripSymbols: true}, `"It's fine" -- really...  ok`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeForClipboard(text, tt.opts); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// registerPreviewLen is how much of each register the registers panel shows
+const registerPreviewLen = 50
+
+// registerName returns the register a key names, and whether an uppercase
+// letter asked to append to it as in Vim
+func registerName(key string) (r rune, appending bool, ok bool) {
+	if len(key) != 1 {
+		return 0, false, false
+	}
+	r = rune(key[0])
+	switch {
+	case r >= 'a' && r <= 'z':
+		return r, false, true
+	case r >= 'A' && r <= 'Z':
+		return unicode.ToLower(r), true, true
+	}
+	return 0, false, false
+}
+
+// handleRegisterKey completes a register command started by the store or
+// recall key. Anything but a letter cancels it.
+func (m *terminalModel) handleRegisterKey(pending keyAction, key string) {
+	r, appending, ok := registerName(key)
+	if !ok {
+		m.showNotice("Register command cancelled")
+		return
+	}
+	if pending == actionStoreRegister {
+		m.storeRegister(r, appending)
+	} else {
+		m.recallRegister(r)
+	}
+}
+
+// storeRegister saves the current text to register r, or appends it when appending
+func (m *terminalModel) storeRegister(r rune, appending bool) {
+	if m.clipboardText == "" {
+		m.showNotice("No text to store")
+		return
+	}
+
+	if m.registers == nil {
+		m.registers = map[rune]string{}
+	}
+	if existing := m.registers[r]; appending && existing != "" {
+		m.registers[r] = existing + " " + m.clipboardText
+		m.statusMessage = fmt.Sprintf("Appended to register %c", r)
+	} else {
+		m.registers[r] = m.clipboardText
+		m.statusMessage = fmt.Sprintf("Stored in register %c", r)
+	}
+
+	if m.registersFile != "" {
+		if err := saveRegisters(m.registersFile, m.registers); err != nil {
+			m.statusMessage = fmt.Sprintf("Error saving registers: %v", err)
+		}
+	}
+}
+
+// recallRegister adds register r to the current text, so fragments can be
+// assembled before copying them together
+func (m *terminalModel) recallRegister(r rune) {
+	text := m.registers[r]
+	if text == "" {
+		m.statusMessage = fmt.Sprintf("Register %c is empty", r)
+		return
+	}
+
+	if m.clipboardText == "" {
+		m.clipboardText = text
+	} else {
+		m.clipboardText += " " + text
+	}
+	m.statusMessage = fmt.Sprintf("Recalled register %c", r)
+}
+
+// buildRegistersView lists the non-empty registers, or returns "" if there are none
+func (m *terminalModel) buildRegistersView() string {
+	if len(m.registers) == 0 {
+		return ""
+	}
+	names := make([]rune, 0, len(m.registers))
+	for r := range m.registers {
+		names = append(names, r)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	var view strings.Builder
+	view.WriteString(m.styles.clipboardTitle.Render("Registers"))
+	view.WriteString("\n")
+	for _, r := range names {
+		text := []rune(m.registers[r])
+		preview := string(text)
+		if len(text) > registerPreviewLen {
+			preview = string(text[:registerPreviewLen-1]) + "…"
+		}
+		view.WriteString("\n")
+		view.WriteString(m.styles.highlightText.Render(fmt.Sprintf("\"%c  ", r)))
+		view.WriteString(m.styles.normalText.Render(preview))
+	}
+	view.WriteString("\n\n")
+	view.WriteString(m.styles.dimText.Render(fmt.Sprintf("[%s a-z] Store | [%s A-Z] Append | [%s a-z] Recall",
+		keyLabel(m.keys.StoreRegister), keyLabel(m.keys.StoreRegister), keyLabel(m.keys.RecallRegister))))
+	return m.styles.border.Render(view.String())
+}
+
+// loadRegisters reads registers saved by saveRegisters. A missing file has no registers.
+func loadRegisters(path string) (map[rune]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[rune]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved map[string]string
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid registers file %s: %v", path, err)
+	}
+	registers := map[rune]string{}
+	for name, text := range saved {
+		if r, _, ok := registerName(name); ok && text != "" {
+			registers[r] = text
+		}
+	}
+	return registers, nil
+}
+
+// saveRegisters writes the registers to path as a JSON object keyed by letter
+func saveRegisters(path string, registers map[rune]string) error {
+	saved := make(map[string]string, len(registers))
+	for r, text := range registers {
+		saved[string(r)] = text
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,54 @@
+package terminal
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// TestRegisters verifies storing, appending and recalling registers by key,
+// and that they're saved to the registers file
+func TestRegisters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registers.json")
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), registersFile: path}
+	press := func(keys ...string) {
+		for _, key := range keys {
+			m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		}
+	}
+
+	m.clipboardText = "first"
+	press(`"`, "a")
+	m.clipboardText = "second"
+	press(`"`, "A")
+	m.clipboardText = "other"
+	press(`"`, "b")
+	want := map[rune]string{'a': "first second", 'b': "other"}
+	if !reflect.DeepEqual(m.registers, want) {
+		t.Fatalf("Expected registers %q, got %q", want, m.registers)
+	}
+
+	// Recalling adds to the current text, so fragments can be assembled
+	m.clipboardText = ""
+	press("'", "b", "'", "a")
+	if m.clipboardText != "other first second" {
+		t.Errorf("Expected the registers assembled in order, got %q", m.clipboardText)
+	}
+
+	// A key that isn't a letter cancels, and isn't handled as its own binding
+	press(`"`, "?")
+	if m.pendingRegister != actionNone || m.showHelp {
+		t.Error("Expected the register command to be cancelled")
+	}
+
+	loaded, err := loadRegisters(path)
+	if err != nil || !reflect.DeepEqual(loaded, want) {
+		t.Errorf("Expected the saved registers %q, got %q, %v", want, loaded, err)
+	}
+	if loaded, err := loadRegisters(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(loaded) != 0 {
+		t.Errorf("Expected no registers from a missing file, got %q, %v", loaded, err)
+	}
+}
@@ -0,0 +1,109 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// OutputSink receives the text the user sends out of conch, by copying it or
+// through auto-copy
+type OutputSink interface {
+	Write(text string) error
+}
+
+// ClipboardSink copies text to the system clipboard
+type ClipboardSink struct{}
+
+func (ClipboardSink) Write(text string) error {
+	return copyToClipboard(text)
+}
+
+// FileSink appends each text to a file as a line
+type FileSink struct {
+	Path string
+}
+
+func (s FileSink) Write(text string) error {
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriterSink writes each text to W as a line, e.g. to stdout piped into another program
+type WriterSink struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+func (s *WriterSink) Write(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintln(s.W, text)
+	return err
+}
+
+// CommandSink runs a shell command for each text. Each {} in the command is
+// replaced with the shell-quoted text; without one the text goes to stdin.
+type CommandSink struct {
+	Command string
+}
+
+func (s CommandSink) Write(text string) error {
+	command := s.Command
+	var stdin io.Reader
+	if strings.Contains(command, "{}") {
+		command = strings.ReplaceAll(command, "{}", shellQuote(text))
+	} else {
+		stdin = strings.NewReader(text)
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = stdin
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ParseOutputSink builds a sink from a spec: "clipboard", "stdout" or "file:PATH"
+func ParseOutputSink(spec string) (OutputSink, error) {
+	switch {
+	case spec == "clipboard":
+		return ClipboardSink{}, nil
+	case spec == "stdout":
+		return &WriterSink{W: os.Stdout}, nil
+	case strings.HasPrefix(spec, "file:") && len(spec) > len("file:"):
+		return FileSink{Path: strings.TrimPrefix(spec, "file:")}, nil
+	}
+	return nil, fmt.Errorf("unknown output %q, expected clipboard, stdout or file:PATH", spec)
+}
+
+// writeSinks writes text to every sink, so one failing doesn't stop the others
+func writeSinks(sinks []OutputSink, text string) error {
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Write(text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseOutputSink verifies each spec maps to its sink and bad specs are rejected
+func TestParseOutputSink(t *testing.T) {
+	if sink, err := ParseOutputSink("file:notes.txt"); err != nil || sink != OutputSink(FileSink{Path: "notes.txt"}) {
+		t.Errorf("Expected a file sink, got %#v, %v", sink, err)
+	}
+	if sink, err := ParseOutputSink("clipboard"); err != nil || sink != OutputSink(ClipboardSink{}) {
+		t.Errorf("Expected a clipboard sink, got %#v, %v", sink, err)
+	}
+	for _, spec := range []string{"file:", "printer", ""} {
+		if _, err := ParseOutputSink(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+// TestSinks verifies the file, writer and command sinks deliver the text
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	var buf bytes.Buffer
+	sinks := []OutputSink{
+		FileSink{Path: path},
+		&WriterSink{W: &buf},
+		CommandSink{Command: "printf '%s' {} > " + filepath.Join(dir, "arg.txt")},
+		CommandSink{Command: "cat > " + filepath.Join(dir, "stdin.txt")},
+	}
+
+	for _, text := range []string{"first", "it's second"} {
+		if err := writeSinks(sinks, text); err != nil {
+			t.Fatalf("writeSinks failed: %v", err)
+		}
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+	if got := read("out.txt"); got != "first\nit's second\n" {
+		t.Errorf("Expected both lines appended, got %q", got)
+	}
+	if buf.String() != "first\nit's second\n" {
+		t.Errorf("Expected both lines written, got %q", buf.String())
+	}
+	if got := read("arg.txt"); got != "it's second" {
+		t.Errorf("Expected the quoted text as an argument, got %q", got)
+	}
+	if got := read("stdin.txt"); got != "it's second" {
+		t.Errorf("Expected the text on stdin, got %q", got)
+	}
+
+	// One failing sink doesn't stop the others
+	err := writeSinks([]OutputSink{CommandSink{Command: "echo broken >&2; exit 1"}, FileSink{Path: path}}, "third")
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected the command's output in the error, got %v", err)
+	}
+	if !strings.HasSuffix(read("out.txt"), "third\n") {
+		t.Error("Expected the file sink to still be written")
+	}
+}
@@ -1,12 +1,16 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-	"os/exec"
-	"io"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -28,13 +32,95 @@ type errMsg struct {
 }
 
 type transcriptionMsg struct {
+	text        string
+	language    string
+	rtf         float64
+	translation string
+}
+
+// playbackDoneMsg reports that playing back the last recording finished
+type playbackDoneMsg struct {
+	err error
+}
+
+// replayTranscriptionMsg carries the transcription of the rolling buffer
+type replayTranscriptionMsg struct {
 	text string
+	err  error
 }
 
 type statusUpdateMsg struct {
 	text string
 }
 
+type recordingMsg struct {
+	audioData *speech.AudioData
+}
+
+type spinnerTickMsg struct {
+	id int
+}
+
+type autoFinalizeTickMsg struct {
+	id int
+}
+
+type startupProgressMsg struct {
+	attempt int
+	ready   bool
+}
+
+type whisperReadyMsg struct {
+	err error
+}
+
+type recordingDiscardedMsg struct {
+	duration time.Duration
+}
+
+// pushToTalkMsg reports the global hotkey going down or up
+type pushToTalkMsg struct {
+	down bool
+}
+
+// recordingSavedMsg reports a recording saved in record-only mode
+type recordingSavedMsg struct {
+	path string
+	err  error
+}
+
+type flashEndMsg struct {
+	id int
+}
+
+type interimCopyMsg struct {
+	id  int32
+	err error
+}
+
+// copiedMsg reports a copy to the output sinks made by copyCmd
+type copiedMsg struct {
+	text     string // The clipboard buffer text copied, before normalization
+	notice   string // Shown once the copy succeeds
+	finalize bool   // Drop the copied text from the buffer once it's out
+	err      error
+}
+
+// autoFinalizeInterval is how often the auto-copy countdown is refreshed
+const autoFinalizeInterval = 250 * time.Millisecond
+
+// DefaultHistoryLimit is how many transcriptions the history keeps by default
+const DefaultHistoryLimit = 5
+
+// flashDuration is how long the status bar stays inverted after a transcription
+const flashDuration = 300 * time.Millisecond
+
+// spinnerFrames are the animation frames shown while transcribing
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner advances, independent of the status poll
+const spinnerInterval = 100 * time.Millisecond
+
 // TerminalApp manages the terminal UI for voice commands
 type TerminalApp struct {
 	program    *tea.Program
@@ -42,15 +128,27 @@ type TerminalApp struct {
 	speechSvc  *speech.SpeechService
 	whisperSvc *speech.WhisperServerService
 	statusSvc  *status.StatusService
+
+	// Log output is copied here as well as to the log overlay while the UI runs
+	logFile io.Writer
+
+	// Where the UI is drawn
+	ui io.Writer
 }
 
 // terminalModel implements the tea.Model interface
 type terminalModel struct {
 	// Services
-	speechSvc      *speech.SpeechService
-	whisperSvc     *speech.WhisperServerService
-	statusSvc      *status.StatusService
-	
+	speechSvc  *speech.SpeechService
+	whisperSvc *speech.WhisperServerService
+	statusSvc  *status.StatusService
+
+	// Transcribes recordings in place of whisperSvc when set
+	transcriber speech.Transcriber
+
+	// Key bindings
+	keys KeyMap
+
 	// UI state
 	mode           InputMode
 	statusMessage  string
@@ -59,57 +157,178 @@ type terminalModel struct {
 	width          int
 	height         int
 	lastCtrlC      time.Time
-	
+
+	// Status set by a notice is kept over the periodic status poll until noticeUntil
+	noticeUntil time.Time
+
+	// Help overlay listing every key binding, replaces the main view while open
+	showHelp bool
+
+	// VAD tuning overlay with the live level and threshold
+	showTuning bool
+
+	// Log overlay showing the lines logged while the UI runs
+	showLogs bool
+	logs     *logRing
+
+	// Named registers a-z holding text fragments, optionally saved to registersFile.
+	// pendingRegister is the store or recall command waiting for its letter.
+	registers       map[rune]string
+	registersFile   string
+	pendingRegister keyAction
+
+	// Options
+	clipboardNorm   ClipboardNormalization
+	recallAutoCopy  bool
+	exportDir       string
+	exportParagraph bool
+
+	// History bounds, oldest entries are evicted first. A zero limit is unbounded.
+	historyLimit    int
+	historyMaxChars int
+	historyChars    int
+	historyEvicted  int
+
+	// Destinations for copied text, the clipboard unless configured
+	sinks []OutputSink
+
+	// Interim copy: each transcription is copied in the background as soon as it
+	// arrives. The id of the newest copy lets older, slower copies be skipped.
+	interimCopy   bool
+	interimCopyID atomic.Int32
+
+	// Set once the UI has exited, when transcriptions drained on shutdown
+	// can only be logged
+	exited atomic.Bool
+
+	// Completion notifications: a terminal bell written to bellOut, and/or a
+	// brief inverted status bar, the id ignoring ticks from an earlier flash
+	completionBell  bool
+	completionFlash bool
+	bellOut         io.Writer
+	flashActive     bool
+	flashID         int
+
+	// When set, every recording is saved with its transcription for later review
+	archive *speech.RecordingArchive
+
+	// Record-only mode saves recordings to archive as voice memos without transcribing
+	recordOnly bool
+
+	// Set when a push-to-talk recording ends, so its transcription is copied
+	// straight to the clipboard for use in whichever window has focus
+	copyNextTranscription bool
+
+	// Language whisper detected for the latest transcription, when auto-detecting
+	detectedLanguage string
+
+	// Real-time factor of the latest transcription, shown when showRTF is set
+	showRTF bool
+	lastRTF float64
+
+	// Always use the compact layout, not only on narrow terminals
+	compact bool
+
+	// English translation of the latest transcription, when requested
+	lastTranslation string
+
+	// Fraction of the latest recording that clipped, when over the warning level
+	clipping float64
+
+	// Destructive actions wait for a spoken confirmation when voiceConfirm is set.
+	// pendingConfirm is the action waiting until confirmDeadline.
+	voiceConfirm    *VoiceConfirm
+	pendingConfirm  keyAction
+	confirmDeadline time.Time
+	confirmID       int
+
+	// Transcriptions are dropped until one starts with wakeWord, when set.
+	// Dictation then stays awake until wakeTimeout passes without one.
+	wakeWord    string
+	wakeTimeout time.Duration
+	awake       bool
+	wakeID      int
+
+	// Set when the whisper server failed to start, capture still runs without it
+	transcriptionErr error
+
+	// While the whisper server loads its model a loading screen replaces the UI
+	loadingModel   bool
+	loadingAttempt int
+
+	// Auto-finalize: after autoFinalizeGrace of silence following a transcription,
+	// the accumulated text is copied and a fresh buffer started. Zero disables it.
+	autoFinalizeGrace    time.Duration
+	autoFinalizeDeadline time.Time
+	autoFinalizeID       int
+
+	// Spinner state, the id guards against stale tick chains after a restart
+	spinnerActive bool
+	spinnerFrame  int
+	spinnerID     int
+
 	// UI styles
-	styles         styles
-	
+	styles styles
+
 	// Other
-	mu             sync.Mutex
-	program        *tea.Program
+	mu      sync.Mutex
+	program *tea.Program
 }
 
 // styles holds the styling for the UI
 type styles struct {
-	statusBar      lipgloss.Style
-	title          lipgloss.Style
-	normalText     lipgloss.Style
-	highlightText  lipgloss.Style
-	dimText        lipgloss.Style
-	errorText      lipgloss.Style
-	focusedText    lipgloss.Style
-	transcriptText lipgloss.Style
-	historyText    lipgloss.Style
-	historyTitle   lipgloss.Style
-	currentTitle   lipgloss.Style
-	clipboardTitle lipgloss.Style
-	clipboardText  lipgloss.Style
+	statusBar       lipgloss.Style
+	title           lipgloss.Style
+	normalText      lipgloss.Style
+	highlightText   lipgloss.Style
+	dimText         lipgloss.Style
+	errorText       lipgloss.Style
+	banner          lipgloss.Style
+	focusedText     lipgloss.Style
+	transcriptText  lipgloss.Style
+	historyText     lipgloss.Style
+	historyTitle    lipgloss.Style
+	currentTitle    lipgloss.Style
+	clipboardTitle  lipgloss.Style
+	clipboardText   lipgloss.Style
 	instructionText lipgloss.Style
-	border         lipgloss.Style
-	section        lipgloss.Style
-	container      lipgloss.Style
+	border          lipgloss.Style
+	section         lipgloss.Style
+	container       lipgloss.Style
 }
 
 // NewTerminalApp creates a new terminal application
 func NewTerminalApp(shell string, speechSvc *speech.SpeechService, whisperSvc *speech.WhisperServerService, statusSvc *status.StatusService) (*TerminalApp, error) {
+	// With stdout piped, e.g. to feed the stdout sink to another program,
+	// draw the UI on the controlling terminal instead
+	var ui io.Writer = os.Stdout
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+			ui = tty
+			lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(tty))
+		}
+	}
+
 	// Create styles
 	s := styles{
-		statusBar:      lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#333333")).Padding(0, 1),
-		title:          lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00")).Align(lipgloss.Center).Padding(0, 4).MarginBottom(1),
-		normalText:     lipgloss.NewStyle(),
-		highlightText:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FF00")),
-		dimText:        lipgloss.NewStyle().Faint(true),
-		errorText:      lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")),
-		focusedText:    lipgloss.NewStyle().Bold(true),
-		transcriptText: lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")),
-		historyText:    lipgloss.NewStyle().Faint(true),
-		historyTitle:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00")),
-		currentTitle:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00CCFF")).Align(lipgloss.Center),
-		clipboardTitle: lipgloss.NewStyle().Bold(true).Align(lipgloss.Center),
-		clipboardText:  lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Align(lipgloss.Center),
+		statusBar:       lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#333333")).Padding(0, 1),
+		title:           lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00")).Align(lipgloss.Center).Padding(0, 4).MarginBottom(1),
+		normalText:      lipgloss.NewStyle(),
+		highlightText:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FF00")),
+		dimText:         lipgloss.NewStyle().Faint(true),
+		errorText:       lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")),
+		banner:          lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#AA0000")).Padding(0, 1),
+		focusedText:     lipgloss.NewStyle().Bold(true),
+		transcriptText:  lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")),
+		historyText:     lipgloss.NewStyle().Faint(true),
+		historyTitle:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00")),
+		currentTitle:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00CCFF")).Align(lipgloss.Center),
+		clipboardTitle:  lipgloss.NewStyle().Bold(true).Align(lipgloss.Center),
+		clipboardText:   lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Align(lipgloss.Center),
 		instructionText: lipgloss.NewStyle().Faint(true).Italic(true).Align(lipgloss.Center),
-		border:         lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(1, 3).BorderForeground(lipgloss.Color("#4B9CD3")),
-		section:        lipgloss.NewStyle().Margin(1, 0),
-		container:      lipgloss.NewStyle().Align(lipgloss.Center).Width(80),
+		border:          lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(1, 3).BorderForeground(lipgloss.Color("#4B9CD3")),
+		section:         lipgloss.NewStyle().Margin(1, 0),
+		container:       lipgloss.NewStyle().Align(lipgloss.Center).Width(80),
 	}
 
 	// Initialize the model
@@ -117,6 +336,7 @@ func NewTerminalApp(shell string, speechSvc *speech.SpeechService, whisperSvc *s
 		speechSvc:      speechSvc,
 		whisperSvc:     whisperSvc,
 		statusSvc:      statusSvc,
+		keys:           DefaultKeyMap(),
 		mode:           VoiceMode,
 		statusMessage:  "Ready",
 		clipboardText:  "",
@@ -124,34 +344,267 @@ func NewTerminalApp(shell string, speechSvc *speech.SpeechService, whisperSvc *s
 		width:          80,
 		height:         24,
 		styles:         s,
+		exportDir:      ".",
+		bellOut:        ui,
+		historyLimit:   DefaultHistoryLimit,
+		logs:           newLogRing(DefaultLogLines),
 	}
 
-	// Create tea program
-	program := tea.NewProgram(model, tea.WithAltScreen())
 	app := &TerminalApp{
-		program:    program,
 		model:      model,
 		speechSvc:  speechSvc,
 		whisperSvc: whisperSvc,
 		statusSvc:  statusSvc,
+		ui:         ui,
 	}
-	
-	// Set the program reference in the model
-	model.program = program
+	app.newProgram(true)
 
 	return app, nil
 }
 
+// newProgram creates the tea program, drawing full screen or inline
+func (app *TerminalApp) newProgram(altScreen bool) {
+	opts := []tea.ProgramOption{tea.WithOutput(app.ui)}
+	if altScreen {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	app.program = tea.NewProgram(app.model, opts...)
+	// Set the program reference in the model
+	app.model.program = app.program
+}
+
+// WithAltScreen chooses whether the UI takes over the whole terminal, the
+// default. Inline, the UI is drawn below the prompt and its last state stays
+// in the scrollback after exit. Call it before the app is started.
+func (app *TerminalApp) WithAltScreen(enabled bool) *TerminalApp {
+	app.newProgram(enabled)
+	return app
+}
+
+// WithKeyMap replaces the default key bindings, which should already be validated
+func (app *TerminalApp) WithKeyMap(keys KeyMap) *TerminalApp {
+	app.model.keys = keys
+	return app
+}
+
+// WithLogFile also writes the log to w while the UI runs, e.g. a file from --log-file
+func (app *TerminalApp) WithLogFile(w io.Writer) *TerminalApp {
+	app.logFile = w
+	return app
+}
+
+// WithRegistersFile loads the registers from path and saves them there as they change
+func (app *TerminalApp) WithRegistersFile(path string) *TerminalApp {
+	registers, err := loadRegisters(path)
+	if err != nil {
+		log.Printf("Failed to load registers: %v", err)
+		registers = map[rune]string{}
+	}
+	app.model.registers = registers
+	app.model.registersFile = path
+	return app
+}
+
+// WithVoiceConfirm makes clearing and quitting wait for a spoken
+// confirmation. Unset fields take their defaults.
+func (app *TerminalApp) WithVoiceConfirm(opts VoiceConfirm) *TerminalApp {
+	defaults := DefaultVoiceConfirm()
+	if opts.Window <= 0 {
+		opts.Window = defaults.Window
+	}
+	if len(opts.Confirm) == 0 {
+		opts.Confirm = defaults.Confirm
+	}
+	if len(opts.Cancel) == 0 {
+		opts.Cancel = defaults.Cancel
+	}
+	app.model.voiceConfirm = &opts
+	return app
+}
+
+// WithTranscriber transcribes recordings with transcriber, e.g. an
+// EnsembleTranscriber, instead of the whisper service. The whisper service
+// still provides progress and health in the status bar.
+func (app *TerminalApp) WithTranscriber(transcriber speech.Transcriber) *TerminalApp {
+	app.model.transcriber = transcriber
+	return app
+}
+
+// WithWakeWord only dictates after a transcription starting with phrase,
+// e.g. "hey conch", until DefaultWakeTimeout passes without speech. Every
+// recording is still transcribed in full to look for the phrase.
+func (app *TerminalApp) WithWakeWord(phrase string) *TerminalApp {
+	app.model.wakeWord = normalizePhrase(phrase)
+	if app.model.wakeTimeout <= 0 {
+		app.model.wakeTimeout = DefaultWakeTimeout
+	}
+	return app
+}
+
+// WithWakeTimeout sets how long dictation stays awake after the wake word
+// and each transcription following it
+func (app *TerminalApp) WithWakeTimeout(timeout time.Duration) *TerminalApp {
+	if timeout > 0 {
+		app.model.wakeTimeout = timeout
+	}
+	return app
+}
+
+// WithRecallAutoCopy copies a history entry to the clipboard as soon as it is recalled by number
+func (app *TerminalApp) WithRecallAutoCopy(enabled bool) *TerminalApp {
+	app.model.recallAutoCopy = enabled
+	return app
+}
+
+// WithExportDir sets the directory session transcripts are saved to
+func (app *TerminalApp) WithExportDir(dir string) *TerminalApp {
+	app.model.exportDir = dir
+	return app
+}
+
+// WithExportParagraph joins exported transcriptions into a single paragraph
+// instead of writing one entry per line
+func (app *TerminalApp) WithExportParagraph(enabled bool) *TerminalApp {
+	app.model.exportParagraph = enabled
+	return app
+}
+
+// WithHistoryLimit bounds the history to entries transcriptions and maxChars
+// characters in total, evicting the oldest first. Zero leaves a bound off, so
+// both zero keeps the full session.
+func (app *TerminalApp) WithHistoryLimit(entries, maxChars int) *TerminalApp {
+	app.model.historyLimit = entries
+	app.model.historyMaxChars = maxChars
+	return app
+}
+
+// WithCompletionBell rings the terminal bell when a transcription arrives
+func (app *TerminalApp) WithCompletionBell(enabled bool) *TerminalApp {
+	app.model.completionBell = enabled
+	return app
+}
+
+// WithCompletionFlash briefly inverts the status bar when a transcription arrives
+func (app *TerminalApp) WithCompletionFlash(enabled bool) *TerminalApp {
+	app.model.completionFlash = enabled
+	return app
+}
+
+// WithOutputSinks sends copied text to sinks instead of the clipboard
+func (app *TerminalApp) WithOutputSinks(sinks ...OutputSink) *TerminalApp {
+	app.model.sinks = sinks
+	return app
+}
+
+// WithInterimCopy copies each transcription to the clipboard in the background
+// as soon as it arrives, so it's usually already copied when the user reacts
+func (app *TerminalApp) WithInterimCopy(enabled bool) *TerminalApp {
+	app.model.interimCopy = enabled
+	return app
+}
+
+// WithShowRTF shows the real-time factor of the latest transcription in the
+// status bar, to judge whether the model is fast enough for the hardware
+func (app *TerminalApp) WithShowRTF(enabled bool) *TerminalApp {
+	app.model.showRTF = enabled
+	return app
+}
+
+// WithCompact always draws the compact layout, a status line and the current
+// text, which is otherwise used below CompactWidth columns
+func (app *TerminalApp) WithCompact(enabled bool) *TerminalApp {
+	app.model.compact = enabled
+	return app
+}
+
+// WithRecordingDir saves each recording to dir as a WAV file with a .txt
+// sidecar holding its transcription, keeping only the most recent ones
+func (app *TerminalApp) WithRecordingDir(dir string) *TerminalApp {
+	app.model.archive = speech.NewRecordingArchive(dir)
+	return app
+}
+
+// WithClipboardNormalization rewrites text copied to the clipboard, e.g. to
+// plain ASCII punctuation. History keeps the text as transcribed.
+func (app *TerminalApp) WithClipboardNormalization(opts ClipboardNormalization) *TerminalApp {
+	app.model.clipboardNorm = opts
+	return app
+}
+
+// WithRecordOnly saves every recording to dir as a timestamped WAV file instead
+// of transcribing it. Voice memos are never pruned.
+func (app *TerminalApp) WithRecordOnly(dir string) *TerminalApp {
+	app.model.archive = speech.NewRecordingArchive(dir)
+	app.model.archive.MaxRecordings = 0
+	app.model.recordOnly = true
+	return app
+}
+
+// WithAutoFinalize accumulates transcriptions and copies them automatically once
+// no new speech has arrived for grace, then starts a fresh buffer. Zero disables it.
+func (app *TerminalApp) WithAutoFinalize(grace time.Duration) *TerminalApp {
+	app.model.autoFinalizeGrace = grace
+	return app
+}
+
+// WithTranscriptionUnavailable starts the UI in a degraded state where audio is
+// captured but not transcribed, showing err and how to fix it in a banner
+func (app *TerminalApp) WithTranscriptionUnavailable(err error) *TerminalApp {
+	app.model.transcriptionErr = err
+	return app
+}
+
+// WithModelLoading starts the UI on a loading screen that stays up until
+// WhisperStarted is called, for when the whisper server initializes in the background
+func (app *TerminalApp) WithModelLoading(enabled bool) *TerminalApp {
+	app.model.loadingModel = enabled
+	return app
+}
+
+// StartupProgress reports a whisper server readiness probe to the loading
+// screen. Its signature matches WhisperServerService.WithStartupProgress.
+func (app *TerminalApp) StartupProgress(attempt int, ready bool) {
+	app.program.Send(startupProgressMsg{attempt: attempt, ready: ready})
+}
+
+// WhisperStarted ends the loading screen once whisper initialization finishes.
+// A non-nil err puts the UI in the transcription unavailable state.
+func (app *TerminalApp) WhisperStarted(err error) {
+	app.program.Send(whisperReadyMsg{err: err})
+}
+
+// PushToTalk starts a recording when down is set and ends it otherwise, copying
+// the transcription to the clipboard. It is driven by a global hotkey.
+func (app *TerminalApp) PushToTalk(down bool) {
+	app.program.Send(pushToTalkMsg{down: down})
+}
+
+// Quit stops the terminal UI, making Run return
+func (app *TerminalApp) Quit() {
+	app.program.Quit()
+}
+
 // Run starts the terminal UI
 func (app *TerminalApp) Run() error {
 	// Start services if needed
 	if app.statusSvc != nil {
 		app.statusSvc.Start()
 	}
-	
+
+	// A log line written over the alt screen corrupts it, so the log goes to
+	// the overlay and the log file until the UI exits
+	var logOut io.Writer = app.model.logs
+	if app.logFile != nil {
+		logOut = io.MultiWriter(app.model.logs, app.logFile)
+	}
+	prevLog := log.Writer()
+	log.SetOutput(logOut)
+	defer log.SetOutput(prevLog)
+
 	// Start the tea program - this will block until the program exits
 	err := app.program.Start()
-	
+	app.model.exited.Store(true)
+
 	return err
 }
 
@@ -159,8 +612,13 @@ func (app *TerminalApp) Run() error {
 
 // Init implements tea.Model
 func (m *terminalModel) Init() tea.Cmd {
+	// Recordings aren't picked up until the model has loaded
+	if m.loadingModel {
+		return tea.Batch(m.startSpinner(), waitForDiscard(m.speechSvc), checkStatus(m))
+	}
 	return tea.Batch(
-		checkForRecording(m.speechSvc, m.whisperSvc),
+		checkForRecording(m.speechSvc),
+		waitForDiscard(m.speechSvc),
 		checkStatus(m),
 	)
 }
@@ -171,71 +629,334 @@ func (m *terminalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Only the help keys and Escape work while the overlay is open
+		if m.showHelp {
+			if msg.String() == "esc" || m.keys.action(msg.String()) == actionHelp {
+				m.showHelp = false
+			}
+			break
+		}
+		if m.showLogs {
+			if msg.String() == "esc" || m.keys.action(msg.String()) == actionLogs {
+				m.showLogs = false
+			}
+			break
+		}
+		if m.showTuning {
+			if cmd := m.handleTuningKey(msg.String()); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		// Any key but quit wakes capture paused for inactivity
+		if m.speechSvc.IsInactive() && m.keys.action(msg.String()) != actionQuit {
+			m.speechSvc.Resume()
+			m.showNotice("Resumed listening")
+			break
+		}
+
+		// A letter completes a register command
+		if pending := m.pendingRegister; pending != actionNone {
+			m.pendingRegister = actionNone
+			m.handleRegisterKey(pending, msg.String())
+			break
+		}
+
+		// Number keys are typed text in manual mode, only recall history in voice mode
+		if key := msg.String(); len(key) == 1 && key >= "1" && key <= "9" {
+			if m.mode == VoiceMode {
+				m.recallHistory(int(key[0] - '0'))
+			}
+			break
+		}
+
 		// Handle keyboard input
-		switch msg.String() {
-		case "ctrl+c":
-			// Exit on Ctrl+C (double tap for confirmation)
+		switch m.keys.action(msg.String()) {
+		case actionQuit:
+			// Exit on a double tap for confirmation
 			if time.Since(m.lastCtrlC) < time.Second {
 				return m, tea.Quit
 			}
 			m.lastCtrlC = time.Now()
-			m.statusMessage = "Press Ctrl+C again to exit"
-			
-		case "c", "C":
+			if m.voiceConfirm != nil {
+				cmds = append(cmds, m.requestConfirmation(actionQuit))
+				break
+			}
+			m.statusMessage = fmt.Sprintf("Press %s again to exit", keyLabel(m.keys.Quit))
+
+		case actionClear:
+			if m.voiceConfirm != nil {
+				cmds = append(cmds, m.requestConfirmation(actionClear))
+				break
+			}
 			// Clear clipboard text
 			m.clipboardText = ""
 			m.statusMessage = "Clipboard cleared"
-			
-		case "enter":
+
+		case actionSave:
+			// Like the number keys, the key is typed text in manual mode
+			if m.mode != VoiceMode {
+				break
+			}
+			// Save a snapshot of the session's transcriptions
+			path, err := m.exportTranscript(time.Now())
+			if err != nil {
+				m.statusMessage = fmt.Sprintf("Error saving transcript: %v", err)
+			} else {
+				m.statusMessage = "Saved transcript to " + path
+			}
+
+		case actionHelp:
+			m.showHelp = true
+
+		case actionLogs:
+			m.showLogs = true
+
+		case actionTune:
+			m.showTuning = true
+
+		case actionStoreRegister, actionRecallRegister:
+			m.pendingRegister = m.keys.action(msg.String())
+			m.showNotice("Register (a-z)?")
+
+		case actionReplay:
+			// Transcribe the last few seconds, whether or not VAD caught them
+			if m.speechSvc.RollingBuffer() == 0 {
+				m.showNotice("Rolling buffer is disabled (--rolling-buffer)")
+				break
+			}
+			m.statusMessage = "Transcribing rolling buffer..."
+			cmds = append(cmds, m.startSpinner(), transcribeRollingBuffer(m.speechSvc))
+
+		case actionCancel:
+			if err := m.speechSvc.CancelRecording(); err != nil {
+				m.showNotice("Not recording")
+			} else {
+				m.showNotice("Recording cancelled")
+			}
+
+		case actionPlay:
+			// Let the user hear what was captured for the last transcription
+			m.statusMessage = "Playing last recording..."
+			cmds = append(cmds, playLastRecording(m.speechSvc))
+
+		case actionCopy:
 			// Copy text to clipboard
 			if m.clipboardText != "" {
-				err := copyToClipboard(m.clipboardText)
+				err := m.copyClipboardText()
 				if err != nil {
-					m.statusMessage = fmt.Sprintf("Error copying to clipboard: %v", err)
+					m.statusMessage = fmt.Sprintf("Error copying to %s: %v", m.outputName(), err)
 				} else {
-					m.statusMessage = "Copied to clipboard"
-					// Add to transcriptions history
-					if len(m.transcriptions) == 0 || m.transcriptions[len(m.transcriptions)-1] != m.clipboardText {
-						m.transcriptions = append(m.transcriptions, m.clipboardText)
-						// Keep only the last 5 transcriptions
-						if len(m.transcriptions) > 5 {
-							m.transcriptions = m.transcriptions[len(m.transcriptions)-5:]
-						}
-					}
+					m.statusMessage = "Copied to " + m.outputName()
+					m.addToHistory(m.clipboardText)
 				}
 			}
 		}
-		
+
 	case statusUpdateMsg:
-		// Update status message
+		// Update status message, unless a notice is still showing
+		if time.Now().Before(m.noticeUntil) {
+			return m, nil
+		}
 		m.statusMessage = msg.text
 		return m, nil
-		
+
+	case pushToTalkMsg:
+		if msg.down {
+			m.speechSvc.BeginRecording()
+			m.statusMessage = "Recording (hotkey held)..."
+		} else {
+			m.speechSvc.EndRecording()
+			m.copyNextTranscription = true
+		}
+
+	case recordingDiscardedMsg:
+		m.copyNextTranscription = false
+		m.showNotice(fmt.Sprintf("Too short (%.2fs) — try again", msg.duration.Seconds()))
+		cmds = append(cmds, waitForDiscard(m.speechSvc))
+
 	case transcriptionMsg:
-		// Process the transcription
-		text := strings.TrimSpace(msg.text)
-		if text != "" {
-			// Set as clipboard text
-			m.clipboardText = text
-			
-			// Add to transcriptions if new
-			if len(m.transcriptions) == 0 || m.transcriptions[len(m.transcriptions)-1] != text {
-				m.transcriptions = append(m.transcriptions, text)
-				// Keep only the last 5 transcriptions
-				if len(m.transcriptions) > 5 {
-					m.transcriptions = m.transcriptions[len(m.transcriptions)-5:]
-				}
+		// The next thing said answers a pending confirmation, it isn't dictation
+		if m.pendingConfirm != actionNone {
+			m.stopSpinner()
+			cmds = append(cmds, m.resolveConfirmation(msg.text), checkForRecording(m.speechSvc))
+			break
+		}
+
+		// Without the wake word, nothing said is dictation
+		if m.wakeWord != "" {
+			var cmd tea.Cmd
+			msg.text, cmd = m.gateWakeWord(msg.text)
+			if cmd != nil {
+				cmds = append(cmds, cmd)
 			}
+			if strings.TrimSpace(msg.text) == "" {
+				m.stopSpinner()
+				cmds = append(cmds, checkForRecording(m.speechSvc))
+				break
+			}
+		}
+
+		if msg.language != "" {
+			m.detectedLanguage = msg.language
 		}
-		
+		m.lastRTF = msg.rtf
+		m.lastTranslation = msg.translation
+		cmds = append(cmds, m.applyTranscription(msg.text)...)
+		m.stopSpinner()
+
+		// A push-to-talk result goes straight to the clipboard
+		if m.copyNextTranscription {
+			m.copyNextTranscription = false
+			if m.clipboardText != "" {
+				cmds = append(cmds, m.copyCmd(copiedMsg{text: m.clipboardText, notice: "Copied to " + m.outputName()}))
+			}
+		}
+
 		// Continue checking for recordings
-		cmds = append(cmds, checkForRecording(m.speechSvc, m.whisperSvc))
-		
+		cmds = append(cmds, checkForRecording(m.speechSvc))
+
+	case playbackDoneMsg:
+		if errors.Is(msg.err, speech.ErrNoRecording) {
+			m.showNotice("Nothing recorded yet")
+		} else if msg.err != nil {
+			m.showNotice(fmt.Sprintf("Error playing recording: %v", msg.err))
+		} else {
+			m.statusMessage = "Playback finished"
+		}
+
+	case replayTranscriptionMsg:
+		// The recording loop is still running, so don't re-arm it here
+		m.stopSpinner()
+		if msg.err != nil {
+			m.showNotice(fmt.Sprintf("Error transcribing rolling buffer: %v", msg.err))
+			break
+		}
+		cmds = append(cmds, m.applyTranscription(msg.text)...)
+
+	case recordingMsg:
+		// Warn about clipping until a recording comes in clean
+		m.clipping = 0
+		if clipped := msg.audioData.ClippedFraction(); clipped > speech.ClipWarningFraction {
+			m.clipping = clipped
+		}
+
+		if m.recordOnly {
+			// Write the file off the UI goroutine and keep listening meanwhile
+			m.statusMessage = "Saving recording..."
+			cmds = append(cmds, saveRecording(m.archive, msg.audioData), checkForRecording(m.speechSvc))
+			break
+		}
+
+		if m.transcriptionErr != nil {
+			// Nothing to transcribe with, drop the recording and keep listening
+			m.statusMessage = "Transcription unavailable, recording discarded"
+			cmds = append(cmds, checkForRecording(m.speechSvc))
+			break
+		}
+
+		// Recording finished, animate the spinner while it is transcribed
+		cmds = append(cmds, m.startSpinner(), transcribeRecording(m.speechSvc, m.whisperSvc, m.transcriber, m.archive, &m.exited, msg.audioData))
+
+	case recordingSavedMsg:
+		if msg.err != nil {
+			m.showNotice(fmt.Sprintf("Error saving recording: %v", msg.err))
+			break
+		}
+		m.showNotice("Saved " + msg.path)
+		m.addToHistory(msg.path)
+
+	case startupProgressMsg:
+		m.loadingAttempt = msg.attempt
+		return m, nil
+
+	case whisperReadyMsg:
+		if !m.loadingModel {
+			return m, nil
+		}
+		m.loadingModel = false
+		m.transcriptionErr = msg.err
+		m.stopSpinner()
+		cmds = append(cmds, checkForRecording(m.speechSvc))
+
+	case flashEndMsg:
+		if msg.id == m.flashID {
+			m.flashActive = false
+		}
+		return m, nil
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.showNotice(fmt.Sprintf("Error copying to %s: %v", m.outputName(), msg.err))
+			return m, nil
+		}
+		// Keep whatever was transcribed while the copy ran
+		if msg.finalize {
+			m.clipboardText = strings.TrimPrefix(strings.TrimPrefix(m.clipboardText, msg.text), " ")
+		}
+		m.showNotice(msg.notice)
+		return m, nil
+
+	case interimCopyMsg:
+		// A newer copy superseded this one, or it was skipped
+		if msg.id != m.interimCopyID.Load() {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error copying to clipboard: %v", msg.err)
+		} else {
+			m.statusMessage = "Copied to clipboard"
+		}
+		return m, nil
+
+	case confirmTimeoutMsg:
+		if msg.id == m.confirmID && m.pendingConfirm != actionNone {
+			m.pendingConfirm = actionNone
+			m.showNotice("Confirmation timed out, nothing done")
+		}
+		return m, nil
+
+	case wakeTimeoutMsg:
+		return m, m.handleWakeTimeout(msg)
+
+	case autoFinalizeTickMsg:
+		// Ignore ticks from a countdown that was restarted or already finalized
+		if msg.id != m.autoFinalizeID || m.autoFinalizeDeadline.IsZero() {
+			return m, nil
+		}
+		// Speaking again isn't silence, hold the countdown until it ends
+		if m.speechSvc.IsRecording() || m.spinnerActive {
+			m.autoFinalizeDeadline = time.Now().Add(m.autoFinalizeGrace)
+		}
+		if time.Now().Before(m.autoFinalizeDeadline) {
+			return m, autoFinalizeTick(m.autoFinalizeID)
+		}
+		return m, m.finalizeBuffer()
+
+	case spinnerTickMsg:
+		// Ignore ticks from a previous spinner run
+		if !m.spinnerActive || msg.id != m.spinnerID {
+			return m, nil
+		}
+		m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+		return m, spinnerTick(m.spinnerID)
+
 	case errMsg:
-		m.statusMessage = "Error: " + msg.err.Error()
+		switch {
+		case errors.Is(msg.err, speech.ErrEmptyAudio):
+			// Nothing was said, so there is nothing to report
+			log.Printf("Skipped an empty recording")
+		case errors.Is(msg.err, speech.ErrServerGone):
+			m.statusMessage = "Whisper server crashed, restarting it"
+		default:
+			m.statusMessage = "Error: " + msg.err.Error()
+		}
+		m.stopSpinner()
+
 		// Continue checking for recordings
-		cmds = append(cmds, checkForRecording(m.speechSvc, m.whisperSvc))
-		
+		cmds = append(cmds, checkForRecording(m.speechSvc))
+
 	case tea.WindowSizeMsg:
 		// Update terminal size
 		m.width = msg.Width
@@ -244,7 +965,7 @@ func (m *terminalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Always check status updates
 	cmds = append(cmds, checkStatus(m))
-	
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -265,27 +986,65 @@ func (m *terminalModel) View() string {
 
 	// Status bar at top - full width
 	statusText := m.buildStatusText()
-	statusBar := m.styles.statusBar.Width(m.width).Padding(1, 0).Render(statusText)
+	if m.compactLayout() {
+		return m.buildCompactView(statusText)
+	}
+	statusStyle := m.styles.statusBar
+	if m.flashActive {
+		statusStyle = statusStyle.Reverse(true)
+	}
+	statusBar := statusStyle.Width(m.width).Padding(1, 0).Render(statusText)
 	view.WriteString(statusBar)
 	view.WriteString("\n\n")
-	
+
+	// Nothing else is usable until the model has loaded
+	if m.loadingModel {
+		view.WriteString(m.styles.container.Render(m.buildLoadingText()))
+		return view.String()
+	}
+
+	if m.showHelp {
+		view.WriteString(m.styles.container.Render(m.buildHelpView()))
+		return view.String()
+	}
+	if m.showLogs {
+		view.WriteString(m.styles.container.Render(m.buildLogView()))
+		return view.String()
+	}
+	if m.showTuning {
+		view.WriteString(m.styles.container.Render(m.buildTuningView()))
+		return view.String()
+	}
+
+	// Banner explaining why transcription is unavailable
+	if m.transcriptionErr != nil {
+		view.WriteString(m.styles.banner.Width(m.width).Render(m.buildUnavailableText()))
+		view.WriteString("\n\n")
+	}
+
 	// Main content: Transcription log (centered)
 	logView := m.buildTranscriptionLog()
 	centeredLog := m.styles.container.Render(logView)
 	view.WriteString(centeredLog)
 	view.WriteString("\n\n")
-	
+
 	// Current text area for clipboard (centered)
 	clipboardView := m.buildClipboardView()
 	centeredClipboard := m.styles.container.Render(clipboardView)
 	view.WriteString(centeredClipboard)
 	view.WriteString("\n\n")
-	
+
+	if registersView := m.buildRegistersView(); registersView != "" {
+		view.WriteString(m.styles.container.Render(registersView))
+		view.WriteString("\n\n")
+	}
+
 	// Instructions at bottom (centered)
-	instructions := "Press Enter to copy text to clipboard | Press 'c' to clear | Press Ctrl+C twice to exit"
+	instructions := fmt.Sprintf("Press %s to copy text to clipboard | Press %s to clear | Press %s twice to exit | Press %s for help",
+		keyLabel(m.keys.Copy), keyLabel(m.keys.Clear), keyLabel(m.keys.Quit), keyLabel(m.keys.Help))
 	centeredInstructions := m.styles.container.Render(m.styles.instructionText.Render(instructions))
 	view.WriteString(centeredInstructions)
-	
+
 	return view.String()
 }
 
@@ -293,55 +1052,204 @@ func (m *terminalModel) View() string {
 func (m *terminalModel) buildStatusText() string {
 	// Mode indicator
 	modeText := "🎤 VOICE MODE"
-	
+
 	// Add speech service status indicators
 	var statusIndicator string
-	if m.speechSvc.IsRecording() {
-		statusIndicator = "🔴 RECORDING"
-	} else if m.speechSvc.IsTranscribing() {
-		statusIndicator = "🔄 TRANSCRIBING"
-	} else if m.speechSvc.IsListening() {
-		statusIndicator = "🔊 LISTENING"
+	var listening bool
+	if m.loadingModel {
+		statusIndicator = spinnerFrames[m.spinnerFrame] + " LOADING MODEL"
+	} else if m.speechSvc.IsInactive() {
+		statusIndicator = "⏸️ PAUSED (inactive)"
+	} else if m.speechSvc.IsCaptureStalled(speech.CaptureStallTimeout) {
+		statusIndicator = "⚠️ NO AUDIO"
 	} else {
-		statusIndicator = "⏸️ IDLE"
+		switch state := m.speechSvc.State(); {
+		case state == speech.StateShuttingDown:
+			statusIndicator = "🛑 SHUTTING DOWN"
+		case state == speech.StateRecording:
+			statusIndicator = "🔴 RECORDING"
+		case m.spinnerActive || state == speech.StateTranscribing:
+			statusIndicator = spinnerFrames[m.spinnerFrame] + " TRANSCRIBING"
+			if progress := m.whisperSvc.Progress(); progress >= 0 {
+				statusIndicator += fmt.Sprintf(" %d%%", progress)
+			}
+		case state == speech.StateListening:
+			listening = true
+			statusIndicator = "🔊 LISTENING"
+		default:
+			statusIndicator = "⏸️ IDLE"
+		}
+	}
+
+	if m.detectedLanguage != "" {
+		statusIndicator += " | detected: " + m.detectedLanguage
+	}
+	if m.showRTF && m.lastRTF > 0 {
+		statusIndicator += fmt.Sprintf(" | RTF %.2gx", m.lastRTF)
 	}
-	
+	if m.clipping > 0 {
+		statusIndicator += fmt.Sprintf(" | ⚠️ input too loud — clipping %.1f%%", m.clipping*100)
+	}
+	if m.whisperSvc != nil {
+		if health := m.whisperSvc.Health(); !health.Checked.IsZero() && !health.Healthy {
+			statusIndicator += " | ⚠️ whisper server not responding"
+		}
+	}
+
+	if m.pendingConfirm != actionNone {
+		statusIndicator += " | " + m.confirmPrompt()
+	}
+	if m.wakeWord != "" && !m.awake {
+		statusIndicator += " | " + m.wakePrompt()
+	}
+
+	// Countdown while auto-finalize is armed
+	if !m.autoFinalizeDeadline.IsZero() {
+		remaining := time.Until(m.autoFinalizeDeadline).Round(time.Second)
+		statusIndicator += fmt.Sprintf(" | ⏱ auto-copy in %v", remaining)
+	}
+
+	// While listening, the indicator brightens as the input level approaches
+	// the voice threshold. Each part is styled so the bar's colors survive
+	// the indicator's reset.
+	if listening {
+		base := m.styles.statusBar.UnsetPadding()
+		return base.Render(modeText+" | ") +
+			base.Foreground(levelColor(m.speechSvc.CurrentLevel(), m.speechSvc.CurrentThreshold())).Render(statusIndicator) +
+			base.Render(" | "+m.statusMessage)
+	}
+
 	// Combine everything
 	return fmt.Sprintf("%s | %s | %s", modeText, statusIndicator, m.statusMessage)
 }
 
+// levelColor interpolates from dim grey at silence to the status bar's white
+// as level reaches the VAD threshold
+func levelColor(level, threshold int64) lipgloss.Color {
+	ratio := float64(level) / float64(max(threshold, 1))
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	const dim, bright = 0x88, 0xFF
+	c := dim + int(ratio*(bright-dim))
+	return lipgloss.Color(fmt.Sprintf("#%02X%02X%02X", c, c, c))
+}
+
+// buildLogView shows as many of the recent log lines as fit the window
+func (m *terminalModel) buildLogView() string {
+	var view strings.Builder
+	view.WriteString(m.styles.clipboardTitle.Render("Recent log messages"))
+	view.WriteString("\n\n")
+
+	// Leave room for the status bar, border, title and footer
+	lines := m.logs.tail(max(m.height-14, 1))
+	if len(lines) == 0 {
+		view.WriteString(m.styles.dimText.Render("Nothing logged yet"))
+		view.WriteString("\n")
+	}
+	for _, line := range lines {
+		view.WriteString(m.styles.normalText.Render(line))
+		view.WriteString("\n")
+	}
+
+	view.WriteString("\n")
+	view.WriteString(m.styles.dimText.Render(fmt.Sprintf("Press %s or Esc to close", keyLabel(m.keys.Logs))))
+	return m.styles.border.Render(view.String())
+}
+
+// buildHelpView lists every key binding from the keymap
+func (m *terminalModel) buildHelpView() string {
+	var help strings.Builder
+	help.WriteString(m.styles.clipboardTitle.Render("Key bindings"))
+	help.WriteString("\n\n")
+
+	line := func(keys, desc string) {
+		help.WriteString(m.styles.highlightText.Render(fmt.Sprintf("%-16s", keys)))
+		help.WriteString(m.styles.normalText.Render(desc))
+		help.WriteString("\n")
+	}
+	for _, b := range m.keys.bindings() {
+		// Keys differing only in case display the same
+		var names []string
+		seen := map[string]bool{}
+		for _, key := range b.keys {
+			if name := keyName(key); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		line(strings.Join(names, " / "), b.desc)
+	}
+	line("1-9", "Recall history (voice mode)")
+
+	help.WriteString("\n")
+	help.WriteString(m.styles.dimText.Render(fmt.Sprintf("Press %s or Esc to close", keyLabel(m.keys.Help))))
+	return m.styles.border.Render(help.String())
+}
+
+// buildLoadingText shows the spinner and probe count while the whisper server starts
+func (m *terminalModel) buildLoadingText() string {
+	text := spinnerFrames[m.spinnerFrame] + " Loading model..."
+	if m.loadingAttempt > 0 {
+		text += fmt.Sprintf(" (waiting for server, attempt %d)", m.loadingAttempt)
+	}
+	return m.styles.highlightText.Render(text)
+}
+
+// buildUnavailableText explains why transcription is unavailable and how to fix it
+func (m *terminalModel) buildUnavailableText() string {
+	text := "⚠️ Transcription unavailable: " + m.transcriptionErr.Error()
+	if errors.Is(m.transcriptionErr, speech.ErrServerNotFound) {
+		return text + "\nSet WHISPER_BIN to the path of your whisper-server binary and restart conch."
+	}
+	return text + "\nSee whisper-server.log for details and restart conch."
+}
+
 // buildTranscriptionLog creates the transcription log view
 func (m *terminalModel) buildTranscriptionLog() string {
 	var log strings.Builder
-	
+
 	// Title - make bigger and centered
 	log.WriteString(m.styles.title.Copy().Bold(true).Render("🐚 CONCH VOICE ASSISTANT 🐚"))
 	log.WriteString("\n\n")
-	
+
 	// History section
 	if len(m.transcriptions) > 1 {
-		log.WriteString(m.styles.historyTitle.Render("📜 Recent History"))
+		title := "📜 Recent History"
+		if m.historyEvicted > 0 {
+			title += fmt.Sprintf(" (%d older evicted)", m.historyEvicted)
+		}
+		log.WriteString(m.styles.historyTitle.Render(title))
 		log.WriteString("\n\n")
-		
-		// All transcriptions except the most recent
+
+		// All transcriptions except the most recent, numbered for recall
 		for i := 0; i < len(m.transcriptions)-1; i++ {
-			log.WriteString(m.styles.historyText.Width(60).Render(m.transcriptions[i]))
+			entry := fmt.Sprintf("[%d] %s", i+1, m.transcriptions[i])
+			log.WriteString(m.styles.historyText.Width(60).Render(entry))
 			log.WriteString("\n\n") // Extra spacing
 		}
 	}
-	
+
 	// Latest transcription - with more emphasis
 	if len(m.transcriptions) > 0 {
 		log.WriteString(m.styles.currentTitle.Render("🔊 Latest Transcription"))
 		log.WriteString("\n\n") // Extra space
-		log.WriteString(m.styles.transcriptText.Bold(true).Width(60).Render(m.transcriptions[len(m.transcriptions)-1]))
+		latest := fmt.Sprintf("[%d] %s", len(m.transcriptions), m.transcriptions[len(m.transcriptions)-1])
+		log.WriteString(m.styles.transcriptText.Bold(true).Width(60).Render(latest))
 		log.WriteString("\n")
+		if m.lastTranslation != "" {
+			log.WriteString(m.styles.dimText.Width(60).Render("EN: " + m.lastTranslation))
+			log.WriteString("\n")
+		}
 	} else {
 		// Show message when no transcriptions
 		log.WriteString(m.styles.dimText.Render("Waiting for speech..."))
 		log.WriteString("\n")
 	}
-	
+
 	// Wrap in a border
 	return m.styles.border.Render(log.String())
 }
@@ -349,11 +1257,11 @@ func (m *terminalModel) buildTranscriptionLog() string {
 // buildClipboardView creates the clipboard view
 func (m *terminalModel) buildClipboardView() string {
 	var clipboard strings.Builder
-	
+
 	// Title with icons
 	clipboard.WriteString(m.styles.clipboardTitle.Render("📋 Current Text"))
 	clipboard.WriteString("\n\n")
-	
+
 	// Text - make it more prominent and wider
 	if m.clipboardText != "" {
 		clipboard.WriteString(m.styles.clipboardText.Width(60).Render(m.clipboardText))
@@ -361,71 +1269,338 @@ func (m *terminalModel) buildClipboardView() string {
 		clipboard.WriteString(m.styles.dimText.Render("No text to copy"))
 	}
 	clipboard.WriteString("\n\n")
-	
+
 	// Add key instructions inside
-	instructions := "[Enter] Copy to clipboard | [C] Clear text"
+	instructions := fmt.Sprintf("[%s] Copy to clipboard | [%s] Clear text | [1-9] Recall history | [%s] Save session",
+		keyLabel(m.keys.Copy), keyLabel(m.keys.Clear), keyLabel(m.keys.Save))
+	if d := m.speechSvc.RollingBuffer(); d > 0 {
+		instructions += fmt.Sprintf(" | [%s] Transcribe last %s", keyLabel(m.keys.Replay), d)
+	}
 	clipboard.WriteString(m.styles.dimText.Render(instructions))
-	
+
 	// Wrap in a border
 	return m.styles.border.Render(clipboard.String())
 }
 
+// armAutoFinalize (re)starts the auto-finalize countdown and returns its first tick
+func (m *terminalModel) armAutoFinalize() tea.Cmd {
+	m.autoFinalizeID++
+	m.autoFinalizeDeadline = time.Now().Add(m.autoFinalizeGrace)
+	return autoFinalizeTick(m.autoFinalizeID)
+}
+
+// autoFinalizeTick schedules the next countdown check for the given countdown
+func autoFinalizeTick(id int) tea.Cmd {
+	return tea.Tick(autoFinalizeInterval, func(t time.Time) tea.Msg {
+		return autoFinalizeTickMsg{id: id}
+	})
+}
+
+// finalizeBuffer copies the accumulated text and starts a fresh buffer once
+// the copy succeeds
+func (m *terminalModel) finalizeBuffer() tea.Cmd {
+	m.autoFinalizeDeadline = time.Time{}
+	if m.clipboardText == "" {
+		return nil
+	}
+	return m.copyCmd(copiedMsg{text: m.clipboardText, notice: "Auto-copied to " + m.outputName(), finalize: true})
+}
+
+// addToHistory appends text unless it repeats the latest entry, then evicts
+// the oldest entries until the history is within its bounds again
+func (m *terminalModel) addToHistory(text string) {
+	if len(m.transcriptions) > 0 && m.transcriptions[len(m.transcriptions)-1] == text {
+		return
+	}
+	m.transcriptions = append(m.transcriptions, text)
+	m.historyChars += len(text)
+
+	// Always keep the newest entry, even if it alone exceeds the character bound
+	for len(m.transcriptions) > 1 &&
+		((m.historyLimit > 0 && len(m.transcriptions) > m.historyLimit) ||
+			(m.historyMaxChars > 0 && m.historyChars > m.historyMaxChars)) {
+		m.historyChars -= len(m.transcriptions[0])
+		m.transcriptions = m.transcriptions[1:]
+		m.historyEvicted++
+	}
+}
+
+// notifyCompletion rings the bell and starts the flash, as enabled
+func (m *terminalModel) notifyCompletion() tea.Cmd {
+	if m.completionBell {
+		io.WriteString(m.bellOut, "\a")
+	}
+	if !m.completionFlash {
+		return nil
+	}
+
+	m.flashActive = true
+	m.flashID++
+	id := m.flashID
+	return tea.Tick(flashDuration, func(t time.Time) tea.Msg {
+		return flashEndMsg{id: id}
+	})
+}
+
+// noticeDuration is how long a notice stays in the status bar
+const noticeDuration = 2 * time.Second
+
+// showNotice sets a status message that isn't immediately replaced by the status poll
+func (m *terminalModel) showNotice(text string) {
+	m.statusMessage = text
+	m.noticeUntil = time.Now().Add(noticeDuration)
+}
+
+// recallHistory loads the numbered history entry (1-based) into the clipboard buffer
+func (m *terminalModel) recallHistory(n int) {
+	if n < 1 || n > len(m.transcriptions) {
+		m.statusMessage = fmt.Sprintf("No history entry %d", n)
+		return
+	}
+
+	m.clipboardText = m.transcriptions[n-1]
+	if !m.recallAutoCopy {
+		m.statusMessage = fmt.Sprintf("Recalled entry %d", n)
+		return
+	}
+
+	if err := m.copyClipboardText(); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying to %s: %v", m.outputName(), err)
+	} else {
+		m.statusMessage = fmt.Sprintf("Recalled and copied entry %d", n)
+	}
+}
+
+// exportTranscript writes the session's transcriptions to a timestamped file
+// in the export directory and returns its path
+func (m *terminalModel) exportTranscript(now time.Time) (string, error) {
+	if len(m.transcriptions) == 0 {
+		return "", fmt.Errorf("nothing transcribed yet")
+	}
+
+	var content string
+	if m.exportParagraph {
+		content = strings.Join(m.transcriptions, " ") + "\n"
+	} else {
+		content = strings.Join(m.transcriptions, "\n") + "\n"
+	}
+
+	name := fmt.Sprintf("conch-transcript-%s.txt", now.Format("20060102-150405"))
+	path := filepath.Join(m.exportDir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 // Helper functions
 
-// copyToClipboard copies text to the system clipboard using pbcopy
-func copyToClipboard(text string) error {
-	cmd := exec.Command("pbcopy")
-	
-	// Connect stdin pipe
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("error creating stdin pipe: %w", err)
+// copyClipboardText sends the clipboard buffer, normalized for the target
+// application, to the output sinks
+func (m *terminalModel) copyClipboardText() error {
+	return sendOutput(m.sinks, normalizeForClipboard(m.clipboardText, m.clipboardNorm))
+}
+
+// copyCmd sends msg.text, normalized for the target application, to the
+// output sinks in the background, so a slow clipboard tool or sink can't
+// stall the UI, and reports back with msg
+func (m *terminalModel) copyCmd(msg copiedMsg) tea.Cmd {
+	sinks := m.sinks
+	text := normalizeForClipboard(msg.text, m.clipboardNorm)
+	return func() tea.Msg {
+		msg.err = sendOutput(sinks, text)
+		return msg
 	}
-	
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error starting pbcopy: %w", err)
+}
+
+// sendOutput writes text to sinks, or copies it to the clipboard without any
+func sendOutput(sinks []OutputSink, text string) error {
+	if len(sinks) == 0 {
+		return copyToClipboard(text)
 	}
-	
-	// Write the text to stdin
-	if _, err := io.WriteString(stdin, text); err != nil {
-		return fmt.Errorf("error writing to stdin: %w", err)
+	return writeSinks(sinks, text)
+}
+
+// outputName names where copyClipboardText sends text, for status messages
+func (m *terminalModel) outputName() string {
+	if len(m.sinks) == 0 || (len(m.sinks) == 1 && m.sinks[0] == OutputSink(ClipboardSink{})) {
+		return "clipboard"
 	}
-	
-	// Close stdin to signal we're done
-	if err := stdin.Close(); err != nil {
-		return fmt.Errorf("error closing stdin: %w", err)
+	return "output"
+}
+
+// interimCopyMu serializes background clipboard writes
+var interimCopyMu sync.Mutex
+
+// interimCopy copies text to the clipboard in the background. Copies run one
+// at a time, and one superseded by a newer transcription while waiting is
+// skipped so the clipboard never ends up holding stale text.
+func interimCopy(latest *atomic.Int32, text string) tea.Cmd {
+	id := latest.Add(1)
+	return func() tea.Msg {
+		interimCopyMu.Lock()
+		defer interimCopyMu.Unlock()
+
+		if id != latest.Load() {
+			return nil
+		}
+		return interimCopyMsg{id: id, err: copyToClipboard(text)}
 	}
-	
-	// Wait for the command to finish
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("error waiting for pbcopy: %w", err)
+}
+
+// startSpinner begins a new spinner tick chain and returns its first tick.
+// A spinner that is already running carries on from its current frame,
+// so overlapping transcriptions don't make it jump back or spin faster.
+func (m *terminalModel) startSpinner() tea.Cmd {
+	if m.spinnerActive {
+		return nil
 	}
-	
-	return nil
+	m.spinnerActive = true
+	m.spinnerFrame = 0
+	m.spinnerID++
+	return spinnerTick(m.spinnerID)
+}
+
+// stopSpinner stops the spinner, any outstanding tick is ignored
+func (m *terminalModel) stopSpinner() {
+	m.spinnerActive = false
+}
+
+// spinnerTick schedules the next spinner frame for the given spinner run
+func spinnerTick(id int) tea.Cmd {
+	return tea.Tick(spinnerInterval, func(t time.Time) tea.Msg {
+		return spinnerTickMsg{id: id}
+	})
 }
 
-// checkForRecording checks for audio recording and transcribes it
-func checkForRecording(speechSvc *speech.SpeechService, whisperSvc *speech.WhisperServerService) tea.Cmd {
+// checkForRecording waits for the next audio recording
+func checkForRecording(speechSvc *speech.SpeechService) tea.Cmd {
 	return func() tea.Msg {
 		// Wait for audio recording with timeout
 		audioData, err := speechSvc.WaitForRecording()
 		if err != nil {
-			if err.Error() == "service is shutting down" {
+			if errors.Is(err, speech.ErrShuttingDown) {
 				return nil
 			}
+			// No recordings will arrive until listening resumes, so stop polling
+			if errors.Is(err, speech.ErrNotListening) || errors.Is(err, speech.ErrListeningStopped) {
+				return statusUpdateMsg{text: "Listening stopped"}
+			}
+			if errors.Is(err, speech.ErrCaptureFailed) {
+				return statusUpdateMsg{text: "⚠️ " + err.Error() + ", see the logs"}
+			}
 			return errMsg{err}
 		}
 
-		// Transcribe the audio
-		result, err := whisperSvc.Transcribe(audioData)
+		return recordingMsg{audioData: audioData}
+	}
+}
+
+// waitForDiscard waits for the next recording dropped as too short
+func waitForDiscard(speechSvc *speech.SpeechService) tea.Cmd {
+	return func() tea.Msg {
+		return recordingDiscardedMsg{duration: <-speechSvc.RecordingDiscarded()}
+	}
+}
+
+// saveRecording saves a recording to archive without transcribing it
+func saveRecording(archive *speech.RecordingArchive, audioData *speech.AudioData) tea.Cmd {
+	return func() tea.Msg {
+		path, err := archive.SaveAudio(audioData, time.Now())
+		return recordingSavedMsg{path: path, err: err}
+	}
+}
+
+// transcribeRecording transcribes a finished recording, saving it to archive if set.
+// The speech service reports transcribing meanwhile so status displays can show it.
+// A transcription finishing after the UI exited is logged instead of lost.
+func transcribeRecording(speechSvc *speech.SpeechService, whisperSvc *speech.WhisperServerService, transcriber speech.Transcriber, archive *speech.RecordingArchive, exited *atomic.Bool, audioData *speech.AudioData) tea.Cmd {
+	if transcriber == nil {
+		transcriber = whisperSvc
+	}
+	return func() tea.Msg {
+		speechSvc.SetTranscribing(true)
+		result, err := transcriber.Transcribe(audioData)
+		speechSvc.SetTranscribing(false)
+
+		// Failed transcriptions are the most interesting ones to keep, so save the error too
+		if archive != nil {
+			var sidecar string
+			if err != nil {
+				sidecar = "error: " + err.Error()
+			} else {
+				sidecar = strings.TrimSpace(result.Text)
+			}
+			if _, saveErr := archive.Save(audioData, sidecar, time.Now()); saveErr != nil {
+				log.Printf("Warning: %v", saveErr)
+			}
+		}
+
 		if err != nil {
 			return errMsg{err}
 		}
 
 		// Clean up the text
 		text := strings.TrimSpace(result.Text)
-		return transcriptionMsg{text: text}
+		if exited.Load() && text != "" {
+			log.Printf("Transcribed during shutdown: %s", text)
+		}
+		// Only report the language when whisper actually detected it
+		var language string
+		if whisperSvc.DetectsLanguage() {
+			language = result.Language
+		}
+		return transcriptionMsg{text: text, language: language, rtf: result.RTF(), translation: result.Translation}
+	}
+}
+
+// applyTranscription adds transcribed text to the clipboard buffer and history
+func (m *terminalModel) applyTranscription(text string) []tea.Cmd {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	// Set as clipboard text, or append to the buffer until it is finalized
+	if m.autoFinalizeGrace > 0 && m.clipboardText != "" {
+		m.clipboardText += " " + text
+	} else {
+		m.clipboardText = text
+	}
+
+	// Each new utterance restarts the silence countdown
+	if m.autoFinalizeGrace > 0 {
+		cmds = append(cmds, m.armAutoFinalize())
+	}
+
+	// Let a user who looked away know there's something new
+	cmds = append(cmds, m.notifyCompletion())
+
+	// Pre-stage the clipboard off the UI goroutine
+	if m.interimCopy {
+		cmds = append(cmds, interimCopy(&m.interimCopyID, normalizeForClipboard(m.clipboardText, m.clipboardNorm)))
+	}
+
+	m.addToHistory(text)
+	return cmds
+}
+
+// playLastRecording plays back the speech service's last recording
+func playLastRecording(speechSvc *speech.SpeechService) tea.Cmd {
+	return func() tea.Msg {
+		return playbackDoneMsg{err: speechSvc.PlayLastRecording()}
+	}
+}
+
+// transcribeRollingBuffer transcribes the speech service's rolling buffer
+func transcribeRollingBuffer(speechSvc *speech.SpeechService) tea.Cmd {
+	return func() tea.Msg {
+		result, err := speechSvc.TranscribeRollingBuffer()
+		if err != nil {
+			return replayTranscriptionMsg{err: err}
+		}
+		return replayTranscriptionMsg{text: result.Text}
 	}
 }
 
@@ -433,18 +1608,25 @@ func checkForRecording(speechSvc *speech.SpeechService, whisperSvc *speech.Whisp
 func checkStatus(m *terminalModel) tea.Cmd {
 	return tea.Tick(250*time.Millisecond, func(t time.Time) tea.Msg {
 		var status string
-		
+
 		// Get status from speech service
-		if m.speechSvc.IsRecording() {
-			status = "Recording audio..."
-		} else if m.speechSvc.IsTranscribing() {
-			status = "Transcribing audio..."
-		} else if m.speechSvc.IsListening() {
-			status = "Listening for speech..."
+		if m.speechSvc.IsInactive() {
+			status = "Press any key to resume listening"
 		} else {
-			status = "Ready"
+			switch m.speechSvc.State() {
+			case speech.StateShuttingDown:
+				status = "Shutting down..."
+			case speech.StateRecording:
+				status = "Recording audio..."
+			case speech.StateTranscribing:
+				status = "Transcribing audio..."
+			case speech.StateListening:
+				status = "Listening for speech..."
+			default:
+				status = "Ready"
+			}
 		}
-		
+
 		return statusUpdateMsg{text: status}
 	})
 }
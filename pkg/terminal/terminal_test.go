@@ -0,0 +1,371 @@
+package terminal
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/marcinja/conch/pkg/speech"
+)
+
+// TestAddToHistoryBounds verifies the entry and character bounds evict oldest first
+func TestAddToHistoryBounds(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		maxChars    int
+		add         []string
+		want        []string
+		wantEvicted int
+	}{
+		{"entry limit", 2, 0, []string{"one", "two", "three"}, []string{"two", "three"}, 1},
+		{"char limit", 0, 8, []string{"aaaa", "bbbb", "cccc"}, []string{"bbbb", "cccc"}, 1},
+		{"both limits", 3, 10, []string{"a", "b", "cccccccccc"}, []string{"cccccccccc"}, 2},
+		{"newest kept when oversized", 0, 3, []string{"ab", "abcdef"}, []string{"abcdef"}, 1},
+		{"repeats skipped", 0, 0, []string{"same", "same", "other"}, []string{"same", "other"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &terminalModel{historyLimit: tt.limit, historyMaxChars: tt.maxChars}
+			for _, text := range tt.add {
+				m.addToHistory(text)
+			}
+
+			if !reflect.DeepEqual(m.transcriptions, tt.want) {
+				t.Errorf("Expected history %q, got %q", tt.want, m.transcriptions)
+			}
+			if m.historyEvicted != tt.wantEvicted {
+				t.Errorf("Expected %d evictions, got %d", tt.wantEvicted, m.historyEvicted)
+			}
+		})
+	}
+}
+
+// TestRecordingDiscardedNotice verifies the too-short notice survives the status poll
+func TestRecordingDiscardedNotice(t *testing.T) {
+	m := &terminalModel{}
+
+	m.Update(recordingDiscardedMsg{duration: 180 * time.Millisecond})
+	m.Update(statusUpdateMsg{text: "Listening for speech..."})
+
+	if m.statusMessage != "Too short (0.18s) — try again" {
+		t.Errorf("Expected the too-short notice to be kept, got %q", m.statusMessage)
+	}
+
+	// Once the notice expires the poll takes over again
+	m.noticeUntil = time.Now()
+	m.Update(statusUpdateMsg{text: "Listening for speech..."})
+	if m.statusMessage != "Listening for speech..." {
+		t.Errorf("Expected status poll to resume after the notice, got %q", m.statusMessage)
+	}
+}
+
+// TestCompletionNotification verifies the bell and flash fire only for non-empty transcriptions
+func TestCompletionNotification(t *testing.T) {
+	var bell bytes.Buffer
+	m := &terminalModel{completionBell: true, completionFlash: true, bellOut: &bell}
+
+	m.Update(transcriptionMsg{text: "   "})
+	if bell.Len() != 0 || m.flashActive {
+		t.Fatalf("Expected no notification for an empty transcription")
+	}
+
+	m.Update(transcriptionMsg{text: "hello"})
+	if bell.String() != "\a" {
+		t.Errorf("Expected a bell, got %q", bell.String())
+	}
+	if !m.flashActive {
+		t.Fatalf("Expected the status bar to flash")
+	}
+
+	// A tick from an earlier flash doesn't end the current one
+	m.Update(flashEndMsg{id: m.flashID - 1})
+	if !m.flashActive {
+		t.Errorf("Expected a stale tick to be ignored")
+	}
+	m.Update(flashEndMsg{id: m.flashID})
+	if m.flashActive {
+		t.Errorf("Expected the flash to end")
+	}
+}
+
+// TestHelpOverlay verifies the overlay swallows other keys and closes on its key or Escape
+func TestHelpOverlay(t *testing.T) {
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), clipboardText: "keep"}
+	press := func(key string) {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+
+	press("?")
+	if !m.showHelp {
+		t.Fatal("Expected ? to open the help overlay")
+	}
+	if view := m.buildHelpView(); !strings.Contains(view, "Transcribe the rolling buffer") || !strings.Contains(view, "C ") {
+		t.Errorf("Expected every binding in the overlay, got:\n%s", view)
+	}
+
+	press("c")
+	if m.clipboardText != "keep" || !m.showHelp {
+		t.Error("Expected keys to be ignored while the overlay is open")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showHelp {
+		t.Error("Expected Escape to close the overlay")
+	}
+
+	press("?")
+	press("?")
+	if m.showHelp {
+		t.Error("Expected ? to toggle the overlay closed")
+	}
+}
+
+// TestCancelKey verifies Escape outside the help overlay cancels the recording
+func TestCancelKey(t *testing.T) {
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap()}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.statusMessage != "Not recording" {
+		t.Errorf("Expected a not recording notice, got %q", m.statusMessage)
+	}
+}
+
+// TestSpinnerRestart verifies starting a running spinner keeps its frame and tick chain
+func TestSpinnerRestart(t *testing.T) {
+	m := &terminalModel{}
+	if m.startSpinner() == nil {
+		t.Fatal("Expected a first tick")
+	}
+	m.Update(spinnerTickMsg{id: m.spinnerID})
+	m.Update(spinnerTickMsg{id: m.spinnerID})
+
+	id := m.spinnerID
+	if m.startSpinner() != nil || m.spinnerID != id || m.spinnerFrame != 2 {
+		t.Errorf("Expected the running spinner to continue, got frame %d id %d", m.spinnerFrame, m.spinnerID)
+	}
+
+	m.stopSpinner()
+	if m.startSpinner() == nil || m.spinnerFrame != 0 {
+		t.Error("Expected a stopped spinner to restart from the first frame")
+	}
+}
+
+// TestLevelColor verifies the listening indicator brightens up to the VAD threshold
+func TestLevelColor(t *testing.T) {
+	tests := []struct {
+		level int64
+		want  lipgloss.Color
+	}{
+		{0, "#888888"},
+		{speech.VadThreshold / 2, "#C3C3C3"},
+		{speech.VadThreshold, "#FFFFFF"},
+		{speech.VadThreshold * 10, "#FFFFFF"},
+	}
+	for _, tt := range tests {
+		if got := levelColor(tt.level, speech.VadThreshold); got != tt.want {
+			t.Errorf("levelColor(%d) = %s, want %s", tt.level, got, tt.want)
+		}
+	}
+}
+
+// TestVoiceConfirm verifies clear waits for a spoken confirmation, and that
+// anything but a confirm phrase or a timeout aborts it
+func TestVoiceConfirm(t *testing.T) {
+	opts := DefaultVoiceConfirm()
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), voiceConfirm: &opts}
+	clear := func() {
+		m.clipboardText = "keep"
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		if m.clipboardText != "keep" || m.pendingConfirm != actionClear {
+			t.Fatal("Expected clear to wait for confirmation")
+		}
+		if status := m.buildStatusText(); !strings.Contains(status, `say "yes" to clear`) {
+			t.Errorf("Expected a confirmation prompt, got %q", status)
+		}
+	}
+
+	clear()
+	m.Update(transcriptionMsg{text: " Yes."})
+	if m.clipboardText != "" || m.pendingConfirm != actionNone {
+		t.Errorf("Expected a spoken yes to clear, got %q", m.clipboardText)
+	}
+
+	for _, answer := range []string{"No!", "yes, but actually keep it"} {
+		clear()
+		m.Update(transcriptionMsg{text: answer})
+		if m.clipboardText != "keep" || m.pendingConfirm != actionNone {
+			t.Errorf("Expected %q to abort the clear", answer)
+		}
+		if len(m.transcriptions) != 0 {
+			t.Errorf("Expected the answer not to be treated as dictation, got %q", m.transcriptions)
+		}
+	}
+
+	clear()
+	m.Update(confirmTimeoutMsg{id: m.confirmID})
+	if m.clipboardText != "keep" || m.pendingConfirm != actionNone {
+		t.Error("Expected the confirmation to time out without clearing")
+	}
+}
+
+// TestWakeWord verifies transcriptions are dropped until one starts with the
+// wake word, and that dictation goes back to sleep after the timeout
+func TestWakeWord(t *testing.T) {
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), wakeWord: normalizePhrase("Hey conch"), wakeTimeout: time.Minute}
+
+	m.Update(transcriptionMsg{text: "Write this down."})
+	if len(m.transcriptions) != 0 || m.awake {
+		t.Fatalf("Expected speech before the wake word to be dropped, got %q", m.transcriptions)
+	}
+	if status := m.buildStatusText(); !strings.Contains(status, `say "hey conch" to dictate`) {
+		t.Errorf("Expected a wake word hint, got %q", status)
+	}
+
+	m.Update(transcriptionMsg{text: "Hey, Conch!"})
+	if !m.awake || len(m.transcriptions) != 0 {
+		t.Fatalf("Expected the wake word alone to wake dictation without text, got %q", m.transcriptions)
+	}
+	m.Update(transcriptionMsg{text: "First sentence."})
+	if len(m.transcriptions) != 1 || m.transcriptions[0] != "First sentence." {
+		t.Errorf("Expected dictation while awake, got %q", m.transcriptions)
+	}
+
+	m.Update(wakeTimeoutMsg{id: m.wakeID - 1})
+	if !m.awake {
+		t.Error("Expected a stale timeout to be ignored")
+	}
+	m.Update(wakeTimeoutMsg{id: m.wakeID})
+	if m.awake {
+		t.Fatal("Expected dictation to sleep after the timeout")
+	}
+
+	m.Update(transcriptionMsg{text: "hey conch, second sentence"})
+	if !m.awake || len(m.transcriptions) != 2 || m.transcriptions[1] != "second sentence" {
+		t.Errorf("Expected the rest of the waking utterance to be dictated, got %q", m.transcriptions)
+	}
+}
+
+// TestCompactLayout verifies narrow terminals get the compact layout, and
+// that resizing switches between the layouts
+func TestCompactLayout(t *testing.T) {
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), clipboardText: "hello world", width: 100, height: 30}
+
+	m.Update(tea.WindowSizeMsg{Width: 40, Height: 20})
+	view := m.View()
+	if !strings.Contains(view, "hello world") || strings.Contains(view, "Current Text") {
+		t.Errorf("Expected the compact layout with the current text, got:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if width := lipgloss.Width(line); width > 40 {
+			t.Errorf("Expected lines to fit 40 columns, got %d: %q", width, line)
+		}
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if view := m.View(); !strings.Contains(view, "Current Text") {
+		t.Errorf("Expected the full layout once widened, got:\n%s", view)
+	}
+
+	m.compact = true
+	if view := m.View(); strings.Contains(view, "Current Text") {
+		t.Errorf("Expected the compact layout when forced, got:\n%s", view)
+	}
+}
+
+// TestTuningOverlay verifies the tuning keys move the threshold and other keys are ignored
+func TestTuningOverlay(t *testing.T) {
+	svc := speech.NewSpeechService()
+	m := &terminalModel{speechSvc: svc, keys: DefaultKeyMap(), clipboardText: "keep"}
+	press := func(key string) {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+
+	press("v")
+	if !m.showTuning {
+		t.Fatal("Expected v to open the tuning overlay")
+	}
+	press("+")
+	if got := svc.CurrentThreshold(); got != speech.VadThreshold+10 {
+		t.Errorf("Expected the threshold raised to %d, got %d", speech.VadThreshold+10, got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if got := svc.CurrentThreshold(); got != 99 {
+		t.Errorf("Expected the threshold lowered to 99, got %d", got)
+	}
+	if view := m.buildTuningView(); !strings.Contains(view, "--vad-threshold 99") {
+		t.Errorf("Expected the suggested flag in the overlay, got:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := svc.InputGain(); got != 1.5625 {
+		t.Errorf("Expected the gain raised to 1.5625, got %v", got)
+	}
+	if view := m.buildTuningView(); !strings.Contains(view, "--gain 1.56") {
+		t.Errorf("Expected the gain in the suggested flags, got:\n%s", view)
+	}
+
+	var out bytes.Buffer
+	m.sinks = []OutputSink{&WriterSink{W: &out}}
+	m.Update(m.handleTuningKey("y")())
+	if out.String() != "--vad-threshold 99 --gain 1.56\n" || m.statusMessage != "Copied --vad-threshold 99 --gain 1.56" {
+		t.Errorf("Expected y to copy the suggested flags to the sink, got %q, %q", out.String(), m.statusMessage)
+	}
+
+	press("c")
+	if m.clipboardText != "keep" {
+		t.Error("Expected keys to be ignored while the overlay is open")
+	}
+	press("v")
+	if m.showTuning {
+		t.Error("Expected v to close the overlay")
+	}
+}
+
+// TestFinalizeBufferAsync verifies auto-copy runs as a command and keeps text
+// transcribed while the copy was running
+func TestFinalizeBufferAsync(t *testing.T) {
+	var out bytes.Buffer
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), clipboardText: "first", sinks: []OutputSink{&WriterSink{W: &out}}}
+
+	cmd := m.finalizeBuffer()
+	if cmd == nil || m.clipboardText != "first" {
+		t.Fatalf("Expected a copy command with the buffer kept until it's done, got %q", m.clipboardText)
+	}
+	msg := cmd()
+	if out.String() != "first\n" {
+		t.Errorf("Expected the buffer sent to the sink, got %q", out.String())
+	}
+
+	m.clipboardText += " second"
+	m.Update(msg)
+	if m.clipboardText != "second" || m.statusMessage != "Auto-copied to output" {
+		t.Errorf("Expected only the copied text dropped, got %q, %q", m.clipboardText, m.statusMessage)
+	}
+}
+
+// TestSaveKeyVoiceModeOnly verifies the save key only saves in voice mode
+func TestSaveKeyVoiceModeOnly(t *testing.T) {
+	m := &terminalModel{speechSvc: speech.NewSpeechService(), keys: DefaultKeyMap(), mode: ManualMode, exportDir: t.TempDir()}
+	m.addToHistory("hello")
+	press := func() {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	}
+
+	press()
+	if m.statusMessage != "" {
+		t.Errorf("Expected s to do nothing in manual mode, got %q", m.statusMessage)
+	}
+
+	m.mode = VoiceMode
+	press()
+	if !strings.HasPrefix(m.statusMessage, "Saved transcript to ") {
+		t.Errorf("Expected s to save in voice mode, got %q", m.statusMessage)
+	}
+}
@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuningMeterWidth is the width of the level meter in the tuning overlay,
+// which spans twice the threshold
+const tuningMeterWidth = 40
+
+// gainStep is the factor each gain key press raises or lowers the input gain by
+const gainStep = 1.25
+
+// handleTuningKey handles a key while the VAD tuning overlay is open
+func (m *terminalModel) handleTuningKey(key string) tea.Cmd {
+	switch {
+	case key == "esc" || m.keys.action(key) == actionTune:
+		m.showTuning = false
+	case key == "up" || key == "+" || key == "=":
+		m.nudgeThreshold(1)
+	case key == "down" || key == "-":
+		m.nudgeThreshold(-1)
+	case key == "right" || key == "]":
+		m.speechSvc.SetInputGain(m.speechSvc.InputGain() * gainStep)
+		m.statusMessage = fmt.Sprintf("Input gain %.2fx", m.speechSvc.InputGain())
+	case key == "left" || key == "[":
+		m.speechSvc.SetInputGain(m.speechSvc.InputGain() / gainStep)
+		m.statusMessage = fmt.Sprintf("Input gain %.2fx", m.speechSvc.InputGain())
+	case key == "y" || key == "Y":
+		flags := m.suggestedThresholdFlag()
+		return m.copyCmd(copiedMsg{text: flags, notice: "Copied " + flags})
+	}
+	return nil
+}
+
+// nudgeThreshold moves the VAD threshold by about 10% in direction
+func (m *terminalModel) nudgeThreshold(direction int64) {
+	threshold := m.speechSvc.CurrentThreshold()
+	m.speechSvc.SetVadThreshold(threshold + direction*max(threshold/10, 5))
+	m.statusMessage = fmt.Sprintf("VAD threshold %d", m.speechSvc.CurrentThreshold())
+}
+
+// suggestedThresholdFlag is the command line flags for the current threshold
+// and, when it isn't unity, the current gain
+func (m *terminalModel) suggestedThresholdFlag() string {
+	flags := fmt.Sprintf("--vad-threshold %d", m.speechSvc.CurrentThreshold())
+	if gain := m.speechSvc.InputGain(); gain != 1 {
+		flags += fmt.Sprintf(" --gain %.2f", gain)
+	}
+	return flags
+}
+
+// buildTuningView shows the live level against the threshold, the noise
+// floor, the input gain and recent triggers, for tuning them interactively
+func (m *terminalModel) buildTuningView() string {
+	level := m.speechSvc.CurrentLevel()
+	threshold := m.speechSvc.CurrentThreshold()
+
+	var view strings.Builder
+	view.WriteString(m.styles.clipboardTitle.Render("VAD tuning"))
+	view.WriteString("\n\n")
+
+	// The meter is full at twice the threshold, which sits at its middle
+	filled := min(int(level*tuningMeterWidth/(2*max(threshold, 1))), tuningMeterWidth)
+	meter := []rune(strings.Repeat("█", filled) + strings.Repeat("░", tuningMeterWidth-filled))
+	meter[tuningMeterWidth/2] = '|'
+	view.WriteString(m.styles.normalText.Render("Level      "))
+	view.WriteString(m.styles.normalText.Foreground(levelColor(level, threshold)).Render(string(meter)))
+	view.WriteString(m.styles.normalText.Render(fmt.Sprintf("  %d", level)))
+	view.WriteString("\n")
+	view.WriteString(m.styles.normalText.Render(fmt.Sprintf("Threshold  %d    Noise floor  %d    Gain  %.2fx", threshold, m.speechSvc.NoiseFloor(), m.speechSvc.InputGain())))
+	view.WriteString("\n\n")
+
+	view.WriteString(m.styles.highlightText.Render("Recent triggers"))
+	view.WriteString("\n")
+	events := m.speechSvc.RecentVadEvents()
+	if len(events) == 0 {
+		view.WriteString(m.styles.dimText.Render("None yet, try speaking"))
+		view.WriteString("\n")
+	}
+	for _, event := range events {
+		what := "■ ended  "
+		if event.Started {
+			what = "▶ started"
+		}
+		view.WriteString(m.styles.normalText.Render(fmt.Sprintf("%s  %s  level %d", event.Time.Format("15:04:05"), what, event.Level)))
+		view.WriteString("\n")
+	}
+
+	view.WriteString("\n")
+	view.WriteString(m.styles.normalText.Render("Suggested flag: "))
+	view.WriteString(m.styles.highlightText.Render(m.suggestedThresholdFlag()))
+	view.WriteString("\n\n")
+	view.WriteString(m.styles.dimText.Render(fmt.Sprintf("[↑/+] Raise | [↓/-] Lower | [←/→] Gain | [Y] Copy flags | [%s/Esc] Close", keyLabel(m.keys.Tune))))
+	return m.styles.border.Render(view.String())
+}
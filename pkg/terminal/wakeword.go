@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultWakeTimeout is how long dictation stays awake after the last
+// transcription before the wake word is needed again
+const DefaultWakeTimeout = 30 * time.Second
+
+// wakeTimeoutMsg puts dictation to sleep unless something was transcribed
+// since the timeout with the given id was armed
+type wakeTimeoutMsg struct {
+	id int
+}
+
+// gateWakeWord passes a transcription through the wake word gate, returning
+// the text to dictate, empty if there is none. While asleep, a transcription
+// only wakes dictation if it starts with the wake word, and the rest of it is
+// dictated. Every transcription while awake re-arms the timeout.
+func (m *terminalModel) gateWakeWord(text string) (string, tea.Cmd) {
+	if !m.awake {
+		rest, ok := afterWakeWord(text, m.wakeWord)
+		if !ok {
+			return "", nil
+		}
+		m.awake = true
+		m.showNotice("Awake, dictating")
+		text = rest
+	}
+	return text, m.armWakeTimeout()
+}
+
+// armWakeTimeout restarts the countdown back to sleep
+func (m *terminalModel) armWakeTimeout() tea.Cmd {
+	m.wakeID++
+	id := m.wakeID
+	return tea.Tick(m.wakeTimeout, func(time.Time) tea.Msg {
+		return wakeTimeoutMsg{id: id}
+	})
+}
+
+// handleWakeTimeout puts dictation to sleep, or waits another timeout if the
+// user is still talking or being transcribed
+func (m *terminalModel) handleWakeTimeout(msg wakeTimeoutMsg) tea.Cmd {
+	if msg.id != m.wakeID || !m.awake {
+		return nil
+	}
+	if m.speechSvc.IsRecording() || m.spinnerActive {
+		return m.armWakeTimeout()
+	}
+	m.awake = false
+	m.showNotice("Asleep, say the wake word to dictate")
+	return nil
+}
+
+// wakePrompt is the status bar hint while dictation is asleep
+func (m *terminalModel) wakePrompt() string {
+	return "💤 say \"" + m.wakeWord + "\" to dictate"
+}
+
+// afterWakeWord reports whether text starts with wake, ignoring case and
+// punctuation, and returns the text after it
+func afterWakeWord(text, wake string) (string, bool) {
+	rest := text
+	for _, word := range strings.Fields(wake) {
+		rest = strings.TrimLeftFunc(rest, notWordRune)
+		end := strings.IndexFunc(rest, notWordRune)
+		if end < 0 {
+			end = len(rest)
+		}
+		if strings.ToLower(rest[:end]) != word {
+			return "", false
+		}
+		rest = rest[end:]
+	}
+	return strings.TrimLeftFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}), true
+}